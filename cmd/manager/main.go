@@ -15,6 +15,7 @@ import (
 
 	"github.com/JAORMX/compliance-profile-operator/pkg/apis"
 	"github.com/JAORMX/compliance-profile-operator/pkg/controller"
+	"github.com/JAORMX/compliance-profile-operator/pkg/webhook"
 	"github.com/JAORMX/compliance-profile-operator/version"
 
 	"github.com/operator-framework/operator-sdk/pkg/k8sutil"
@@ -57,6 +58,11 @@ func main() {
 	// controller-runtime)
 	pflag.CommandLine.AddGoFlagSet(flag.CommandLine)
 
+	enableWebhooks := pflag.Bool("enable-webhooks", false, "Serve the admission webhooks for the operator's resources")
+	webhookPort := pflag.Int("webhook-port", 9443, "The port the admission webhooks are served at")
+	webhookCertDir := pflag.String("webhook-cert-dir", "/tmp/k8s-webhook-server/serving-certs",
+		"The directory containing the admission webhooks' serving certificate (tls.crt) and key (tls.key)")
+
 	pflag.Parse()
 
 	// Use a zap logr.Logger implementation. If none of the zap
@@ -98,6 +104,8 @@ func main() {
 	options := manager.Options{
 		Namespace:          namespace,
 		MetricsBindAddress: fmt.Sprintf("%s:%d", metricsHost, metricsPort),
+		Port:               *webhookPort,
+		CertDir:            *webhookCertDir,
 	}
 
 	// Add support for MultiNamespace set in WATCH_NAMESPACE (e.g ns1,ns2)
@@ -130,6 +138,14 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Setup all Webhooks
+	if *enableWebhooks {
+		if err := webhook.AddToManager(mgr); err != nil {
+			log.Error(err, "")
+			os.Exit(1)
+		}
+	}
+
 	// Add the Metrics Service
 	addMetrics(ctx, cfg)
 
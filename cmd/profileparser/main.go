@@ -195,12 +195,12 @@ func parseProfilesAndDo(contentDom *xmldom.Document, pcfg *profileparser.ParserC
 				Name:      xccdf.GetProfileNameFromID(id),
 				Namespace: pcfg.ProfileBundleKey.Namespace,
 			},
-			ID:          id,
-			Title:       title.Text,
-			Description: description.Text,
-			Rules:       selectedrules,
-			Values:      selectedvalues,
+			ID:     id,
+			Rules:  selectedrules,
+			Values: selectedvalues,
 		}
+		p.Title, p.TitleTranslations = profileparser.ParseLocalizedTexts(profileObj.GetChildren("title"), profileparser.GetLocalizedNodeText)
+		p.Description, p.DescriptionTranslations = profileparser.ParseLocalizedTexts(profileObj.GetChildren("description"), profileparser.GetLocalizedNodeText)
 		err := action(&p)
 		if err != nil {
 			log.Error(err, "couldn't execute action")
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/operator-framework/operator-sdk/pkg/log/zap"
+	"github.com/spf13/pflag"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
+	_ "k8s.io/client-go/plugin/pkg/client/auth"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/yaml"
+
+	"github.com/JAORMX/compliance-profile-operator/pkg/apis"
+	cmpv1alpha1 "github.com/JAORMX/compliance-profile-operator/pkg/apis/compliance/v1alpha1"
+	"github.com/JAORMX/compliance-profile-operator/pkg/xccdf"
+)
+
+var log = logf.Log.WithName("tailoringimport")
+
+type importConfig struct {
+	TailoringPath    string
+	ProfileBundleKey types.NamespacedName
+	Name             string
+	AllowUnresolved  bool
+}
+
+func assertNotEmpty(param, paramName string) {
+	if param == "" {
+		log.Info("This cli parameter can't be empty", "parameter", paramName)
+		os.Exit(1)
+	}
+}
+
+func newImportConfig() *importConfig {
+	icfg := importConfig{}
+
+	// Add the zap logger flag set to the CLI. The flag set must
+	// be added before calling pflag.Parse().
+	pflag.CommandLine.AddFlagSet(zap.FlagSet())
+
+	// Add flags registered by imported packages (e.g. glog and
+	// controller-runtime)
+	pflag.CommandLine.AddGoFlagSet(flag.CommandLine)
+
+	pflag.StringVar(&icfg.TailoringPath, "tailoring-file", "", "Path to the tailoring xml file to import")
+	pflag.StringVar(&icfg.ProfileBundleKey.Name, "profile-bundle-name", "", "Name of the ProfileBundle the tailoring applies to")
+	pflag.StringVar(&icfg.ProfileBundleKey.Namespace, "profile-bundle-namespace", "", "Namespace of the ProfileBundle object")
+	pflag.StringVar(&icfg.Name, "name", "", "Name of the TailoredProfile. Defaults to the name of the tailored profile in the file")
+	pflag.BoolVar(&icfg.AllowUnresolved, "allow-unresolved", false, "Import the tailoring even if some of the XCCDF IDs can't be resolved")
+
+	pflag.Parse()
+
+	logf.SetLogger(zap.Logger())
+
+	assertNotEmpty(icfg.TailoringPath, "tailoring-file")
+	assertNotEmpty(icfg.ProfileBundleKey.Name, "profile-bundle-name")
+	assertNotEmpty(icfg.ProfileBundleKey.Namespace, "profile-bundle-namespace")
+
+	return &icfg
+}
+
+// The client allows us to read the objects parsed from the ProfileBundle
+func getK8sClient() runtimeclient.Client {
+	scheme := k8sruntime.NewScheme()
+	if err := apis.AddToScheme(scheme); err != nil {
+		log.Error(err, "")
+		os.Exit(1)
+	}
+
+	// Get a config to talk to the apiserver
+	cfg, err := config.GetConfig()
+	if err != nil {
+		log.Error(err, "")
+		os.Exit(1)
+	}
+
+	client, err := runtimeclient.New(cfg, runtimeclient.Options{
+		Scheme: scheme,
+	})
+	if err != nil {
+		log.Error(err, "")
+		os.Exit(1)
+	}
+	return client
+}
+
+// getObjectNames maps the XCCDF IDs of the Profiles, Rules and Variables parsed
+// from the ProfileBundle to the names of their objects
+func getObjectNames(client runtimeclient.Client, pb *cmpv1alpha1.ProfileBundle) (*xccdf.TailoringObjectNames, error) {
+	names := &xccdf.TailoringObjectNames{
+		ProfileBundle: pb.Name,
+		Profiles:      map[string]string{},
+		Rules:         map[string]string{},
+		Variables:     map[string]string{},
+	}
+	inNamespace := runtimeclient.InNamespace(pb.Namespace)
+
+	profiles := &cmpv1alpha1.ProfileList{}
+	if err := client.List(context.TODO(), profiles, inNamespace); err != nil {
+		return nil, err
+	}
+	for i := range profiles.Items {
+		if metav1.IsControlledBy(&profiles.Items[i], pb) {
+			names.Profiles[profiles.Items[i].ID] = profiles.Items[i].Name
+		}
+	}
+
+	rules := &cmpv1alpha1.RuleList{}
+	if err := client.List(context.TODO(), rules, inNamespace); err != nil {
+		return nil, err
+	}
+	for i := range rules.Items {
+		if metav1.IsControlledBy(&rules.Items[i], pb) {
+			names.Rules[rules.Items[i].ID] = rules.Items[i].Name
+		}
+	}
+
+	variables := &cmpv1alpha1.VariableList{}
+	if err := client.List(context.TODO(), variables, inNamespace); err != nil {
+		return nil, err
+	}
+	for i := range variables.Items {
+		if metav1.IsControlledBy(&variables.Items[i], pb) {
+			names.Variables[variables.Items[i].ID] = variables.Items[i].Name
+		}
+	}
+	return names, nil
+}
+
+func main() {
+	icfg := newImportConfig()
+	client := getK8sClient()
+
+	pb := &cmpv1alpha1.ProfileBundle{}
+	if err := client.Get(context.TODO(), icfg.ProfileBundleKey, pb); err != nil {
+		log.Error(err, "Couldn't get ProfileBundle")
+		os.Exit(1)
+	}
+
+	names, err := getObjectNames(client, pb)
+	if err != nil {
+		log.Error(err, "Couldn't get the objects of the ProfileBundle")
+		os.Exit(1)
+	}
+
+	// #nosec G304
+	tailoringFile, err := os.Open(filepath.Clean(icfg.TailoringPath))
+	if err != nil {
+		log.Error(err, "Couldn't read the tailoring")
+		os.Exit(1)
+	}
+	// #nosec
+	defer tailoringFile.Close()
+
+	tailorings, err := xccdf.ParseTailoring(tailoringFile)
+	if err != nil {
+		log.Error(err, "Couldn't parse the tailoring")
+		os.Exit(1)
+	}
+	if icfg.Name != "" && len(tailorings) > 1 {
+		log.Info("The tailoring holds several profiles, so they can't be given a single name", "profiles", len(tailorings))
+		os.Exit(1)
+	}
+
+	documents := []string{}
+	failed := false
+	for i := range tailorings {
+		tp, unresolved := xccdf.TailoringToTailoredProfile(&tailorings[i], names, pb.Namespace)
+		if icfg.Name != "" {
+			tp.Name = icfg.Name
+		}
+		for _, id := range unresolved {
+			log.Info("Couldn't resolve XCCDF ID", "TailoredProfile.Name", tp.Name, "id", id)
+		}
+		if len(unresolved) > 0 && !icfg.AllowUnresolved {
+			failed = true
+		}
+
+		document, err := yaml.Marshal(tp)
+		if err != nil {
+			log.Error(err, "Couldn't marshal the TailoredProfile", "TailoredProfile.Name", tp.Name)
+			os.Exit(1)
+		}
+		documents = append(documents, string(document))
+	}
+
+	if failed {
+		log.Info("Some XCCDF IDs couldn't be resolved; use --allow-unresolved to import the tailoring without them")
+		os.Exit(1)
+	}
+	fmt.Print(strings.Join(documents, "---\n"))
+}
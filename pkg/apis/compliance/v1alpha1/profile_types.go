@@ -15,6 +15,10 @@ func NewProfileRule(rule string) ProfileRule {
 // ProfileValue defines a value for a setting in the profile
 type ProfileValue string
 
+// LocalizedTexts maps a language tag (e.g. "en-US") to the text written in
+// that language
+type LocalizedTexts map[string]string
+
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
 // Profile is the Schema for the profiles API
@@ -26,6 +30,14 @@ type Profile struct {
 	Title       string `json:"title"`
 	Description string `json:"description"`
 	ID          string `json:"id"`
+	// The available translations of the title, keyed by language
+	// +nullable
+	// +optional
+	TitleTranslations LocalizedTexts `json:"titleTranslations,omitempty"`
+	// The available translations of the description, keyed by language
+	// +nullable
+	// +optional
+	DescriptionTranslations LocalizedTexts `json:"descriptionTranslations,omitempty"`
 	// +nullable
 	// +optional
 	Rules []ProfileRule `json:"rules,omitempty"`
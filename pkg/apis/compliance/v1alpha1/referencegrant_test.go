@@ -0,0 +1,67 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Testing reference grants", func() {
+	var grant *ReferenceGrant
+
+	BeforeEach(func() {
+		grant = &ReferenceGrant{
+			ObjectMeta: metav1.ObjectMeta{Name: "app-teams", Namespace: "compliance-content"},
+			Spec: ReferenceGrantSpec{
+				From: []ReferenceGrantFrom{{Namespace: "my-app"}},
+				To: []ReferenceGrantTo{
+					{Kind: ReferenceKindProfile, Name: "ocp4-moderate"},
+					{Kind: ReferenceKindTailoredProfile},
+				},
+			},
+		}
+	})
+
+	It("allows the granted objects", func() {
+		Expect(grant.Allows("my-app", ReferenceKindProfile, "ocp4-moderate")).To(BeTrue())
+		Expect(grant.Allows("my-app", ReferenceKindProfile, "ocp4-cis")).To(BeFalse())
+		Expect(grant.Allows("my-app", ReferenceKindProfileBundle, "ocp4")).To(BeFalse())
+	})
+
+	It("allows every object of a kind without a name", func() {
+		Expect(grant.Allows("my-app", ReferenceKindTailoredProfile, "org-baseline")).To(BeTrue())
+	})
+
+	It("only allows the granted namespaces", func() {
+		Expect(grant.Allows("other-app", ReferenceKindProfile, "ocp4-moderate")).To(BeFalse())
+	})
+})
+
+var _ = Describe("Testing tailored profile references", func() {
+	It("defaults to its own namespace", func() {
+		tp := &TailoredProfile{
+			ObjectMeta: metav1.ObjectMeta{Name: "my-profile", Namespace: "my-app"},
+			Spec:       TailoredProfileSpec{Extends: "ocp4-moderate"},
+		}
+		Expect(tp.GetContentNamespace()).To(Equal("my-app"))
+		tp.Spec.ContentNamespace = "compliance-content"
+		Expect(tp.GetContentNamespace()).To(Equal("compliance-content"))
+	})
+
+	It("tells the kind and name of the referenced object", func() {
+		tp := &TailoredProfile{Spec: TailoredProfileSpec{Extends: "ocp4-moderate"}}
+		kind, name := tp.GetReference()
+		Expect(kind).To(Equal(ReferenceKindProfile))
+		Expect(name).To(Equal("ocp4-moderate"))
+
+		tp.Spec.ExtendsKind = ExtendsTailoredProfile
+		kind, _ = tp.GetReference()
+		Expect(kind).To(Equal(ReferenceKindTailoredProfile))
+
+		tp = &TailoredProfile{Spec: TailoredProfileSpec{ProfileBundle: "ocp4"}}
+		kind, name = tp.GetReference()
+		Expect(kind).To(Equal(ReferenceKindProfileBundle))
+		Expect(name).To(Equal("ocp4"))
+	})
+})
@@ -0,0 +1,94 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ReferenceKind defines the kind of object a TailoredProfile may reference in
+// another namespace
+type ReferenceKind string
+
+const (
+	// ReferenceKindProfile grants referencing Profiles, and thus the
+	// rules and variables of their ProfileBundle
+	ReferenceKindProfile ReferenceKind = "Profile"
+	// ReferenceKindProfileBundle grants building TailoredProfiles from
+	// scratch out of ProfileBundles
+	ReferenceKindProfileBundle ReferenceKind = "ProfileBundle"
+	// ReferenceKindTailoredProfile grants extending TailoredProfiles
+	ReferenceKindTailoredProfile ReferenceKind = "TailoredProfile"
+)
+
+// ReferenceGrantFrom specifies a namespace whose TailoredProfiles are granted
+// the references
+type ReferenceGrantFrom struct {
+	// The namespace of the TailoredProfiles
+	Namespace string `json:"namespace"`
+}
+
+// ReferenceGrantTo specifies the objects of the grant's namespace that may be
+// referenced
+type ReferenceGrantTo struct {
+	// The kind of the objects
+	// +kubebuilder:validation:Enum=Profile;ProfileBundle;TailoredProfile
+	Kind ReferenceKind `json:"kind"`
+	// The name of the object. Every object of the kind may be referenced
+	// if it's not set.
+	// +optional
+	Name string `json:"name,omitempty"`
+}
+
+// ReferenceGrantSpec defines the desired state of ReferenceGrant
+type ReferenceGrantSpec struct {
+	// The namespaces whose TailoredProfiles may reference the objects
+	From []ReferenceGrantFrom `json:"from"`
+	// The objects that may be referenced
+	To []ReferenceGrantTo `json:"to"`
+}
+
+// Allows tells whether the grant allows the TailoredProfiles of the given
+// namespace to reference the given object of the grant's namespace
+func (g *ReferenceGrant) Allows(fromNamespace string, kind ReferenceKind, name string) bool {
+	fromAllowed := false
+	for _, from := range g.Spec.From {
+		if from.Namespace == fromNamespace {
+			fromAllowed = true
+			break
+		}
+	}
+	if !fromAllowed {
+		return false
+	}
+	for _, to := range g.Spec.To {
+		if to.Kind == kind && (to.Name == "" || to.Name == name) {
+			return true
+		}
+	}
+	return false
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ReferenceGrant allows the TailoredProfiles of other namespaces to reference
+// Profiles, ProfileBundles or TailoredProfiles of its namespace. It's created
+// by whoever owns the referenced content.
+// +kubebuilder:resource:path=referencegrants,scope=Namespaced
+type ReferenceGrant struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ReferenceGrantSpec `json:"spec,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ReferenceGrantList contains a list of ReferenceGrant
+type ReferenceGrantList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ReferenceGrant `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ReferenceGrant{}, &ReferenceGrantList{})
+}
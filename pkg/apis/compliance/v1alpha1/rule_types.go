@@ -11,6 +11,21 @@ import (
 // here or in the compliance-operator?
 const RuleIDAnnotationKey = "compliance.openshift.io/rule"
 
+// ControlAnnotationBase is the prefix of the annotations listing the controls
+// of a standard a rule is mapped to, e.g.
+// "control.compliance.openshift.io/NIST-800-53". The controls are separated
+// by semicolons.
+const ControlAnnotationBase = "control.compliance.openshift.io/"
+
+// RuleStandardsAnnotationKey lists the standards a rule is mapped to, as
+// open-cluster-management Policies expect them. They're separated by commas.
+const RuleStandardsAnnotationKey = "policies.open-cluster-management.io/standards"
+
+// RuleControlsAnnotationKey lists the controls of all the standards a rule is
+// mapped to, as open-cluster-management Policies expect them. They're
+// separated by commas.
+const RuleControlsAnnotationKey = "policies.open-cluster-management.io/controls"
+
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
 // Rule is the Schema for the rules API
@@ -29,12 +44,55 @@ type Rule struct {
 	Rationale string `json:"rationale,omitempty"`
 	// A discretionary warning about the of the Rule
 	Warning string `json:"warning,omitempty"`
+	// The description of the Rule rendered as Markdown
+	RenderedDescription string `json:"renderedDescription,omitempty"`
+	// The rationale of the Rule rendered as Markdown
+	RenderedRationale string `json:"renderedRationale,omitempty"`
+	// The warning of the Rule rendered as Markdown
+	RenderedWarning string `json:"renderedWarning,omitempty"`
 	// The severity level
 	Severity string `json:"severity,omitempty"`
+	// The XCCDF IDs of the groups the Rule belongs to, from the outermost
+	// to the innermost one
+	// +nullable
+	// +optional
+	Groups []string `json:"groups,omitempty"`
+	// The available translations of the title, keyed by language
+	// +nullable
+	// +optional
+	TitleTranslations LocalizedTexts `json:"titleTranslations,omitempty"`
+	// The available translations of the description, keyed by language
+	// +nullable
+	// +optional
+	DescriptionTranslations LocalizedTexts `json:"descriptionTranslations,omitempty"`
+	// The available translations of the rationale, keyed by language
+	// +nullable
+	// +optional
+	RationaleTranslations LocalizedTexts `json:"rationaleTranslations,omitempty"`
+	// The available translations of the warning, keyed by language
+	// +nullable
+	// +optional
+	WarningTranslations LocalizedTexts `json:"warningTranslations,omitempty"`
 	// The Available fixes
 	// +nullable
 	// +optional
 	AvailableFixes []FixDefinition `json:"availableFixes,omitempty"`
+	// The XCCDF IDs of the rules or groups this Rule requires. Each entry
+	// is a space-separated list of IDs of which at least one needs to be
+	// selected, as defined by the XCCDF "requires" element.
+	// +nullable
+	// +optional
+	Requires []string `json:"requires,omitempty"`
+	// The XCCDF IDs of the rules or groups that can't be selected at the
+	// same time as this Rule
+	// +nullable
+	// +optional
+	Conflicts []string `json:"conflicts,omitempty"`
+	// The selectors of the check, fix and text variants of the Rule. A
+	// refinement may pick one of them.
+	// +nullable
+	// +optional
+	Selectors []string `json:"selectors,omitempty"`
 }
 
 // FixDefinition Specifies a fix or remediation
@@ -0,0 +1,283 @@
+package v1alpha1
+
+import (
+	"encoding/json"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Testing rule selectors", func() {
+	var rule *Rule
+
+	BeforeEach(func() {
+		rule = &Rule{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "audit-rules-immutable",
+				Labels: map[string]string{"area": "audit"},
+				Annotations: map[string]string{
+					ControlAnnotationBase + "NIST-800-53": "AC-2(4);AU-9",
+				},
+			},
+			Severity: "high",
+			Groups: []string{
+				"xccdf_org.ssgproject.content_group_auditing",
+				"xccdf_org.ssgproject.content_group_audit_rules",
+			},
+		}
+	})
+
+	It("matches by label", func() {
+		selector := &RuleSelector{LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"area": "audit"}}}
+		Expect(selector.Matches(rule)).To(BeTrue())
+		selector.LabelSelector.MatchLabels["area"] = "network"
+		Expect(selector.Matches(rule)).To(BeFalse())
+	})
+
+	It("matches by severity", func() {
+		Expect((&RuleSelector{Severity: "high"}).Matches(rule)).To(BeTrue())
+		Expect((&RuleSelector{Severity: "low"}).Matches(rule)).To(BeFalse())
+	})
+
+	It("matches controls and their enhancements", func() {
+		Expect((&RuleSelector{Control: &RuleControlSelector{Standard: "NIST-800-53", Control: "AC-2"}}).Matches(rule)).To(BeTrue())
+		Expect((&RuleSelector{Control: &RuleControlSelector{Standard: "NIST-800-53", Control: "AU-9"}}).Matches(rule)).To(BeTrue())
+		Expect((&RuleSelector{Control: &RuleControlSelector{Standard: "NIST-800-53", Control: "AC-20"}}).Matches(rule)).To(BeFalse())
+		Expect((&RuleSelector{Control: &RuleControlSelector{Standard: "PCI-DSS", Control: "AC-2"}}).Matches(rule)).To(BeFalse())
+	})
+
+	It("matches nested groups", func() {
+		Expect((&RuleSelector{Group: "xccdf_org.ssgproject.content_group_auditing"}).Matches(rule)).To(BeTrue())
+		Expect((&RuleSelector{Group: "xccdf_org.ssgproject.content_group_network"}).Matches(rule)).To(BeFalse())
+	})
+
+	It("requires all the criteria to match", func() {
+		Expect((&RuleSelector{Severity: "high", Group: "xccdf_org.ssgproject.content_group_network"}).Matches(rule)).To(BeFalse())
+	})
+
+	It("reports invalid label selectors", func() {
+		selector := &RuleSelector{LabelSelector: &metav1.LabelSelector{
+			MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "area", Operator: "Bogus"}},
+		}}
+		_, err := selector.Matches(rule)
+		Expect(err).ToNot(BeNil())
+	})
+})
+
+var _ = Describe("Testing refinements", func() {
+	Context("rule refinements", func() {
+		It("accepts valid refinements", func() {
+			refinement := &RuleRefinementSpec{Name: "foo", Severity: "high", Weight: "2.5", Role: RuleRoleUnscored}
+			Expect(refinement.Validate()).To(Succeed())
+		})
+
+		It("requires something to be refined", func() {
+			refinement := &RuleRefinementSpec{Name: "foo"}
+			Expect(refinement.Validate()).ToNot(Succeed())
+		})
+
+		It("rejects invalid values", func() {
+			Expect((&RuleRefinementSpec{Name: "foo", Severity: "critical"}).Validate()).ToNot(Succeed())
+			Expect((&RuleRefinementSpec{Name: "foo", Weight: "-1"}).Validate()).ToNot(Succeed())
+			Expect((&RuleRefinementSpec{Name: "foo", Role: "optional"}).Validate()).ToNot(Succeed())
+		})
+
+		It("accepts the selectors of the rule", func() {
+			rule := &Rule{Selectors: []string{"lenient", "strict"}}
+			Expect((&RuleRefinementSpec{Name: "foo", Selector: "strict"}).ValidateFor(rule)).To(Succeed())
+			Expect((&RuleRefinementSpec{Name: "foo", Severity: "high"}).ValidateFor(&Rule{})).To(Succeed())
+		})
+
+		It("rejects selectors the rule doesn't define", func() {
+			rule := &Rule{Selectors: []string{"lenient", "strict"}}
+			Expect((&RuleRefinementSpec{Name: "foo", Selector: "stirct"}).ValidateFor(rule)).To(MatchError(
+				"selector stirct isn't defined for the rule (available selectors: lenient, strict)"))
+			Expect((&RuleRefinementSpec{Name: "foo", Selector: "strict"}).ValidateFor(&Rule{})).ToNot(Succeed())
+		})
+	})
+
+	Context("value refinements", func() {
+		var v *Variable
+
+		BeforeEach(func() {
+			v = &Variable{
+				ID:   "timeout",
+				Type: VarTypeNumber,
+				Selections: []ValueSelection{
+					{Description: "10_minutes", Value: "600"},
+				},
+			}
+		})
+
+		It("accepts a known selector", func() {
+			refinement := &ValueRefinementSpec{Name: "timeout", Selector: "10_minutes"}
+			Expect(refinement.ValidateFor(v)).To(Succeed())
+		})
+
+		It("rejects an unknown selector", func() {
+			refinement := &ValueRefinementSpec{Name: "timeout", Selector: "20_minutes"}
+			Expect(refinement.ValidateFor(v)).To(MatchError("selector 20_minutes isn't defined for the variable"))
+		})
+
+		It("accepts operators suitable for the type", func() {
+			refinement := &ValueRefinementSpec{Name: "timeout", Operator: VarOpLessThanOrEqual}
+			Expect(refinement.ValidateFor(v)).To(Succeed())
+		})
+
+		It("rejects operators unsuitable for the type", func() {
+			refinement := &ValueRefinementSpec{Name: "timeout", Operator: VarOpPatternMatch}
+			Expect(refinement.ValidateFor(v)).To(MatchError("operator pattern match can't be used with variables of type number"))
+		})
+	})
+})
+
+var _ = Describe("Testing policy options", func() {
+	It("defaults to the original Policy", func() {
+		var options *PolicyOptions
+		defaulted := options.WithDefaults()
+		Expect(defaulted.Schedule).To(Equal("0 1 * * *"))
+		Expect(defaulted.Scans).To(Equal([]ScanSpec{{Role: ScanRoleWorker}}))
+		Expect(defaulted.AutoApplyRemediations).To(BeFalse())
+		Expect(defaulted.RemediationAction).To(Equal(PolicyRemediationInform))
+		Expect(defaulted.Namespaces.Include).To(Equal([]string{"default"}))
+		Expect(defaulted.Namespaces.Exclude).To(Equal([]string{"kube-*"}))
+	})
+
+	It("keeps the options that are set", func() {
+		options := &PolicyOptions{ScanSettings: ScanSettings{Schedule: "0 3 * * 0", Scans: []ScanSpec{{Role: ScanRoleMaster}}}}
+		defaulted := options.WithDefaults()
+		Expect(defaulted.Schedule).To(Equal("0 3 * * 0"))
+		Expect(defaulted.Scans).To(Equal(options.Scans))
+		Expect(defaulted).ToNot(BeIdenticalTo(options))
+	})
+
+	It("holds the scan settings next to the other options", func() {
+		options := &PolicyOptions{}
+		Expect(json.Unmarshal([]byte(`{"schedule": "0 3 * * 0", "remediationAction": "enforce"}`), options)).To(Succeed())
+		Expect(options.Schedule).To(Equal("0 3 * * 0"))
+		Expect(options.RemediationAction).To(Equal(PolicyRemediationEnforce))
+	})
+
+	It("selects the nodes by role", func() {
+		Expect((&ScanSpec{Role: ScanRoleMaster}).GetNodeSelector()).To(Equal(map[string]string{"node-role.kubernetes.io/master": ""}))
+		custom := &ScanSpec{Name: "infra", Role: ScanRoleCustom, NodeSelector: map[string]string{"infra": "true"}}
+		Expect(custom.GetNodeSelector()).To(Equal(map[string]string{"infra": "true"}))
+		Expect(custom.GetName()).To(Equal("infra"))
+	})
+
+	It("accepts valid options", func() {
+		options := &PolicyOptions{
+			ScanSettings: ScanSettings{
+				Schedule: "0 3 * * 0",
+				Scans: []ScanSpec{
+					{Role: ScanRoleMaster},
+					{Role: ScanRoleWorker},
+					{Name: "infra", Role: ScanRoleCustom, NodeSelector: map[string]string{"infra": "true"}},
+				},
+			},
+			RemediationAction: PolicyRemediationEnforce,
+		}
+		Expect(options.Validate()).To(Succeed())
+	})
+
+	It("rejects invalid options", func() {
+		Expect((&PolicyOptions{ScanSettings: ScanSettings{Schedule: "daily"}}).Validate()).ToNot(Succeed())
+		Expect((&PolicyOptions{RemediationAction: "fix"}).Validate()).ToNot(Succeed())
+		Expect((&PolicyOptions{ScanSettings: ScanSettings{Scans: []ScanSpec{{Role: ScanRoleCustom, NodeSelector: map[string]string{"infra": "true"}}}}}).Validate()).ToNot(Succeed())
+		Expect((&PolicyOptions{ScanSettings: ScanSettings{Scans: []ScanSpec{{Name: "infra", Role: ScanRoleCustom}}}}).Validate()).ToNot(Succeed())
+		Expect((&PolicyOptions{ScanSettings: ScanSettings{Scans: []ScanSpec{{Role: ScanRoleWorker}, {Role: ScanRoleWorker}}}}).Validate()).ToNot(Succeed())
+		Expect((&PolicyOptions{ClusterSelector: &metav1.LabelSelector{
+			MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "environment", Operator: "Bogus"}},
+		}}).Validate()).ToNot(Succeed())
+	})
+})
+
+var _ = Describe("Testing scan settings", func() {
+	It("defaults to a daily scan of the workers", func() {
+		var settings *ScanSettings
+		defaulted := settings.WithDefaults()
+		Expect(defaulted.Schedule).To(Equal("0 1 * * *"))
+		Expect(defaulted.Scans).To(Equal([]ScanSpec{{Role: ScanRoleWorker}}))
+		Expect(defaulted.AutoApplyRemediations).To(BeFalse())
+	})
+
+	It("keeps the settings that are set", func() {
+		settings := &ScanSettings{Schedule: "0 3 * * 0", Scans: []ScanSpec{{Role: ScanRoleMaster}}, AutoApplyRemediations: true}
+		defaulted := settings.WithDefaults()
+		Expect(defaulted).To(Equal(settings))
+		Expect(defaulted).ToNot(BeIdenticalTo(settings))
+	})
+
+	It("rejects invalid settings", func() {
+		Expect((&ScanSettings{Schedule: "daily"}).Validate()).ToNot(Succeed())
+		Expect((&ScanSettings{Scans: []ScanSpec{{Role: "infra"}}}).Validate()).ToNot(Succeed())
+		Expect((&ScanSettings{Scans: []ScanSpec{{Role: ScanRoleMaster, NodeSelector: map[string]string{"infra": "true"}}}}).Validate()).ToNot(Succeed())
+	})
+})
+
+var _ = Describe("Testing status conditions", func() {
+	var status *TailoredProfileStatus
+	earlier := metav1.NewTime(metav1.Now().Add(-time.Hour).Truncate(time.Second))
+
+	BeforeEach(func() {
+		status = &TailoredProfileStatus{}
+		status.SetCondition(TailoredProfileCondition{
+			Type:               TailoredProfileConditionValidated,
+			Status:             ConditionTrue,
+			ObservedGeneration: 1,
+			LastTransitionTime: earlier,
+			Reason:             ReasonValid,
+		})
+	})
+
+	It("adds conditions", func() {
+		status.SetCondition(TailoredProfileCondition{Type: TailoredProfileConditionOutputReady, Status: ConditionUnknown})
+		Expect(status.Conditions).To(HaveLen(2))
+		Expect(status.GetCondition(TailoredProfileConditionOutputReady).LastTransitionTime.IsZero()).To(BeFalse())
+		Expect(status.GetCondition(TailoredProfileConditionProfileResolved)).To(BeNil())
+	})
+
+	It("keeps the transition time while the status doesn't change", func() {
+		status.SetCondition(TailoredProfileCondition{
+			Type:               TailoredProfileConditionValidated,
+			Status:             ConditionTrue,
+			ObservedGeneration: 2,
+			Reason:             ReasonValid,
+		})
+		Expect(status.Conditions).To(HaveLen(1))
+		condition := status.GetCondition(TailoredProfileConditionValidated)
+		Expect(condition.ObservedGeneration).To(BeEquivalentTo(2))
+		Expect(condition.LastTransitionTime).To(Equal(earlier))
+	})
+
+	It("updates the transition time when the status changes", func() {
+		status.SetCondition(TailoredProfileCondition{
+			Type:    TailoredProfileConditionValidated,
+			Status:  ConditionFalse,
+			Reason:  ReasonInvalidSpec,
+			Message: "invalid",
+		})
+		condition := status.GetCondition(TailoredProfileConditionValidated)
+		Expect(condition.Status).To(Equal(ConditionFalse))
+		Expect(condition.LastTransitionTime.After(earlier.Time)).To(BeTrue())
+	})
+
+	It("compares conditions regardless of their transition time", func() {
+		Expect(status.HasCondition(TailoredProfileCondition{
+			Type:               TailoredProfileConditionValidated,
+			Status:             ConditionTrue,
+			ObservedGeneration: 1,
+			Reason:             ReasonValid,
+		})).To(BeTrue())
+		Expect(status.HasCondition(TailoredProfileCondition{
+			Type:               TailoredProfileConditionValidated,
+			Status:             ConditionTrue,
+			ObservedGeneration: 2,
+			Reason:             ReasonValid,
+		})).To(BeFalse())
+		Expect(status.HasCondition(TailoredProfileCondition{Type: TailoredProfileConditionOutputReady, Status: ConditionTrue})).To(BeFalse())
+	})
+})
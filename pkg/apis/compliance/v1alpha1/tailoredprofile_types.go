@@ -1,7 +1,14 @@
 package v1alpha1
 
 import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 // FIXME: move name/rationale to a common struct with an interface?
@@ -15,19 +22,278 @@ const (
 	// ConfigMapOutput specifies that the TailoredProfile should
 	// generate a ConfigMap object (default).
 	ConfigMapOutput TailoredProfileOutputType = "ConfigMap"
+	// SecretOutput specifies that the TailoredProfile should generate a
+	// Secret object, for tailorings holding sensitive values. The Secret has
+	// the same keys as the ConfigMap would, and the revisions of the
+	// tailoring are kept in Secrets as well. ComplianceSuites and Policies
+	// only take tailorings from ConfigMaps, so policyOptions and
+	// scanSettings are rejected with it.
+	SecretOutput TailoredProfileOutputType = "Secret"
+	// ComplianceSuiteOutput specifies that the TailoredProfile should
+	// generate a ComplianceSuite scanning the cluster with the tailoring,
+	// next to the ConfigMap holding it.
+	ComplianceSuiteOutput TailoredProfileOutputType = "ComplianceSuite"
+)
+
+// TailoredProfileExtendsKind defines the kind of object a TailoredProfile
+// extends
+type TailoredProfileExtendsKind string
+
+const (
+	// ExtendsProfile specifies that the TailoredProfile extends a Profile
+	// (default).
+	ExtendsProfile TailoredProfileExtendsKind = "Profile"
+	// ExtendsTailoredProfile specifies that the TailoredProfile extends
+	// another TailoredProfile. The changes of the whole chain are applied
+	// to the Profile at its root.
+	ExtendsTailoredProfile TailoredProfileExtendsKind = "TailoredProfile"
+)
+
+// RuleDependencyMode defines how the requirements and conflicts between
+// rules are handled when tailoring a profile
+type RuleDependencyMode string
+
+const (
+	// RuleDependencyModeError makes a tailoring that enables conflicting
+	// rules, or that leaves a requirement of an enabled rule unselected,
+	// fail (default).
+	RuleDependencyModeError RuleDependencyMode = "Error"
+	// RuleDependencyModeAutoEnable makes the tailoring enable the rules
+	// that enabled rules require. Conflicts are still reported as errors.
+	RuleDependencyModeAutoEnable RuleDependencyMode = "AutoEnable"
 )
 
 // RuleReferenceSpec specifies a rule to be selected/deselected, as well as the reason why
 type RuleReferenceSpec struct {
-	// Name of the rule that's being referenced
-	Name string `json:"name"`
+	// Name of the rule that's being referenced. Either this or "selector"
+	// needs to be set. Besides the name of the Rule object, this may be the
+	// XCCDF ID of the rule or its short name, e.g. "chronyd_client_only".
+	// +optional
+	Name string `json:"name,omitempty"`
+	// Selects all the rules of the profile's bundle that match, instead of a
+	// single rule by name
+	// +optional
+	Selector *RuleSelector `json:"selector,omitempty"`
 	// Rationale of why this rule is being selected/deselected
 	Rationale string `json:"rationale"`
 }
 
+// RuleSelector selects rules by their attributes. A rule needs to match all
+// the criteria that are set.
+type RuleSelector struct {
+	// Selects the rules whose labels match
+	// +optional
+	LabelSelector *metav1.LabelSelector `json:"labelSelector,omitempty"`
+	// Selects the rules with the given severity, e.g. "high"
+	// +optional
+	Severity string `json:"severity,omitempty"`
+	// Selects the rules mapped to the given control
+	// +optional
+	Control *RuleControlSelector `json:"control,omitempty"`
+	// Selects the rules that belong to the XCCDF group with the given ID,
+	// directly or through nested groups
+	// +optional
+	Group string `json:"group,omitempty"`
+}
+
+// RuleControlSelector selects the rules mapped to a control of a standard
+type RuleControlSelector struct {
+	// The standard the control belongs to, e.g. "NIST-800-53"
+	Standard string `json:"standard"`
+	// The control, e.g. "AC-2". Its enhancements and items, e.g. "AC-2(1)",
+	// are matched as well.
+	Control string `json:"control"`
+}
+
+// IsEmpty tells whether the selector doesn't set any criteria
+func (s *RuleSelector) IsEmpty() bool {
+	return s.LabelSelector == nil && s.Severity == "" && s.Control == nil && s.Group == ""
+}
+
+// Matches tells whether the rule matches all the criteria of the selector
+func (s *RuleSelector) Matches(rule *Rule) (bool, error) {
+	if s.LabelSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(s.LabelSelector)
+		if err != nil {
+			return false, err
+		}
+		if !selector.Matches(labels.Set(rule.Labels)) {
+			return false, nil
+		}
+	}
+	if s.Severity != "" && !strings.EqualFold(s.Severity, rule.Severity) {
+		return false, nil
+	}
+	if s.Control != nil && !s.Control.matches(rule) {
+		return false, nil
+	}
+	if s.Group != "" && !containsString(rule.Groups, s.Group) {
+		return false, nil
+	}
+	return true, nil
+}
+
+func (s *RuleControlSelector) matches(rule *Rule) bool {
+	controls, ok := rule.Annotations[ControlAnnotationBase+s.Standard]
+	if !ok {
+		return false
+	}
+	for _, control := range strings.Split(controls, ";") {
+		control = strings.TrimSpace(control)
+		if control == s.Control || strings.HasPrefix(control, s.Control+"(") {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// RuleRole defines how a rule is taken into account when scoring a scan
+type RuleRole string
+
+const (
+	// RuleRoleFull makes the rule checked and scored (XCCDF default)
+	RuleRoleFull RuleRole = "full"
+	// RuleRoleUnscored makes the rule checked, but not scored
+	RuleRoleUnscored RuleRole = "unscored"
+	// RuleRoleUnchecked makes the rule not checked at all
+	RuleRoleUnchecked RuleRole = "unchecked"
+)
+
+// RuleRefinementSpec refines the properties of a rule, as well as the reason
+// why. This maps to the XCCDF "refine-rule" element.
+type RuleRefinementSpec struct {
+	// Name of the rule that's being refined. Besides the name of the Rule
+	// object, this may be the XCCDF ID of the rule or its short name.
+	Name string `json:"name"`
+	// Rationale of why this rule is being refined
+	Rationale string `json:"rationale"`
+	// Overrides the severity of the rule
+	// +kubebuilder:validation:Enum=unknown;info;low;medium;high
+	// +optional
+	Severity string `json:"severity,omitempty"`
+	// Overrides the weight of the rule when scoring, a non-negative decimal
+	// number
+	// +optional
+	Weight string `json:"weight,omitempty"`
+	// Overrides the role of the rule
+	// +kubebuilder:validation:Enum=full;unscored;unchecked
+	// +optional
+	Role RuleRole `json:"role,omitempty"`
+	// Selects the check, fix or description variant to use for the rule
+	// +optional
+	Selector string `json:"selector,omitempty"`
+}
+
+var ruleSeverities = map[string]bool{"unknown": true, "info": true, "low": true, "medium": true, "high": true}
+
+var weightRegexp = regexp.MustCompile(`^([0-9]+(\.[0-9]*)?|\.[0-9]+)$`)
+
+// Validate checks that the refinement refines something, with valid values
+func (r *RuleRefinementSpec) Validate() error {
+	if r.Severity == "" && r.Weight == "" && r.Role == "" && r.Selector == "" {
+		return errors.New("at least one of severity, weight, role or selector needs to be refined")
+	}
+	if r.Severity != "" && !ruleSeverities[r.Severity] {
+		return fmt.Errorf("severity %s is invalid (accepted values: unknown, info, low, medium, high)", r.Severity)
+	}
+	if r.Weight != "" && !weightRegexp.MatchString(r.Weight) {
+		return fmt.Errorf("weight %s isn't a non-negative decimal number", r.Weight)
+	}
+	switch r.Role {
+	case "", RuleRoleFull, RuleRoleUnscored, RuleRoleUnchecked:
+	default:
+		return fmt.Errorf("role %s is invalid (accepted values: full, unscored, unchecked)", r.Role)
+	}
+	return nil
+}
+
+// ValidateFor checks that the refinement is valid and can be applied to the
+// given rule
+func (r *RuleRefinementSpec) ValidateFor(rule *Rule) error {
+	if err := r.Validate(); err != nil {
+		return err
+	}
+	if r.Selector == "" {
+		return nil
+	}
+	for _, selector := range rule.Selectors {
+		if selector == r.Selector {
+			return nil
+		}
+	}
+	if len(rule.Selectors) == 0 {
+		return fmt.Errorf("selector %s isn't defined for the rule, which has no selectors", r.Selector)
+	}
+	return fmt.Errorf("selector %s isn't defined for the rule (available selectors: %s)", r.Selector, strings.Join(rule.Selectors, ", "))
+}
+
+// ValueRefinementSpec refines how a variable is used, as well as the reason
+// why. This maps to the XCCDF "refine-value" element.
+type ValueRefinementSpec struct {
+	// Name of the variable that's being refined. Besides the name of the
+	// Variable object, this may be the XCCDF ID of the variable or its short
+	// name.
+	Name string `json:"name"`
+	// Rationale of why this variable is being refined
+	Rationale string `json:"rationale"`
+	// Overrides the operator used to compare the value with what's found in
+	// the system
+	// +optional
+	Operator VariableOperator `json:"operator,omitempty"`
+	// Selects one of the values defined for the variable
+	// +optional
+	Selector string `json:"selector,omitempty"`
+}
+
+// ValidateFor checks that the refinement refines something and can be applied
+// to the given variable
+func (r *ValueRefinementSpec) ValidateFor(v *Variable) error {
+	if r.Operator == "" && r.Selector == "" {
+		return errors.New("at least one of operator or selector needs to be refined")
+	}
+	if r.Operator != "" {
+		if err := validateOperatorForType(r.Operator, v.Type); err != nil {
+			return err
+		}
+	}
+	if r.Selector != "" && !v.hasSelection(r.Selector) {
+		return fmt.Errorf("selector %s isn't defined for the variable", r.Selector)
+	}
+	return nil
+}
+
+func validateOperatorForType(op VariableOperator, varType VariableType) error {
+	switch op {
+	case VarOpEquals, VarOpNotEqual:
+		return nil
+	case VarOpGreaterThan, VarOpLessThan, VarOpGreaterThanOrEqual, VarOpLessThanOrEqual:
+		if varType == VarTypeNumber {
+			return nil
+		}
+	case VarOpPatternMatch:
+		if varType == VarTypeString || varType == "" {
+			return nil
+		}
+	default:
+		return fmt.Errorf("operator %s is invalid", op)
+	}
+	return fmt.Errorf("operator %s can't be used with variables of type %s", op, varType)
+}
+
 // ValueReferenceSpec specifies a value to be set for a variable with a reason why
 type VariableValueSpec struct {
-	// Name of the variable that's being referenced
+	// Name of the variable that's being referenced. Besides the name of the
+	// Variable object, this may be the XCCDF ID of the variable or its short
+	// name, e.g. "var_sshd_set_keepalive".
 	Name string `json:"name"`
 	// Rationale of why this value is being tailored
 	Rationale string `json:"rationale"`
@@ -38,13 +304,41 @@ type VariableValueSpec struct {
 // TailoredProfileSpec defines the desired state of TailoredProfile
 type TailoredProfileSpec struct {
 	// Points to the name of the profile to extend
-	Extends string `json:"extends"`
-	// Overwrites the title of the extended profile (optional)
+	// +optional
+	Extends string `json:"extends,omitempty"`
+	// The kind of object to extend, either a Profile (the default) or
+	// another TailoredProfile
+	// +kubebuilder:validation:Enum=Profile;TailoredProfile
+	ExtendsKind TailoredProfileExtendsKind `json:"extendsKind,omitempty"`
+	// Points to the name of the ProfileBundle to build the tailored profile
+	// from, instead of extending a profile. Only the rules enabled by the
+	// tailored profile are selected; every other rule of the bundle is
+	// deselected. Mutually exclusive with "extends".
+	// +optional
+	ProfileBundle string `json:"profileBundle,omitempty"`
+	// The namespace of the object referenced by "extends" or
+	// "profileBundle". Defaults to the namespace of the tailored profile.
+	// Referencing another namespace requires a ReferenceGrant in that
+	// namespace.
+	// +optional
+	ContentNamespace string `json:"contentNamespace,omitempty"`
+	// Overwrites the title of the extended profile (optional). Tailored
+	// profiles built from scratch default to their name.
 	Title string `json:"title,omitempty"`
 	// Overwrites the description of the extended profile (optional)
 	Description string `json:"description,omitempty"`
+	// Translations of the title, keyed by language (e.g. "de-DE"). The
+	// title itself is considered to be in "en-US".
+	// +optional
+	// +nullable
+	TitleTranslations LocalizedTexts `json:"titleTranslations,omitempty"`
+	// Translations of the description, keyed by language (e.g. "de-DE").
+	// The description itself is considered to be in "en-US".
+	// +optional
+	// +nullable
+	DescriptionTranslations LocalizedTexts `json:"descriptionTranslations,omitempty"`
 	// Defines the type of output that the tailored profile will do.
-	// +kubebuilder:validation:Enum=ConfigMap;Policy
+	// +kubebuilder:validation:Enum=ConfigMap;Policy;Secret;ComplianceSuite
 	OutputType TailoredProfileOutputType `json:"outputType,omitempty"`
 	// Enables the referenced rules
 	// +optional
@@ -58,8 +352,267 @@ type TailoredProfileSpec struct {
 	// +optional
 	// +nullable
 	SetValues []VariableValueSpec `json:"setValues,omitempty"`
+	// Refines the severity, weight, role or selector of the referenced rules
+	// +optional
+	// +nullable
+	RefineRules []RuleRefinementSpec `json:"refineRules,omitempty"`
+	// Refines the operator or selector of the referenced variables
+	// +optional
+	// +nullable
+	RefineValues []ValueRefinementSpec `json:"refineValues,omitempty"`
+	// Defines how the requirements and conflicts between rules are handled.
+	// "Error" (the default) rejects the tailoring, "AutoEnable" enables the
+	// required rules.
+	// +kubebuilder:validation:Enum=Error;AutoEnable
+	DependencyMode RuleDependencyMode `json:"dependencyMode,omitempty"`
+	// The number of tailoring revisions to keep. Defaults to 10.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	RevisionHistoryLimit *int32 `json:"revisionHistoryLimit,omitempty"`
+	// Pins the output to the given tailoring revision instead of the latest
+	// one, e.g. to roll back a change
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	PinnedRevision int64 `json:"pinnedRevision,omitempty"`
+	// Configures the generated Policy. Only used with the Policy output
+	// type.
+	// +optional
+	PolicyOptions *PolicyOptions `json:"policyOptions,omitempty"`
+	// Configures the generated ComplianceSuite. Only used with the
+	// ComplianceSuite output type.
+	// +optional
+	ScanSettings *ScanSettings `json:"scanSettings,omitempty"`
+}
+
+// ScanRole defines the nodes a scan of the generated ComplianceSuite runs on
+type ScanRole string
+
+const (
+	// ScanRoleMaster scans the master nodes
+	ScanRoleMaster ScanRole = "master"
+	// ScanRoleWorker scans the worker nodes
+	ScanRoleWorker ScanRole = "worker"
+	// ScanRoleCustom scans the nodes matching a custom node selector
+	ScanRoleCustom ScanRole = "custom"
+)
+
+// PolicyRemediationAction defines what the generated Policy does about the
+// clusters that don't comply with it
+type PolicyRemediationAction string
+
+const (
+	// PolicyRemediationInform only reports the clusters that don't comply
+	// (default)
+	PolicyRemediationInform PolicyRemediationAction = "inform"
+	// PolicyRemediationEnforce makes the clusters comply
+	PolicyRemediationEnforce PolicyRemediationAction = "enforce"
+)
+
+const (
+	// DefaultPolicySchedule is the schedule of the ComplianceSuite of the
+	// generated Policy when none is set
+	DefaultPolicySchedule = "0 1 * * *"
+	// NodeRoleLabelBase is the base of the labels identifying the role of
+	// a node, e.g. "node-role.kubernetes.io/worker"
+	NodeRoleLabelBase = "node-role.kubernetes.io/"
+)
+
+// ScanSpec defines a scan of the generated ComplianceSuite, be it held by
+// a Policy or not
+type ScanSpec struct {
+	// The name of the scan, which is prefixed with the name of the tailored
+	// profile. Defaults to the role. Required for custom scans.
+	// +optional
+	Name string `json:"name,omitempty"`
+	// The nodes to scan: "master", "worker" or "custom"
+	// +kubebuilder:validation:Enum=master;worker;custom
+	Role ScanRole `json:"role"`
+	// The nodes a custom scan runs on. Required for custom scans.
+	// +optional
+	// +nullable
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+}
+
+// GetName gets the name of the scan, without the tailored profile's prefix
+func (s *ScanSpec) GetName() string {
+	if s.Name != "" {
+		return s.Name
+	}
+	return string(s.Role)
+}
+
+// GetNodeSelector gets the node selector of the scan
+func (s *ScanSpec) GetNodeSelector() map[string]string {
+	if s.Role == ScanRoleCustom {
+		return s.NodeSelector
+	}
+	return map[string]string{NodeRoleLabelBase + string(s.Role): ""}
+}
+
+// ScanSettings configures the ComplianceSuite generated by a TailoredProfile
+type ScanSettings struct {
+	// The schedule of the ComplianceSuite, in cron format. Defaults to
+	// "0 1 * * *".
+	// +optional
+	Schedule string `json:"schedule,omitempty"`
+	// The scans of the ComplianceSuite. Defaults to a scan of the worker
+	// nodes.
+	// +optional
+	// +nullable
+	Scans []ScanSpec `json:"scans,omitempty"`
+	// Whether the ComplianceSuite applies the remediations of the failed
+	// checks automatically
+	// +optional
+	AutoApplyRemediations bool `json:"autoApplyRemediations,omitempty"`
+}
+
+// WithDefaults returns a copy of the settings, with the defaults set for the
+// settings that aren't. The settings may be nil.
+func (s *ScanSettings) WithDefaults() *ScanSettings {
+	defaulted := &ScanSettings{}
+	if s != nil {
+		defaulted = s.DeepCopy()
+	}
+	if defaulted.Schedule == "" {
+		defaulted.Schedule = DefaultPolicySchedule
+	}
+	if len(defaulted.Scans) == 0 {
+		defaulted.Scans = []ScanSpec{{Role: ScanRoleWorker}}
+	}
+	return defaulted
+}
+
+// Validate checks that the settings can be turned into a ComplianceSuite
+func (s *ScanSettings) Validate() error {
+	if s.Schedule != "" && len(strings.Fields(s.Schedule)) != 5 {
+		return fmt.Errorf("schedule %s isn't in cron format", s.Schedule)
+	}
+
+	names := make(map[string]bool)
+	for _, scan := range s.Scans {
+		switch scan.Role {
+		case ScanRoleMaster, ScanRoleWorker:
+			if len(scan.NodeSelector) > 0 {
+				return fmt.Errorf("scan %s can't set a nodeSelector unless its role is custom", scan.GetName())
+			}
+		case ScanRoleCustom:
+			if scan.Name == "" {
+				return errors.New("custom scans need a name")
+			}
+			if len(scan.NodeSelector) == 0 {
+				return fmt.Errorf("custom scan %s needs a nodeSelector", scan.Name)
+			}
+		default:
+			return fmt.Errorf("role %s of scan %s is invalid (accepted values: master, worker, custom)", scan.Role, scan.GetName())
+		}
+		if names[scan.GetName()] {
+			return fmt.Errorf("scan %s appears twice", scan.GetName())
+		}
+		names[scan.GetName()] = true
+	}
+	return nil
 }
 
+// PolicyNamespaces selects the namespaces the generated Policy applies to
+type PolicyNamespaces struct {
+	// Namespaces to include, which may contain wildcards
+	// +optional
+	// +nullable
+	Include []string `json:"include,omitempty"`
+	// Namespaces to exclude, which may contain wildcards
+	// +optional
+	// +nullable
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+// PolicyOptions configures the Policy generated by a TailoredProfile and the
+// ComplianceSuite it holds
+type PolicyOptions struct {
+	// The settings of the ComplianceSuite the Policy holds
+	ScanSettings `json:",inline"`
+	// What the Policy does about the clusters that don't comply with it:
+	// "inform" (the default) or "enforce"
+	// +kubebuilder:validation:Enum=inform;enforce
+	// +optional
+	RemediationAction PolicyRemediationAction `json:"remediationAction,omitempty"`
+	// The namespaces the Policy applies to. Defaults to including "default"
+	// and excluding "kube-*".
+	// +optional
+	Namespaces *PolicyNamespaces `json:"namespaces,omitempty"`
+	// Places the Policy on the managed clusters whose labels match. A
+	// PlacementRule and a PlacementBinding are generated for it; otherwise
+	// the Policy needs to be bound to clusters by other means.
+	// +optional
+	ClusterSelector *metav1.LabelSelector `json:"clusterSelector,omitempty"`
+}
+
+// WithDefaults returns a copy of the options, with the defaults set for the
+// options that aren't. The options may be nil.
+func (o *PolicyOptions) WithDefaults() *PolicyOptions {
+	defaulted := &PolicyOptions{}
+	if o != nil {
+		defaulted = o.DeepCopy()
+	}
+	defaulted.ScanSettings = *defaulted.ScanSettings.WithDefaults()
+	if defaulted.RemediationAction == "" {
+		defaulted.RemediationAction = PolicyRemediationInform
+	}
+	if defaulted.Namespaces == nil {
+		defaulted.Namespaces = &PolicyNamespaces{
+			Include: []string{"default"},
+			Exclude: []string{"kube-*"},
+		}
+	}
+	return defaulted
+}
+
+// Validate checks that the options can be turned into a Policy
+func (o *PolicyOptions) Validate() error {
+	if err := o.ScanSettings.Validate(); err != nil {
+		return err
+	}
+	switch o.RemediationAction {
+	case "", PolicyRemediationInform, PolicyRemediationEnforce:
+	default:
+		return fmt.Errorf("remediationAction %s is invalid (accepted values: inform, enforce)", o.RemediationAction)
+	}
+	if o.ClusterSelector != nil {
+		if _, err := metav1.LabelSelectorAsSelector(o.ClusterSelector); err != nil {
+			return fmt.Errorf("clusterSelector is invalid: %s", err)
+		}
+	}
+	return nil
+}
+
+// DefaultRevisionHistoryLimit is the number of tailoring revisions kept when
+// the TailoredProfile doesn't set it
+const DefaultRevisionHistoryLimit int32 = 10
+
+const (
+	// TailoredProfileLabel labels the output and the revisions of a tailoring
+	// with the name of their TailoredProfile
+	TailoredProfileLabel = "compliance.openshift.io/tailored-profile"
+	// TailoredProfileRevisionNumberLabel labels a revision of a tailoring with
+	// its version
+	TailoredProfileRevisionNumberLabel = "compliance.openshift.io/tailoring-revision"
+	// LastModifiedByAnnotation records the user that last changed the spec of
+	// a TailoredProfile. It's set by the defaulting webhook.
+	LastModifiedByAnnotation = "compliance.openshift.io/last-modified-by"
+	// RevisionChangedByAnnotation records the user whose change to the spec
+	// produced a revision of a tailoring
+	RevisionChangedByAnnotation = "compliance.openshift.io/changed-by"
+	// RevisionTimeAnnotation records the time a revision of a tailoring was
+	// generated at
+	RevisionTimeAnnotation = "compliance.openshift.io/revision-time"
+	// GeneratedByAnnotation records the TailoredProfile, as
+	// "namespace/name", an output that it can't own through an
+	// OwnerReference was generated for
+	GeneratedByAnnotation = "compliance.openshift.io/generated-by"
+	// TailoredProfileFinalizer makes sure the outputs that a TailoredProfile
+	// can't own through OwnerReferences are removed along with it
+	TailoredProfileFinalizer = "tailoredprofile.finalizers.compliance.openshift.io"
+)
+
 // TailoredProfileState defines the state fo the tailored profile
 type TailoredProfileState string
 
@@ -72,19 +625,201 @@ const (
 	TailoredProfileStateError TailoredProfileState = "ERROR"
 )
 
+// TailoredProfileConditionType defines a step of the processing of a tailored
+// profile
+type TailoredProfileConditionType string
+
+const (
+	// TailoredProfileConditionValidated tells whether the spec of the
+	// tailored profile is valid
+	TailoredProfileConditionValidated TailoredProfileConditionType = "Validated"
+	// TailoredProfileConditionProfileResolved tells whether the objects the
+	// tailored profile references, directly or through the chain it extends,
+	// were found and resolved
+	TailoredProfileConditionProfileResolved TailoredProfileConditionType = "ProfileResolved"
+	// TailoredProfileConditionOutputReady tells whether the output of the
+	// tailored profile is generated and up to date
+	TailoredProfileConditionOutputReady TailoredProfileConditionType = "OutputReady"
+)
+
+// TailoredProfileConditionTypes lists the conditions of a tailored profile, in
+// the order of the steps they stand for
+var TailoredProfileConditionTypes = []TailoredProfileConditionType{
+	TailoredProfileConditionValidated,
+	TailoredProfileConditionProfileResolved,
+	TailoredProfileConditionOutputReady,
+}
+
+// ConditionStatus defines whether a condition holds
+type ConditionStatus string
+
+const (
+	// ConditionTrue means the condition holds
+	ConditionTrue ConditionStatus = "True"
+	// ConditionFalse means the condition doesn't hold
+	ConditionFalse ConditionStatus = "False"
+	// ConditionUnknown means it's unknown whether the condition holds, e.g.
+	// because a previous step failed
+	ConditionUnknown ConditionStatus = "Unknown"
+)
+
+// The reasons of the conditions of a tailored profile
+const (
+	// ReasonValid means the spec of the tailored profile is valid
+	ReasonValid = "Valid"
+	// ReasonInvalidSpec means the spec of the tailored profile is invalid
+	ReasonInvalidSpec = "InvalidSpec"
+	// ReasonResolved means the referenced objects were resolved
+	ReasonResolved = "Resolved"
+	// ReasonResolutionFailed means a referenced object is missing,
+	// inaccessible or doesn't fit the tailoring
+	ReasonResolutionFailed = "ResolutionFailed"
+	// ReasonOutputGenerated means the output is up to date
+	ReasonOutputGenerated = "OutputGenerated"
+	// ReasonOutputFailed means the output couldn't be generated
+	ReasonOutputFailed = "OutputFailed"
+	// ReasonCleanupFailed means the outputs couldn't be removed while the
+	// tailored profile is deleted
+	ReasonCleanupFailed = "CleanupFailed"
+	// ReasonPreviousStepFailed means the step wasn't processed, as a
+	// previous one failed
+	ReasonPreviousStepFailed = "PreviousStepFailed"
+)
+
+// TailoredProfileCondition describes the outcome of a step of the processing
+// of a tailored profile
+type TailoredProfileCondition struct {
+	// The step the condition stands for
+	Type TailoredProfileConditionType `json:"type"`
+	// Whether the condition holds: "True", "False" or "Unknown"
+	Status ConditionStatus `json:"status"`
+	// The generation of the tailored profile the condition was set for
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// The last time the status of the condition changed
+	// +optional
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+	// A CamelCase reason for the status of the condition
+	Reason string `json:"reason"`
+	// A human readable explanation of the status of the condition
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
 // TailoredProfileStatus defines the observed state of TailoredProfile
 type TailoredProfileStatus struct {
 	// The XCCDF ID of the tailored profile
 	ID string `json:"id,omitempty"`
 	// Points to the generated resource of the type specified in "outputType"
 	OutputRef OutputRef `json:"outputRef,omitempty"`
-	// The current state of the tailored profile
+	// The chain of objects the tailored profile inherits from, starting with
+	// the Profile at its root, e.g. "Profile/ocp4-moderate" followed by
+	// "TailoredProfile/org-baseline"
+	// +optional
+	// +nullable
+	ExtendsChain []string `json:"extendsChain,omitempty"`
+	// The ProfileBundle the rules and variables of the tailored profile are
+	// resolved from, once the chain of objects it extends is resolved
+	// +optional
+	ProfileBundleRef *ProfileBundleRef `json:"profileBundleRef,omitempty"`
+	// The names of the rules the tailored profile enables, once the
+	// selectors and the TailoredProfiles it extends are resolved
+	// +optional
+	// +nullable
+	ResolvedEnableRules []string `json:"resolvedEnableRules,omitempty"`
+	// The names of the rules the tailored profile disables, once the
+	// selectors and the TailoredProfiles it extends are resolved
+	// +optional
+	// +nullable
+	ResolvedDisableRules []string `json:"resolvedDisableRules,omitempty"`
+	// A hash of the generated tailoring. It changes whenever the content of
+	// the tailoring does.
+	ContentHash string `json:"contentHash,omitempty"`
+	// The version of the generated tailoring. It's increased whenever the
+	// content of the tailoring changes.
+	Version int64 `json:"version,omitempty"`
+	// The time the current version of the tailoring was generated at
+	// +optional
+	// +nullable
+	VersionTime *metav1.Time `json:"versionTime,omitempty"`
+	// The tailoring revision the output currently holds. It differs from
+	// "version" when the output is pinned to an older revision.
+	OutputRevision int64 `json:"outputRevision,omitempty"`
+	// The number of managed clusters the generated Policy is placed on, as
+	// decided by the PlacementRule generated from "policyOptions.clusterSelector"
+	PlacedClusters int32 `json:"placedClusters,omitempty"`
+	// The generation of the tailored profile the status was computed for
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// The conditions of the steps the tailored profile is processed in
+	// +optional
+	// +nullable
+	Conditions []TailoredProfileCondition `json:"conditions,omitempty"`
+	// Issues found in the tailored profile that don't prevent generating the
+	// tailoring, e.g. enabling a rule the extended profile already enables
+	// +optional
+	// +nullable
+	Warnings []string `json:"warnings,omitempty"`
+	// The current state of the tailored profile. It sums up the conditions.
 	State        TailoredProfileState `json:"state,omitempty"`
-	ErrorMessage string               `json:"errorMessagae,omitempty"`
+	ErrorMessage string               `json:"errorMessage,omitempty"`
+}
+
+// GetCondition gets the condition of the given type, or nil if it isn't set
+func (s *TailoredProfileStatus) GetCondition(condType TailoredProfileConditionType) *TailoredProfileCondition {
+	for i := range s.Conditions {
+		if s.Conditions[i].Type == condType {
+			return &s.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// SetCondition adds the condition, or replaces the one of the same type. The
+// transition time is kept as long as the status of the condition doesn't
+// change; otherwise it's set to now unless the condition has one.
+func (s *TailoredProfileStatus) SetCondition(condition TailoredProfileCondition) {
+	existing := s.GetCondition(condition.Type)
+	if existing != nil && existing.Status == condition.Status {
+		condition.LastTransitionTime = existing.LastTransitionTime
+	} else if condition.LastTransitionTime.IsZero() {
+		condition.LastTransitionTime = metav1.NewTime(time.Now().Truncate(time.Second))
+	}
+
+	if existing != nil {
+		*existing = condition
+		return
+	}
+	s.Conditions = append(s.Conditions, condition)
+}
+
+// HasCondition tells whether the condition is set with the given status,
+// reason, message and generation, regardless of its transition time
+func (s *TailoredProfileStatus) HasCondition(condition TailoredProfileCondition) bool {
+	existing := s.GetCondition(condition.Type)
+	return existing != nil &&
+		existing.Status == condition.Status &&
+		existing.Reason == condition.Reason &&
+		existing.Message == condition.Message &&
+		existing.ObservedGeneration == condition.ObservedGeneration
+}
+
+// ProfileBundleRef is a reference to the ProfileBundle a tailored profile's
+// content comes from
+type ProfileBundleRef struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
 }
 
 // OutputRef is a reference to the object created from the tailored profile
 type OutputRef struct {
+	// The API version of the object, e.g. "v1" for ConfigMaps and Secrets
+	// +optional
+	APIVersion string `json:"apiVersion,omitempty"`
+	// The kind of the object, e.g. "ConfigMap", "Secret", "Policy" or
+	// "ComplianceSuite"
+	// +optional
+	Kind      string `json:"kind,omitempty"`
 	Name      string `json:"name"`
 	Namespace string `json:"namespace"`
 }
@@ -112,6 +847,27 @@ type TailoredProfileList struct {
 	Items           []TailoredProfile `json:"items"`
 }
 
+// GetContentNamespace gets the namespace of the object the TailoredProfile
+// extends or is built from
+func (tp *TailoredProfile) GetContentNamespace() string {
+	if tp.Spec.ContentNamespace != "" {
+		return tp.Spec.ContentNamespace
+	}
+	return tp.Namespace
+}
+
+// GetReference gets the kind and name of the object the TailoredProfile
+// extends or is built from
+func (tp *TailoredProfile) GetReference() (ReferenceKind, string) {
+	if tp.Spec.Extends == "" {
+		return ReferenceKindProfileBundle, tp.Spec.ProfileBundle
+	}
+	if tp.Spec.ExtendsKind == ExtendsTailoredProfile {
+		return ReferenceKindTailoredProfile, tp.Spec.Extends
+	}
+	return ReferenceKindProfile, tp.Spec.Extends
+}
+
 func init() {
 	SchemeBuilder.Register(&TailoredProfile{}, &TailoredProfileList{})
 }
@@ -139,4 +139,122 @@ var _ = Describe("Testing variables API", func() {
 			Expect(v.Value).To(BeEquivalentTo("42"))
 		})
 	})
+
+	Context("number variable value bounds", func() {
+		BeforeEach(func() {
+			v = &Variable{
+				ID:         "bounds_test",
+				Type:       "number",
+				Value:      "5",
+				LowerBound: "1",
+				UpperBound: "10",
+			}
+		})
+
+		It("values within the bounds are used", func() {
+			err := v.SetValue("10")
+			Expect(err).To(BeNil())
+			Expect(v.Value).To(BeEquivalentTo("10"))
+		})
+
+		It("values below the lower bound are not used", func() {
+			err := v.SetValue("0")
+			Expect(err).To(MatchError("value 0 is lower than the lower bound 1"))
+			Expect(v.Value).To(BeEquivalentTo("5"))
+		})
+
+		It("values above the upper bound are not used", func() {
+			err := v.SetValue("11")
+			Expect(err).To(MatchError("value 11 is greater than the upper bound 10"))
+			Expect(v.Value).To(BeEquivalentTo("5"))
+		})
+	})
+
+	Context("decimal number variable values", func() {
+		BeforeEach(func() {
+			v = &Variable{
+				ID:         "decimal_test",
+				Type:       "number",
+				Value:      "0.5",
+				LowerBound: "0.1",
+				UpperBound: "99999999999999999999.5",
+			}
+		})
+
+		It("decimal values are used", func() {
+			err := v.SetValue("0.25")
+			Expect(err).To(BeNil())
+			Expect(v.Value).To(BeEquivalentTo("0.25"))
+		})
+
+		It("values that don't fit in an int64 are used", func() {
+			err := v.SetValue("99999999999999999999")
+			Expect(err).To(BeNil())
+			Expect(v.Value).To(BeEquivalentTo("99999999999999999999"))
+		})
+
+		It("values in scientific notation are used", func() {
+			err := v.SetValue("1.5e3")
+			Expect(err).To(BeNil())
+			Expect(v.Value).To(BeEquivalentTo("1.5e3"))
+		})
+
+		It("values below a decimal lower bound are not used", func() {
+			err := v.SetValue("0.09")
+			Expect(err).To(MatchError("value 0.09 is lower than the lower bound 0.1"))
+			Expect(v.Value).To(BeEquivalentTo("0.5"))
+		})
+
+		It("non-numeric values are not used", func() {
+			err := v.SetValue("0.5.1")
+			Expect(err).To(MatchError("value 0.5.1 is not a number"))
+			Expect(v.Value).To(BeEquivalentTo("0.5"))
+		})
+	})
+
+	Context("string variable value match", func() {
+		BeforeEach(func() {
+			v = &Variable{
+				ID:    "match_test",
+				Type:  "string",
+				Value: "example.com",
+				Match: "^[a-z.]+$",
+			}
+		})
+
+		It("matching values are used", func() {
+			err := v.SetValue("internal.example.com")
+			Expect(err).To(BeNil())
+			Expect(v.Value).To(BeEquivalentTo("internal.example.com"))
+		})
+
+		It("values not matching are not used", func() {
+			err := v.SetValue("Example.com")
+			Expect(err).To(MatchError("value Example.com doesn't match the pattern ^[a-z.]+$"))
+			Expect(v.Value).To(BeEquivalentTo("example.com"))
+		})
+	})
+
+	Context("pattern match variables", func() {
+		BeforeEach(func() {
+			v = &Variable{
+				ID:       "pattern_test",
+				Type:     "string",
+				Value:    ".*",
+				Operator: VarOpPatternMatch,
+			}
+		})
+
+		It("valid regular expressions are used", func() {
+			err := v.SetValue("^foo[0-9]+$")
+			Expect(err).To(BeNil())
+			Expect(v.Value).To(BeEquivalentTo("^foo[0-9]+$"))
+		})
+
+		It("invalid regular expressions are not used", func() {
+			err := v.SetValue("foo(")
+			Expect(err).ToNot(BeNil())
+			Expect(v.Value).To(BeEquivalentTo(".*"))
+		})
+	})
 })
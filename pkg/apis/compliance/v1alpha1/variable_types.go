@@ -3,8 +3,11 @@ package v1alpha1
 import (
 	"errors"
 	"fmt"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"math/big"
+	"regexp"
 	"strconv"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // +kubebuilder:validation:Enum=number;bool;string
@@ -16,6 +19,21 @@ const (
 	VarTypeString = "string"
 )
 
+// VariableOperator defines how the value of a variable is compared with the
+// values found in the system being checked
+// +kubebuilder:validation:Enum=equals;not equal;greater than;less than;greater than or equal;less than or equal;pattern match
+type VariableOperator string
+
+const (
+	VarOpEquals             VariableOperator = "equals"
+	VarOpNotEqual           VariableOperator = "not equal"
+	VarOpGreaterThan        VariableOperator = "greater than"
+	VarOpLessThan           VariableOperator = "less than"
+	VarOpGreaterThanOrEqual VariableOperator = "greater than or equal"
+	VarOpLessThanOrEqual    VariableOperator = "less than or equal"
+	VarOpPatternMatch       VariableOperator = "pattern match"
+)
+
 type ValueSelection struct {
 	// The string description of the selection
 	Description string `json:"description,omitempty"`
@@ -41,6 +59,16 @@ type Variable struct {
 	Title string `json:"title"`
 	// The description of the Variable
 	Description string `json:"description,omitempty"`
+	// The description of the Variable rendered as Markdown
+	RenderedDescription string `json:"renderedDescription,omitempty"`
+	// The available translations of the title, keyed by language
+	// +nullable
+	// +optional
+	TitleTranslations LocalizedTexts `json:"titleTranslations,omitempty"`
+	// The available translations of the description, keyed by language
+	// +nullable
+	// +optional
+	DescriptionTranslations LocalizedTexts `json:"descriptionTranslations,omitempty"`
 	// The type of the variable
 	Type VariableType `json:"type"`
 	// The value of the variable
@@ -49,6 +77,21 @@ type Variable struct {
 	// +optional
 	// +nullable
 	Selections []ValueSelection `json:"selections,omitempty"`
+	// The operator used to compare the value with what's found in the system
+	Operator VariableOperator `json:"operator,omitempty"`
+	// The minimum value allowed for a number variable
+	LowerBound string `json:"lowerBound,omitempty"`
+	// The maximum value allowed for a number variable
+	UpperBound string `json:"upperBound,omitempty"`
+	// A regular expression that the value needs to match
+	Match string `json:"match,omitempty"`
+	// Whether the value is meant to be provided interactively by the user
+	Interactive bool `json:"interactive,omitempty"`
+	// Issues found while parsing the variable, e.g. a type that couldn't
+	// be mapped reliably
+	// +optional
+	// +nullable
+	Warnings []string `json:"warnings,omitempty"`
 }
 
 func (v *Variable) SetValue(val string) error {
@@ -60,6 +103,10 @@ func (v *Variable) SetValue(val string) error {
 		return err
 	}
 
+	if err := v.validateConstraints(val); err != nil {
+		return err
+	}
+
 	v.Value = val
 	return nil
 }
@@ -68,7 +115,7 @@ func (v *Variable) validateType(val string) error {
 	var err error
 	switch v.Type {
 	case VarTypeNumber:
-		_, err = strconv.Atoi(val)
+		_, err = parseNumber(val)
 		return err
 	case VarTypeBool:
 		_, err = strconv.ParseBool(val)
@@ -97,6 +144,85 @@ func (v *Variable) validateValue(val string) error {
 	return fmt.Errorf("value %s is not allowed, use one of %v", val, v.Selections)
 }
 
+func (v *Variable) validateConstraints(val string) error {
+	if v.Operator == VarOpPatternMatch {
+		if _, err := regexp.Compile(val); err != nil {
+			return fmt.Errorf("value %s is not a valid regular expression: %s", val, err)
+		}
+	}
+
+	if v.Match != "" {
+		matcher, err := regexp.Compile(v.Match)
+		if err != nil {
+			return fmt.Errorf("can't validate value %s, the variable's match pattern %s is invalid: %s", val, v.Match, err)
+		}
+		if !matcher.MatchString(val) {
+			return fmt.Errorf("value %s doesn't match the pattern %s", val, v.Match)
+		}
+	}
+
+	if v.Type == VarTypeNumber {
+		return v.validateBounds(val)
+	}
+	return nil
+}
+
+func (v *Variable) validateBounds(val string) error {
+	if v.LowerBound == "" && v.UpperBound == "" {
+		return nil
+	}
+
+	num, err := parseNumber(val)
+	if err != nil {
+		return err
+	}
+	if v.LowerBound != "" {
+		lower, err := parseNumber(v.LowerBound)
+		if err != nil {
+			return fmt.Errorf("can't validate value %s, the variable's lower bound is invalid: %s", val, err)
+		}
+		if num.Cmp(lower) < 0 {
+			return fmt.Errorf("value %s is lower than the lower bound %s", val, v.LowerBound)
+		}
+	}
+	if v.UpperBound != "" {
+		upper, err := parseNumber(v.UpperBound)
+		if err != nil {
+			return fmt.Errorf("can't validate value %s, the variable's upper bound is invalid: %s", val, err)
+		}
+		if num.Cmp(upper) > 0 {
+			return fmt.Errorf("value %s is greater than the upper bound %s", val, v.UpperBound)
+		}
+	}
+	return nil
+}
+
+func (v *Variable) hasSelection(selector string) bool {
+	for _, selection := range v.Selections {
+		if selection.Description == selector {
+			return true
+		}
+	}
+	return false
+}
+
+// The exponent is limited so parsing a number can't take an arbitrary amount
+// of memory
+var numberRegexp = regexp.MustCompile(`^[+-]?([0-9]+(\.[0-9]*)?|\.[0-9]+)([eE][+-]?[0-9]{1,3})?$`)
+
+// parseNumber parses an integer or decimal number of arbitrary size and
+// precision
+func parseNumber(val string) (*big.Rat, error) {
+	if !numberRegexp.MatchString(val) {
+		return nil, fmt.Errorf("value %s is not a number", val)
+	}
+	num, ok := new(big.Rat).SetString(val)
+	if !ok {
+		return nil, fmt.Errorf("value %s is not a number", val)
+	}
+	return num, nil
+}
+
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
 // VariableList contains a list of Variable
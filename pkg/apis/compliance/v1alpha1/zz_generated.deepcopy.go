@@ -5,6 +5,7 @@
 package v1alpha1
 
 import (
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -28,6 +29,28 @@ func (in *FixDefinition) DeepCopy() *FixDefinition {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in LocalizedTexts) DeepCopyInto(out *LocalizedTexts) {
+	{
+		in := &in
+		*out = make(LocalizedTexts, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+		return
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LocalizedTexts.
+func (in LocalizedTexts) DeepCopy() LocalizedTexts {
+	if in == nil {
+		return nil
+	}
+	out := new(LocalizedTexts)
+	in.DeepCopyInto(out)
+	return *out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OutputRef) DeepCopyInto(out *OutputRef) {
 	*out = *in
@@ -44,11 +67,78 @@ func (in *OutputRef) DeepCopy() *OutputRef {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolicyNamespaces) DeepCopyInto(out *PolicyNamespaces) {
+	*out = *in
+	if in.Include != nil {
+		in, out := &in.Include, &out.Include
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Exclude != nil {
+		in, out := &in.Exclude, &out.Exclude
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolicyNamespaces.
+func (in *PolicyNamespaces) DeepCopy() *PolicyNamespaces {
+	if in == nil {
+		return nil
+	}
+	out := new(PolicyNamespaces)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolicyOptions) DeepCopyInto(out *PolicyOptions) {
+	*out = *in
+	in.ScanSettings.DeepCopyInto(&out.ScanSettings)
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = new(PolicyNamespaces)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ClusterSelector != nil {
+		in, out := &in.ClusterSelector, &out.ClusterSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolicyOptions.
+func (in *PolicyOptions) DeepCopy() *PolicyOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(PolicyOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Profile) DeepCopyInto(out *Profile) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	if in.TitleTranslations != nil {
+		in, out := &in.TitleTranslations, &out.TitleTranslations
+		*out = make(LocalizedTexts, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.DescriptionTranslations != nil {
+		in, out := &in.DescriptionTranslations, &out.DescriptionTranslations
+		*out = make(LocalizedTexts, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	if in.Rules != nil {
 		in, out := &in.Rules, &out.Rules
 		*out = make([]ProfileRule, len(*in))
@@ -141,6 +231,22 @@ func (in *ProfileBundleList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProfileBundleRef) DeepCopyInto(out *ProfileBundleRef) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProfileBundleRef.
+func (in *ProfileBundleRef) DeepCopy() *ProfileBundleRef {
+	if in == nil {
+		return nil
+	}
+	out := new(ProfileBundleRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ProfileBundleSpec) DeepCopyInto(out *ProfileBundleSpec) {
 	*out = *in
@@ -206,11 +312,162 @@ func (in *ProfileList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReferenceGrant) DeepCopyInto(out *ReferenceGrant) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReferenceGrant.
+func (in *ReferenceGrant) DeepCopy() *ReferenceGrant {
+	if in == nil {
+		return nil
+	}
+	out := new(ReferenceGrant)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ReferenceGrant) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReferenceGrantFrom) DeepCopyInto(out *ReferenceGrantFrom) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReferenceGrantFrom.
+func (in *ReferenceGrantFrom) DeepCopy() *ReferenceGrantFrom {
+	if in == nil {
+		return nil
+	}
+	out := new(ReferenceGrantFrom)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReferenceGrantList) DeepCopyInto(out *ReferenceGrantList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ReferenceGrant, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReferenceGrantList.
+func (in *ReferenceGrantList) DeepCopy() *ReferenceGrantList {
+	if in == nil {
+		return nil
+	}
+	out := new(ReferenceGrantList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ReferenceGrantList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReferenceGrantSpec) DeepCopyInto(out *ReferenceGrantSpec) {
+	*out = *in
+	if in.From != nil {
+		in, out := &in.From, &out.From
+		*out = make([]ReferenceGrantFrom, len(*in))
+		copy(*out, *in)
+	}
+	if in.To != nil {
+		in, out := &in.To, &out.To
+		*out = make([]ReferenceGrantTo, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReferenceGrantSpec.
+func (in *ReferenceGrantSpec) DeepCopy() *ReferenceGrantSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ReferenceGrantSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReferenceGrantTo) DeepCopyInto(out *ReferenceGrantTo) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReferenceGrantTo.
+func (in *ReferenceGrantTo) DeepCopy() *ReferenceGrantTo {
+	if in == nil {
+		return nil
+	}
+	out := new(ReferenceGrantTo)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Rule) DeepCopyInto(out *Rule) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	if in.Groups != nil {
+		in, out := &in.Groups, &out.Groups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.TitleTranslations != nil {
+		in, out := &in.TitleTranslations, &out.TitleTranslations
+		*out = make(LocalizedTexts, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.DescriptionTranslations != nil {
+		in, out := &in.DescriptionTranslations, &out.DescriptionTranslations
+		*out = make(LocalizedTexts, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.RationaleTranslations != nil {
+		in, out := &in.RationaleTranslations, &out.RationaleTranslations
+		*out = make(LocalizedTexts, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.WarningTranslations != nil {
+		in, out := &in.WarningTranslations, &out.WarningTranslations
+		*out = make(LocalizedTexts, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	if in.AvailableFixes != nil {
 		in, out := &in.AvailableFixes, &out.AvailableFixes
 		*out = make([]FixDefinition, len(*in))
@@ -218,6 +475,21 @@ func (in *Rule) DeepCopyInto(out *Rule) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Requires != nil {
+		in, out := &in.Requires, &out.Requires
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conflicts != nil {
+		in, out := &in.Conflicts, &out.Conflicts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Selectors != nil {
+		in, out := &in.Selectors, &out.Selectors
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -239,6 +511,22 @@ func (in *Rule) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RuleControlSelector) DeepCopyInto(out *RuleControlSelector) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RuleControlSelector.
+func (in *RuleControlSelector) DeepCopy() *RuleControlSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(RuleControlSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RuleList) DeepCopyInto(out *RuleList) {
 	*out = *in
@@ -275,6 +563,11 @@ func (in *RuleList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RuleReferenceSpec) DeepCopyInto(out *RuleReferenceSpec) {
 	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(RuleSelector)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -288,13 +581,101 @@ func (in *RuleReferenceSpec) DeepCopy() *RuleReferenceSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RuleRefinementSpec) DeepCopyInto(out *RuleRefinementSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RuleRefinementSpec.
+func (in *RuleRefinementSpec) DeepCopy() *RuleRefinementSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RuleRefinementSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RuleSelector) DeepCopyInto(out *RuleSelector) {
+	*out = *in
+	if in.LabelSelector != nil {
+		in, out := &in.LabelSelector, &out.LabelSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Control != nil {
+		in, out := &in.Control, &out.Control
+		*out = new(RuleControlSelector)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RuleSelector.
+func (in *RuleSelector) DeepCopy() *RuleSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(RuleSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScanSettings) DeepCopyInto(out *ScanSettings) {
+	*out = *in
+	if in.Scans != nil {
+		in, out := &in.Scans, &out.Scans
+		*out = make([]ScanSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScanSettings.
+func (in *ScanSettings) DeepCopy() *ScanSettings {
+	if in == nil {
+		return nil
+	}
+	out := new(ScanSettings)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScanSpec) DeepCopyInto(out *ScanSpec) {
+	*out = *in
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScanSpec.
+func (in *ScanSpec) DeepCopy() *ScanSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ScanSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TailoredProfile) DeepCopyInto(out *TailoredProfile) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -316,6 +697,23 @@ func (in *TailoredProfile) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TailoredProfileCondition) DeepCopyInto(out *TailoredProfileCondition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TailoredProfileCondition.
+func (in *TailoredProfileCondition) DeepCopy() *TailoredProfileCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(TailoredProfileCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TailoredProfileList) DeepCopyInto(out *TailoredProfileList) {
 	*out = *in
@@ -352,21 +750,64 @@ func (in *TailoredProfileList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TailoredProfileSpec) DeepCopyInto(out *TailoredProfileSpec) {
 	*out = *in
+	if in.TitleTranslations != nil {
+		in, out := &in.TitleTranslations, &out.TitleTranslations
+		*out = make(LocalizedTexts, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.DescriptionTranslations != nil {
+		in, out := &in.DescriptionTranslations, &out.DescriptionTranslations
+		*out = make(LocalizedTexts, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	if in.EnableRules != nil {
 		in, out := &in.EnableRules, &out.EnableRules
 		*out = make([]RuleReferenceSpec, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	if in.DisableRules != nil {
 		in, out := &in.DisableRules, &out.DisableRules
 		*out = make([]RuleReferenceSpec, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	if in.SetValues != nil {
 		in, out := &in.SetValues, &out.SetValues
 		*out = make([]VariableValueSpec, len(*in))
 		copy(*out, *in)
 	}
+	if in.RefineRules != nil {
+		in, out := &in.RefineRules, &out.RefineRules
+		*out = make([]RuleRefinementSpec, len(*in))
+		copy(*out, *in)
+	}
+	if in.RefineValues != nil {
+		in, out := &in.RefineValues, &out.RefineValues
+		*out = make([]ValueRefinementSpec, len(*in))
+		copy(*out, *in)
+	}
+	if in.RevisionHistoryLimit != nil {
+		in, out := &in.RevisionHistoryLimit, &out.RevisionHistoryLimit
+		*out = new(int32)
+		**out = **in
+	}
+	if in.PolicyOptions != nil {
+		in, out := &in.PolicyOptions, &out.PolicyOptions
+		*out = new(PolicyOptions)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ScanSettings != nil {
+		in, out := &in.ScanSettings, &out.ScanSettings
+		*out = new(ScanSettings)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -384,6 +825,42 @@ func (in *TailoredProfileSpec) DeepCopy() *TailoredProfileSpec {
 func (in *TailoredProfileStatus) DeepCopyInto(out *TailoredProfileStatus) {
 	*out = *in
 	out.OutputRef = in.OutputRef
+	if in.ExtendsChain != nil {
+		in, out := &in.ExtendsChain, &out.ExtendsChain
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ProfileBundleRef != nil {
+		in, out := &in.ProfileBundleRef, &out.ProfileBundleRef
+		*out = new(ProfileBundleRef)
+		**out = **in
+	}
+	if in.ResolvedEnableRules != nil {
+		in, out := &in.ResolvedEnableRules, &out.ResolvedEnableRules
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ResolvedDisableRules != nil {
+		in, out := &in.ResolvedDisableRules, &out.ResolvedDisableRules
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.VersionTime != nil {
+		in, out := &in.VersionTime, &out.VersionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]TailoredProfileCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Warnings != nil {
+		in, out := &in.Warnings, &out.Warnings
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -397,6 +874,22 @@ func (in *TailoredProfileStatus) DeepCopy() *TailoredProfileStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ValueRefinementSpec) DeepCopyInto(out *ValueRefinementSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ValueRefinementSpec.
+func (in *ValueRefinementSpec) DeepCopy() *ValueRefinementSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ValueRefinementSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ValueSelection) DeepCopyInto(out *ValueSelection) {
 	*out = *in
@@ -418,11 +911,30 @@ func (in *Variable) DeepCopyInto(out *Variable) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	if in.TitleTranslations != nil {
+		in, out := &in.TitleTranslations, &out.TitleTranslations
+		*out = make(LocalizedTexts, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.DescriptionTranslations != nil {
+		in, out := &in.DescriptionTranslations, &out.DescriptionTranslations
+		*out = make(LocalizedTexts, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	if in.Selections != nil {
 		in, out := &in.Selections, &out.Selections
 		*out = make([]ValueSelection, len(*in))
 		copy(*out, *in)
 	}
+	if in.Warnings != nil {
+		in, out := &in.Warnings, &out.Warnings
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -0,0 +1,52 @@
+package compliancesuite
+
+import (
+	compliancev1alpha1 "github.com/JAORMX/compliance-profile-operator/pkg/apis/compliance/v1alpha1"
+	"github.com/JAORMX/compliance-profile-operator/pkg/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// NewComplianceSuite creates a ComplianceSuite scanning the nodes with the
+// profile defined in the tailoring ConfigMap, according to the scan settings.
+// Each scan is named after the suite and the role of the nodes it scans.
+func NewComplianceSuite(name, namespace string, settings *compliancev1alpha1.ScanSettings, profileID string,
+	pb *compliancev1alpha1.ProfileBundle, tailoringConfigMap string) *ComplianceSuite {
+	scans := []ComplianceScanSpecWrapper{}
+	for _, scan := range settings.Scans {
+		scans = append(scans, ComplianceScanSpecWrapper{
+			Name: name + "-" + scan.GetName() + "-scan",
+			ComplianceScanSpec: ComplianceScanSpec{
+				ContentImage: pb.Spec.ContentImage,
+				Profile:      profileID,
+				Content:      pb.Spec.ContentFile,
+				NodeSelector: scan.GetNodeSelector(),
+				TailoringConfigMap: &TailoringConfigMapRef{
+					Name: tailoringConfigMap,
+				},
+			},
+		})
+	}
+
+	return &ComplianceSuite{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: GroupVersionKind.GroupVersion().String(),
+			Kind:       GroupVersionKind.Kind,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: ComplianceSuiteSpec{
+			Schedule:              settings.Schedule,
+			AutoApplyRemediations: settings.AutoApplyRemediations,
+			Scans:                 scans,
+		},
+	}
+}
+
+// ToUnstructured converts the ComplianceSuite to an unstructured object, as
+// the type isn't registered in the operator's scheme
+func (s *ComplianceSuite) ToUnstructured() (*unstructured.Unstructured, error) {
+	return utils.ToUnstructured(s)
+}
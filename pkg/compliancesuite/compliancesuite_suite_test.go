@@ -0,0 +1,13 @@
+package compliancesuite
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestComplianceSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "compliancesuite Suite")
+}
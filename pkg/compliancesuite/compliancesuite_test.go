@@ -0,0 +1,68 @@
+package compliancesuite
+
+import (
+	compliancev1alpha1 "github.com/JAORMX/compliance-profile-operator/pkg/apis/compliance/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Testing ComplianceSuites", func() {
+	var pb *compliancev1alpha1.ProfileBundle
+	var settings *compliancev1alpha1.ScanSettings
+
+	BeforeEach(func() {
+		pb = &compliancev1alpha1.ProfileBundle{
+			Spec: compliancev1alpha1.ProfileBundleSpec{
+				ContentImage: "quay.io/complianceascode/ocp4:latest",
+				ContentFile:  "ssg-ocp4-ds.xml",
+			},
+		}
+		settings = (&compliancev1alpha1.ScanSettings{
+			Schedule: "0 3 * * 0",
+			Scans: []compliancev1alpha1.ScanSpec{
+				{Role: compliancev1alpha1.ScanRoleMaster},
+				{Role: compliancev1alpha1.ScanRoleWorker},
+				{Name: "infra", Role: compliancev1alpha1.ScanRoleCustom, NodeSelector: map[string]string{"infra": "true"}},
+			},
+		}).WithDefaults()
+	})
+
+	It("scans the nodes of each role with the tailoring", func() {
+		suite := NewComplianceSuite("my-profile", "my-app", settings, "xccdf_compliance.openshift.io_profile_my-profile", pb, "my-profile-tp")
+		Expect(suite.Name).To(Equal("my-profile"))
+		Expect(suite.Namespace).To(Equal("my-app"))
+		Expect(suite.Spec.Schedule).To(Equal("0 3 * * 0"))
+		Expect(suite.Spec.Scans).To(HaveLen(3))
+
+		master := suite.Spec.Scans[0]
+		Expect(master.Name).To(Equal("my-profile-master-scan"))
+		Expect(master.Profile).To(Equal("xccdf_compliance.openshift.io_profile_my-profile"))
+		Expect(master.ContentImage).To(Equal(pb.Spec.ContentImage))
+		Expect(master.Content).To(Equal(pb.Spec.ContentFile))
+		Expect(master.NodeSelector).To(Equal(map[string]string{"node-role.kubernetes.io/master": ""}))
+		Expect(master.TailoringConfigMap).To(Equal(&TailoringConfigMapRef{Name: "my-profile-tp"}))
+
+		Expect(suite.Spec.Scans[2].Name).To(Equal("my-profile-infra-scan"))
+		Expect(suite.Spec.Scans[2].NodeSelector).To(Equal(map[string]string{"infra": "true"}))
+	})
+
+	It("converts to an unstructured object", func() {
+		suite := NewComplianceSuite("my-profile", "", settings, "xccdf_compliance.openshift.io_profile_my-profile", pb, "my-profile-tp")
+		obj, err := suite.ToUnstructured()
+		Expect(err).To(BeNil())
+		Expect(obj.GroupVersionKind()).To(Equal(GroupVersionKind))
+		Expect(obj.GetNamespace()).To(BeEmpty())
+		_, found, _ := unstructured.NestedFieldNoCopy(obj.Object, "metadata", "creationTimestamp")
+		Expect(found).To(BeFalse())
+
+		scans, _, _ := unstructured.NestedSlice(obj.Object, "spec", "scans")
+		Expect(scans).To(HaveLen(3))
+		Expect(scans[0]).To(HaveKeyWithValue("name", "my-profile-master-scan"))
+		Expect(scans[0]).To(HaveKeyWithValue("tailoringConfigMap", map[string]interface{}{"name": "my-profile-tp"}))
+		autoApply, found, _ := unstructured.NestedBool(obj.Object, "spec", "autoApplyRemediations")
+		Expect(found).To(BeTrue())
+		Expect(autoApply).To(BeFalse())
+	})
+})
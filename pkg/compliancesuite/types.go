@@ -0,0 +1,51 @@
+// Package compliancesuite holds the types of the compliance-operator's
+// ComplianceSuite API the operator generates to scan clusters with a
+// tailoring. The types only model the fields the operator sets, and aren't
+// registered in the operator's scheme, as the compliance-operator serves the
+// API.
+package compliancesuite
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// GroupVersionKind is the kind of the ComplianceSuites
+var GroupVersionKind = schema.GroupVersionKind{Group: "compliance.openshift.io", Version: "v1alpha1", Kind: "ComplianceSuite"}
+
+// ComplianceSuite runs scans of a cluster's nodes, possibly on a schedule
+type ComplianceSuite struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ComplianceSuiteSpec `json:"spec"`
+}
+
+// ComplianceSuiteSpec defines the desired state of a ComplianceSuite
+type ComplianceSuiteSpec struct {
+	Schedule              string                      `json:"schedule,omitempty"`
+	AutoApplyRemediations bool                        `json:"autoApplyRemediations"`
+	Scans                 []ComplianceScanSpecWrapper `json:"scans"`
+}
+
+// ComplianceScanSpecWrapper names a scan of the ComplianceSuite
+type ComplianceScanSpecWrapper struct {
+	ComplianceScanSpec `json:",inline"`
+
+	Name string `json:"name"`
+}
+
+// ComplianceScanSpec defines a scan of a profile on the selected nodes
+type ComplianceScanSpec struct {
+	ContentImage       string                 `json:"contentImage,omitempty"`
+	Profile            string                 `json:"profile"`
+	Content            string                 `json:"content,omitempty"`
+	NodeSelector       map[string]string      `json:"nodeSelector"`
+	TailoringConfigMap *TailoringConfigMapRef `json:"tailoringConfigMap,omitempty"`
+}
+
+// TailoringConfigMapRef references the ConfigMap holding the tailoring the
+// scanned profile is defined in
+type TailoringConfigMapRef struct {
+	Name string `json:"name"`
+}
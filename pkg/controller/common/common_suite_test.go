@@ -0,0 +1,87 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/JAORMX/compliance-profile-operator/pkg/apis"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestCommon(t *testing.T) {
+	RegisterFailHandler(Fail)
+	if err := apis.AddToScheme(scheme.Scheme); err != nil {
+		t.Fatal(err)
+	}
+	RunSpecs(t, "common Suite")
+}
+
+// indexedClient wraps the fake client, which ignores field selectors, so
+// that listing honours the field indexes registered with it
+type indexedClient struct {
+	client.Client
+	indexes map[string]client.IndexerFunc
+}
+
+func newIndexedClient(objs ...runtime.Object) *indexedClient {
+	return &indexedClient{
+		Client:  fake.NewFakeClientWithScheme(scheme.Scheme, objs...),
+		indexes: map[string]client.IndexerFunc{},
+	}
+}
+
+func getIndexKey(obj runtime.Object, field string) string {
+	return fmt.Sprintf("%s/%s", reflect.TypeOf(obj).Elem().Name(), field)
+}
+
+func (c *indexedClient) IndexField(obj runtime.Object, field string, extractValue client.IndexerFunc) error {
+	c.indexes[getIndexKey(obj, field)] = extractValue
+	return nil
+}
+
+func (c *indexedClient) List(ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+	if err := c.Client.List(ctx, list, opts...); err != nil {
+		return err
+	}
+	listOpts := &client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+	if listOpts.FieldSelector == nil {
+		return nil
+	}
+
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return err
+	}
+	matching := []runtime.Object{}
+	for _, item := range items {
+		if c.matches(item, listOpts) {
+			matching = append(matching, item)
+		}
+	}
+	return meta.SetList(list, matching)
+}
+
+func (c *indexedClient) matches(obj runtime.Object, listOpts *client.ListOptions) bool {
+	for _, req := range listOpts.FieldSelector.Requirements() {
+		extractValue, ok := c.indexes[getIndexKey(obj, req.Field)]
+		Expect(ok).To(BeTrue(), "no index for field %s", req.Field)
+		found := false
+		for _, value := range extractValue(obj) {
+			found = found || value == req.Value
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
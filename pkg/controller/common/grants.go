@@ -0,0 +1,29 @@
+package common
+
+import (
+	"context"
+
+	compliancev1alpha1 "github.com/JAORMX/compliance-profile-operator/pkg/apis/compliance/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// IsReferenceGranted tells whether the TailoredProfiles of the given namespace
+// may reference the given object. References within the same namespace are
+// always allowed; references to another namespace need a ReferenceGrant in
+// that namespace.
+func IsReferenceGranted(ctx context.Context, c client.Client, fromNamespace string, kind compliancev1alpha1.ReferenceKind, namespace, name string) (bool, error) {
+	if fromNamespace == namespace {
+		return true, nil
+	}
+
+	grantList := &compliancev1alpha1.ReferenceGrantList{}
+	if err := c.List(ctx, grantList, client.InNamespace(namespace)); err != nil {
+		return false, err
+	}
+	for i := range grantList.Items {
+		if grantList.Items[i].Allows(fromNamespace, kind, name) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,53 @@
+package common
+
+import (
+	"context"
+
+	compliancev1alpha1 "github.com/JAORMX/compliance-profile-operator/pkg/apis/compliance/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Testing reference grants", func() {
+	var c *indexedClient
+
+	BeforeEach(func() {
+		c = newIndexedClient(
+			&compliancev1alpha1.ReferenceGrant{
+				ObjectMeta: metav1.ObjectMeta{Name: "app-teams", Namespace: "compliance-content"},
+				Spec: compliancev1alpha1.ReferenceGrantSpec{
+					From: []compliancev1alpha1.ReferenceGrantFrom{{Namespace: "my-app"}},
+					To: []compliancev1alpha1.ReferenceGrantTo{
+						{Kind: compliancev1alpha1.ReferenceKindProfile, Name: "ocp4-moderate"},
+						{Kind: compliancev1alpha1.ReferenceKindTailoredProfile},
+					},
+				},
+			},
+			// Grants of other namespaces don't count
+			&compliancev1alpha1.ReferenceGrant{
+				ObjectMeta: metav1.ObjectMeta{Name: "everything", Namespace: "other"},
+				Spec: compliancev1alpha1.ReferenceGrantSpec{
+					From: []compliancev1alpha1.ReferenceGrantFrom{{Namespace: "my-app"}},
+					To:   []compliancev1alpha1.ReferenceGrantTo{{Kind: compliancev1alpha1.ReferenceKindProfileBundle}},
+				},
+			},
+		)
+	})
+
+	table.DescribeTable("checking references",
+		func(fromNamespace string, kind compliancev1alpha1.ReferenceKind, name string, expected bool) {
+			granted, err := IsReferenceGranted(context.TODO(), c, fromNamespace, kind, "compliance-content", name)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(granted).To(Equal(expected))
+		},
+		table.Entry("references within the namespace", "compliance-content", compliancev1alpha1.ReferenceKindProfileBundle, "ocp4", true),
+		table.Entry("a granted object", "my-app", compliancev1alpha1.ReferenceKindProfile, "ocp4-moderate", true),
+		table.Entry("an object of a granted kind", "my-app", compliancev1alpha1.ReferenceKindTailoredProfile, "any", true),
+		table.Entry("another object of the kind", "my-app", compliancev1alpha1.ReferenceKindProfile, "ocp4-high", false),
+		table.Entry("a kind that isn't granted", "my-app", compliancev1alpha1.ReferenceKindProfileBundle, "ocp4", false),
+		table.Entry("a namespace that isn't granted", "other-app", compliancev1alpha1.ReferenceKindProfile, "ocp4-moderate", false),
+	)
+})
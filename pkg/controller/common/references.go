@@ -0,0 +1,158 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	compliancev1alpha1 "github.com/JAORMX/compliance-profile-operator/pkg/apis/compliance/v1alpha1"
+	"github.com/JAORMX/compliance-profile-operator/pkg/xccdf"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// IDIndex indexes Rules and Variables by their XCCDF ID
+	IDIndex = "id"
+	// ShortNameIndex indexes Rules and Variables by the short name of their
+	// XCCDF ID
+	ShortNameIndex = "shortName"
+)
+
+// AddIDIndexes registers the field indexes used to find Rules and Variables
+// by their XCCDF ID or its short name
+func AddIDIndexes(indexer client.FieldIndexer) error {
+	err := addIDIndexes(indexer, &compliancev1alpha1.Rule{}, func(obj runtime.Object) string {
+		return obj.(*compliancev1alpha1.Rule).ID
+	})
+	if err != nil {
+		return err
+	}
+	return addIDIndexes(indexer, &compliancev1alpha1.Variable{}, func(obj runtime.Object) string {
+		return obj.(*compliancev1alpha1.Variable).ID
+	})
+}
+
+func addIDIndexes(indexer client.FieldIndexer, obj runtime.Object, getID func(runtime.Object) string) error {
+	err := indexer.IndexField(obj, IDIndex, func(obj runtime.Object) []string {
+		return []string{getID(obj)}
+	})
+	if err != nil {
+		return err
+	}
+	return indexer.IndexField(obj, ShortNameIndex, func(obj runtime.Object) []string {
+		if shortName := xccdf.GetShortNameFromID(getID(obj)); shortName != "" {
+			return []string{shortName}
+		}
+		return nil
+	})
+}
+
+// getIDSelectors gets the field selectors matching the Rules or Variables a
+// reference could be the XCCDF ID or the short name of
+func getIDSelectors(ref string) []client.MatchingFields {
+	return []client.MatchingFields{
+		{IDIndex: ref},
+		{ShortNameIndex: xccdf.NormalizeShortName(ref)},
+	}
+}
+
+// AmbiguousReferenceError is returned when a reference by XCCDF ID or short
+// name matches several objects
+type AmbiguousReferenceError struct {
+	Reference string
+	Names     []string
+}
+
+func (e *AmbiguousReferenceError) Error() string {
+	return fmt.Sprintf("'%s' is ambiguous, it could be any of %s", e.Reference, strings.Join(e.Names, ", "))
+}
+
+// IsAmbiguousReference tells whether the error is an AmbiguousReferenceError
+func IsAmbiguousReference(err error) bool {
+	_, ok := err.(*AmbiguousReferenceError)
+	return ok
+}
+
+// ResolveRuleName resolves a reference to a rule to the name of its Rule
+// object. Besides the name of the object, rules may be referenced by their
+// XCCDF ID or their short name, e.g. "chronyd_client_only" for
+// "xccdf_org.ssgproject.content_rule_chronyd_client_only", whatever the
+// namespace of the content's IDs is. These are
+// resolved to the rules parsed from the given ProfileBundle; if it's nil, the
+// reference needs to match a single rule of the namespace. A NotFound error
+// is returned if there's no such rule.
+func ResolveRuleName(ctx context.Context, c client.Client, namespace, ref string, pb *compliancev1alpha1.ProfileBundle) (string, error) {
+	rule := &compliancev1alpha1.Rule{}
+	err := c.Get(ctx, types.NamespacedName{Name: ref, Namespace: namespace}, rule)
+	if err == nil || !errors.IsNotFound(err) {
+		return ref, err
+	}
+
+	candidates := []metav1.Object{}
+	for _, selector := range getIDSelectors(ref) {
+		ruleList := &compliancev1alpha1.RuleList{}
+		err := c.List(ctx, ruleList, client.InNamespace(namespace), selector)
+		if err != nil {
+			return "", err
+		}
+		for i := range ruleList.Items {
+			candidates = append(candidates, &ruleList.Items[i])
+		}
+	}
+	return pickReference(ref, candidates, pb, compliancev1alpha1.SchemeGroupVersion.WithResource("rules").GroupResource())
+}
+
+// ResolveVariableName resolves a reference to a variable to the name of its
+// Variable object, the same way ResolveRuleName does for rules
+func ResolveVariableName(ctx context.Context, c client.Client, namespace, ref string, pb *compliancev1alpha1.ProfileBundle) (string, error) {
+	variable := &compliancev1alpha1.Variable{}
+	err := c.Get(ctx, types.NamespacedName{Name: ref, Namespace: namespace}, variable)
+	if err == nil || !errors.IsNotFound(err) {
+		return ref, err
+	}
+
+	candidates := []metav1.Object{}
+	for _, selector := range getIDSelectors(ref) {
+		variableList := &compliancev1alpha1.VariableList{}
+		err := c.List(ctx, variableList, client.InNamespace(namespace), selector)
+		if err != nil {
+			return "", err
+		}
+		for i := range variableList.Items {
+			candidates = append(candidates, &variableList.Items[i])
+		}
+	}
+	return pickReference(ref, candidates, pb, compliancev1alpha1.SchemeGroupVersion.WithResource("variables").GroupResource())
+}
+
+// pickReference picks the single object the reference can be resolved to out
+// of the candidates
+func pickReference(ref string, candidates []metav1.Object, pb *compliancev1alpha1.ProfileBundle, resource schema.GroupResource) (string, error) {
+	names := []string{}
+	seen := make(map[string]bool)
+	for _, candidate := range candidates {
+		if pb != nil && !metav1.IsControlledBy(candidate, pb) {
+			continue
+		}
+		if !seen[candidate.GetName()] {
+			seen[candidate.GetName()] = true
+			names = append(names, candidate.GetName())
+		}
+	}
+
+	switch len(names) {
+	case 0:
+		return "", errors.NewNotFound(resource, ref)
+	case 1:
+		return names[0], nil
+	default:
+		sort.Strings(names)
+		return "", &AmbiguousReferenceError{Reference: ref, Names: names}
+	}
+}
@@ -0,0 +1,141 @@
+package common
+
+import (
+	"context"
+
+	compliancev1alpha1 "github.com/JAORMX/compliance-profile-operator/pkg/apis/compliance/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func newTestBundle(name string) *compliancev1alpha1.ProfileBundle {
+	return &compliancev1alpha1.ProfileBundle{
+		TypeMeta:   metav1.TypeMeta{APIVersion: compliancev1alpha1.SchemeGroupVersion.String(), Kind: "ProfileBundle"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test", UID: types.UID(name + "-uid")},
+	}
+}
+
+func newBundleObjectMeta(pb *compliancev1alpha1.ProfileBundle, name string) metav1.ObjectMeta {
+	controller := true
+	return metav1.ObjectMeta{
+		Name:      pb.Name + "-" + name,
+		Namespace: pb.Namespace,
+		OwnerReferences: []metav1.OwnerReference{{
+			APIVersion: pb.APIVersion,
+			Kind:       pb.Kind,
+			Name:       pb.Name,
+			UID:        pb.UID,
+			Controller: &controller,
+		}},
+	}
+}
+
+var _ = Describe("Testing references to rules and variables", func() {
+	var ocp4, rhcos4 *compliancev1alpha1.ProfileBundle
+	var c *indexedClient
+
+	BeforeEach(func() {
+		ocp4 = newTestBundle("ocp4")
+		rhcos4 = newTestBundle("rhcos4")
+		objs := []runtime.Object{
+			&compliancev1alpha1.Rule{
+				ObjectMeta: newBundleObjectMeta(ocp4, "chronyd-client-only"),
+				ID:         "xccdf_org.ssgproject.content_rule_chronyd_client_only",
+			},
+			&compliancev1alpha1.Rule{
+				ObjectMeta: newBundleObjectMeta(rhcos4, "chronyd-client-only"),
+				ID:         "xccdf_org.ssgproject.content_rule_chronyd_client_only",
+			},
+			&compliancev1alpha1.Rule{
+				ObjectMeta: newBundleObjectMeta(ocp4, "no-telnet"),
+				ID:         "xccdf_com.example.www_rule_no_telnet",
+			},
+			&compliancev1alpha1.Variable{
+				ObjectMeta: newBundleObjectMeta(ocp4, "var-sshd-set-keepalive"),
+				ID:         "xccdf_org.ssgproject.content_value_var_sshd_set_keepalive",
+			},
+		}
+		c = newIndexedClient(objs...)
+		Expect(AddIDIndexes(c)).To(Succeed())
+	})
+
+	Context("resolving rules", func() {
+		It("resolves the name of the Rule object", func() {
+			Expect(ResolveRuleName(context.TODO(), c, "test", "rhcos4-chronyd-client-only", ocp4)).To(Equal("rhcos4-chronyd-client-only"))
+		})
+
+		It("resolves XCCDF IDs to the rules of the bundle", func() {
+			Expect(ResolveRuleName(context.TODO(), c, "test", "xccdf_org.ssgproject.content_rule_chronyd_client_only", ocp4)).To(Equal("ocp4-chronyd-client-only"))
+			Expect(ResolveRuleName(context.TODO(), c, "test", "xccdf_org.ssgproject.content_rule_chronyd_client_only", rhcos4)).To(Equal("rhcos4-chronyd-client-only"))
+		})
+
+		It("resolves short names", func() {
+			Expect(ResolveRuleName(context.TODO(), c, "test", "chronyd_client_only", ocp4)).To(Equal("ocp4-chronyd-client-only"))
+			Expect(ResolveRuleName(context.TODO(), c, "test", "chronyd-client-only", ocp4)).To(Equal("ocp4-chronyd-client-only"))
+		})
+
+		It("resolves short names of content with another namespace", func() {
+			Expect(ResolveRuleName(context.TODO(), c, "test", "no_telnet", ocp4)).To(Equal("ocp4-no-telnet"))
+		})
+
+		It("reports references matching rules of several bundles without a bundle", func() {
+			_, err := ResolveRuleName(context.TODO(), c, "test", "chronyd_client_only", nil)
+			Expect(IsAmbiguousReference(err)).To(BeTrue())
+			Expect(err).To(MatchError("'chronyd_client_only' is ambiguous, it could be any of ocp4-chronyd-client-only, rhcos4-chronyd-client-only"))
+		})
+
+		It("reports unknown rules as not found", func() {
+			_, err := ResolveRuleName(context.TODO(), c, "test", "no_such_rule", ocp4)
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+			_, err = ResolveRuleName(context.TODO(), c, "test", "no_telnet", rhcos4)
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
+	Context("resolving variables", func() {
+		It("resolves XCCDF IDs and short names", func() {
+			Expect(ResolveVariableName(context.TODO(), c, "test", "xccdf_org.ssgproject.content_value_var_sshd_set_keepalive", ocp4)).To(Equal("ocp4-var-sshd-set-keepalive"))
+			Expect(ResolveVariableName(context.TODO(), c, "test", "var_sshd_set_keepalive", nil)).To(Equal("ocp4-var-sshd-set-keepalive"))
+		})
+
+		It("doesn't resolve rules", func() {
+			_, err := ResolveVariableName(context.TODO(), c, "test", "chronyd_client_only", ocp4)
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
+	Context("picking references", func() {
+		var rules []metav1.Object
+
+		BeforeEach(func() {
+			rules = []metav1.Object{
+				&compliancev1alpha1.Rule{ObjectMeta: newBundleObjectMeta(rhcos4, "audit")},
+				&compliancev1alpha1.Rule{ObjectMeta: newBundleObjectMeta(ocp4, "audit")},
+			}
+		})
+
+		It("picks the candidate of the bundle", func() {
+			Expect(pickReference("audit", rules, ocp4, compliancev1alpha1.SchemeGroupVersion.WithResource("rules").GroupResource())).To(Equal("ocp4-audit"))
+		})
+
+		It("counts candidates matched twice once", func() {
+			rules = append(rules, rules[1])
+			Expect(pickReference("audit", rules, ocp4, compliancev1alpha1.SchemeGroupVersion.WithResource("rules").GroupResource())).To(Equal("ocp4-audit"))
+		})
+
+		It("reports ambiguous references with the sorted names", func() {
+			_, err := pickReference("audit", rules, nil, compliancev1alpha1.SchemeGroupVersion.WithResource("rules").GroupResource())
+			Expect(err).To(Equal(&AmbiguousReferenceError{Reference: "audit", Names: []string{"ocp4-audit", "rhcos4-audit"}}))
+		})
+
+		It("reports missing candidates as not found", func() {
+			_, err := pickReference("audit", nil, ocp4, compliancev1alpha1.SchemeGroupVersion.WithResource("rules").GroupResource())
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+})
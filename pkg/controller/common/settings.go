@@ -0,0 +1,20 @@
+package common
+
+import (
+	"os"
+
+	"github.com/JAORMX/compliance-profile-operator/pkg/policy"
+)
+
+const policyAPIVersionEnvVar = "POLICY_API_VERSION"
+
+// GetPolicyAPIVersion returns the API version of the Policies generated for
+// TailoredProfiles. It defaults to the current open-cluster-management API,
+// and may be set to the policy.mcm.ibm.com API for older hubs.
+func GetPolicyAPIVersion() string {
+	apiVersion := os.Getenv(policyAPIVersionEnvVar)
+	if apiVersion == "" {
+		apiVersion = policy.DefaultAPIVersion
+	}
+	return apiVersion
+}
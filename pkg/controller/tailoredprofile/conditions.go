@@ -0,0 +1,118 @@
+package tailoredprofile
+
+import (
+	"fmt"
+	"sort"
+
+	compliancev1alpha1 "github.com/JAORMX/compliance-profile-operator/pkg/apis/compliance/v1alpha1"
+)
+
+// successReasons are the reasons of the conditions of the steps that succeeded
+var successReasons = map[compliancev1alpha1.TailoredProfileConditionType]string{
+	compliancev1alpha1.TailoredProfileConditionValidated:       compliancev1alpha1.ReasonValid,
+	compliancev1alpha1.TailoredProfileConditionProfileResolved: compliancev1alpha1.ReasonResolved,
+	compliancev1alpha1.TailoredProfileConditionOutputReady:     compliancev1alpha1.ReasonOutputGenerated,
+}
+
+// getReadyConditions gets the conditions of a TailoredProfile whose output is
+// up to date
+func getReadyConditions(generation int64) []compliancev1alpha1.TailoredProfileCondition {
+	conditions := []compliancev1alpha1.TailoredProfileCondition{}
+	for _, condType := range compliancev1alpha1.TailoredProfileConditionTypes {
+		conditions = append(conditions, compliancev1alpha1.TailoredProfileCondition{
+			Type:               condType,
+			Status:             compliancev1alpha1.ConditionTrue,
+			ObservedGeneration: generation,
+			Reason:             successReasons[condType],
+		})
+	}
+	return conditions
+}
+
+// getErrorConditions gets the conditions of a TailoredProfile whose processing
+// failed at the given step. The steps before it succeeded, while the ones
+// after it weren't processed at all.
+func getErrorConditions(generation int64, failed compliancev1alpha1.TailoredProfileConditionType, reason string, err error) []compliancev1alpha1.TailoredProfileCondition {
+	conditions := []compliancev1alpha1.TailoredProfileCondition{}
+	failedSeen := false
+	for _, condType := range compliancev1alpha1.TailoredProfileConditionTypes {
+		condition := compliancev1alpha1.TailoredProfileCondition{
+			Type:               condType,
+			ObservedGeneration: generation,
+		}
+		switch {
+		case condType == failed:
+			condition.Status = compliancev1alpha1.ConditionFalse
+			condition.Reason = reason
+			condition.Message = err.Error()
+			failedSeen = true
+		case !failedSeen:
+			condition.Status = compliancev1alpha1.ConditionTrue
+			condition.Reason = successReasons[condType]
+		default:
+			condition.Status = compliancev1alpha1.ConditionUnknown
+			condition.Reason = compliancev1alpha1.ReasonPreviousStepFailed
+		}
+		conditions = append(conditions, condition)
+	}
+	return conditions
+}
+
+// invalidSpecError is an error in the spec of a TailoredProfile that's only
+// found once the content it references is resolved, e.g. a refinement that
+// doesn't suit the rule it refines
+type invalidSpecError struct {
+	error
+}
+
+// getResolutionErrorCondition gets the condition type and reason of an error
+// found while resolving the content a TailoredProfile references
+func getResolutionErrorCondition(err error) (compliancev1alpha1.TailoredProfileConditionType, string) {
+	if _, ok := err.(*invalidSpecError); ok {
+		return compliancev1alpha1.TailoredProfileConditionValidated, compliancev1alpha1.ReasonInvalidSpec
+	}
+	return compliancev1alpha1.TailoredProfileConditionProfileResolved, compliancev1alpha1.ReasonResolutionFailed
+}
+
+// getWarnings gets the issues of the TailoredProfile that don't prevent
+// generating the tailoring: rules it enables by name although the profile it
+// extends already enables them, or disables by name although that profile
+// doesn't enable them. The changes of the TailoredProfiles in between, which
+// are ordered from the root, are taken into account. Rules matched by
+// selectors aren't reported, as selectors are expected to match rules of
+// either kind. Disabled rules aren't reported either if selectors enable
+// rules, as they may be the ones the rules are disabled from.
+func getWarnings(tp *compliancev1alpha1.TailoredProfile, parents []*compliancev1alpha1.TailoredProfile, p *compliancev1alpha1.Profile) []string {
+	if p == nil {
+		return nil
+	}
+	enabled := make(map[string]bool, len(p.Rules))
+	for _, rule := range p.Rules {
+		enabled[string(rule)] = true
+	}
+	selectorsEnable := false
+	for _, parent := range parents {
+		for _, selection := range parent.Spec.EnableRules {
+			enabled[selection.Name] = true
+			selectorsEnable = selectorsEnable || selection.Selector != nil
+		}
+		for _, selection := range parent.Spec.DisableRules {
+			delete(enabled, selection.Name)
+		}
+	}
+
+	var warnings []string
+	for _, selection := range tp.Spec.EnableRules {
+		selectorsEnable = selectorsEnable || selection.Selector != nil
+		if selection.Name != "" && enabled[selection.Name] {
+			warnings = append(warnings, fmt.Sprintf("Rule '%s' is already enabled in the extended profile", selection.Name))
+		}
+	}
+	for _, selection := range tp.Spec.DisableRules {
+		if selection.Name != "" && !enabled[selection.Name] && !selectorsEnable {
+			warnings = append(warnings, fmt.Sprintf("Rule '%s' isn't enabled in the extended profile", selection.Name))
+		}
+	}
+	sort.Strings(warnings)
+	return warnings
+}
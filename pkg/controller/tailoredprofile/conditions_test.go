@@ -0,0 +1,136 @@
+package tailoredprofile
+
+import (
+	"errors"
+
+	compliancev1alpha1 "github.com/JAORMX/compliance-profile-operator/pkg/apis/compliance/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+// selectorRef references the rules matching a severity instead of a rule
+func selectorRef(severity string) compliancev1alpha1.RuleReferenceSpec {
+	return compliancev1alpha1.RuleReferenceSpec{Selector: &compliancev1alpha1.RuleSelector{Severity: severity}, Rationale: severity}
+}
+
+var _ = Describe("Testing TailoredProfile conditions", func() {
+	table.DescribeTable("failing a step",
+		func(failed compliancev1alpha1.TailoredProfileConditionType, expectedStatuses []compliancev1alpha1.ConditionStatus) {
+			conditions := getErrorConditions(3, failed, "SomeReason", errors.New("some error"))
+			Expect(conditions).To(HaveLen(len(compliancev1alpha1.TailoredProfileConditionTypes)))
+			for i, condition := range conditions {
+				Expect(condition.Type).To(Equal(compliancev1alpha1.TailoredProfileConditionTypes[i]))
+				Expect(condition.ObservedGeneration).To(Equal(int64(3)))
+				Expect(condition.Status).To(Equal(expectedStatuses[i]), "status of %s", condition.Type)
+				switch condition.Status {
+				case compliancev1alpha1.ConditionTrue:
+					Expect(condition.Reason).To(Equal(successReasons[condition.Type]))
+					Expect(condition.Message).To(BeEmpty())
+				case compliancev1alpha1.ConditionFalse:
+					Expect(condition.Reason).To(Equal("SomeReason"))
+					Expect(condition.Message).To(Equal("some error"))
+				case compliancev1alpha1.ConditionUnknown:
+					Expect(condition.Reason).To(Equal(compliancev1alpha1.ReasonPreviousStepFailed))
+					Expect(condition.Message).To(BeEmpty())
+				}
+			}
+		},
+		table.Entry("validation", compliancev1alpha1.TailoredProfileConditionValidated, []compliancev1alpha1.ConditionStatus{
+			compliancev1alpha1.ConditionFalse, compliancev1alpha1.ConditionUnknown, compliancev1alpha1.ConditionUnknown,
+		}),
+		table.Entry("resolution", compliancev1alpha1.TailoredProfileConditionProfileResolved, []compliancev1alpha1.ConditionStatus{
+			compliancev1alpha1.ConditionTrue, compliancev1alpha1.ConditionFalse, compliancev1alpha1.ConditionUnknown,
+		}),
+		table.Entry("output", compliancev1alpha1.TailoredProfileConditionOutputReady, []compliancev1alpha1.ConditionStatus{
+			compliancev1alpha1.ConditionTrue, compliancev1alpha1.ConditionTrue, compliancev1alpha1.ConditionFalse,
+		}),
+	)
+
+	It("sets every condition once the output is ready", func() {
+		for _, condition := range getReadyConditions(3) {
+			Expect(condition.Status).To(Equal(compliancev1alpha1.ConditionTrue))
+			Expect(condition.Reason).To(Equal(successReasons[condition.Type]))
+		}
+	})
+})
+
+var _ = Describe("Testing TailoredProfile warnings", func() {
+	var p *compliancev1alpha1.Profile
+
+	BeforeEach(func() {
+		p = &compliancev1alpha1.Profile{
+			ObjectMeta: metav1.ObjectMeta{Name: "ocp4-moderate", Namespace: "test"},
+			Rules:      []compliancev1alpha1.ProfileRule{"ocp4-audit", "ocp4-chrony"},
+		}
+	})
+
+	table.DescribeTable("warning about needless changes",
+		func(parentEnable, parentDisable, enable, disable []compliancev1alpha1.RuleReferenceSpec, expectedWarnings []string) {
+			parent := newExtendingTailoredProfile("parent", "")
+			parent.Spec.EnableRules = parentEnable
+			parent.Spec.DisableRules = parentDisable
+			tp := newExtendingTailoredProfile("tp", "parent")
+			tp.Spec.EnableRules = enable
+			tp.Spec.DisableRules = disable
+			Expect(getWarnings(tp, []*compliancev1alpha1.TailoredProfile{parent}, p)).To(Equal(expectedWarnings))
+		},
+		table.Entry("no needless changes", nil, nil,
+			[]compliancev1alpha1.RuleReferenceSpec{ruleRef("ocp4-sshd", "")},
+			[]compliancev1alpha1.RuleReferenceSpec{ruleRef("ocp4-audit", "")},
+			nil),
+		table.Entry("rules the profile already enables or doesn't enable", nil, nil,
+			[]compliancev1alpha1.RuleReferenceSpec{ruleRef("ocp4-chrony", "")},
+			[]compliancev1alpha1.RuleReferenceSpec{ruleRef("ocp4-sshd", "")},
+			[]string{
+				"Rule 'ocp4-chrony' is already enabled in the extended profile",
+				"Rule 'ocp4-sshd' isn't enabled in the extended profile",
+			}),
+		table.Entry("a rule a parent already enables",
+			[]compliancev1alpha1.RuleReferenceSpec{ruleRef("ocp4-sshd", "")}, nil,
+			[]compliancev1alpha1.RuleReferenceSpec{ruleRef("ocp4-sshd", "")}, nil,
+			[]string{"Rule 'ocp4-sshd' is already enabled in the extended profile"}),
+		table.Entry("a rule a parent disables", nil,
+			[]compliancev1alpha1.RuleReferenceSpec{ruleRef("ocp4-audit", "")},
+			[]compliancev1alpha1.RuleReferenceSpec{ruleRef("ocp4-audit", "")},
+			[]compliancev1alpha1.RuleReferenceSpec{ruleRef("ocp4-audit", "")},
+			[]string{"Rule 'ocp4-audit' isn't enabled in the extended profile"}),
+		table.Entry("rules matched by selectors", nil, nil,
+			[]compliancev1alpha1.RuleReferenceSpec{selectorRef("high")},
+			[]compliancev1alpha1.RuleReferenceSpec{selectorRef("low")},
+			nil),
+		table.Entry("disabled rules when the TailoredProfile's selectors enable rules", nil, nil,
+			[]compliancev1alpha1.RuleReferenceSpec{selectorRef("high")},
+			[]compliancev1alpha1.RuleReferenceSpec{ruleRef("ocp4-sshd", "")},
+			nil),
+		table.Entry("disabled rules when a parent's selectors enable rules",
+			[]compliancev1alpha1.RuleReferenceSpec{selectorRef("high")}, nil,
+			nil,
+			[]compliancev1alpha1.RuleReferenceSpec{ruleRef("ocp4-sshd", "")},
+			nil),
+	)
+
+	It("applies the changes of the whole chain from the root", func() {
+		root := newExtendingTailoredProfile("root", "")
+		root.Spec.EnableRules = []compliancev1alpha1.RuleReferenceSpec{ruleRef("ocp4-sshd", "")}
+		root.Spec.DisableRules = []compliancev1alpha1.RuleReferenceSpec{ruleRef("ocp4-chrony", "")}
+		middle := newExtendingTailoredProfile("middle", "root")
+		middle.Spec.EnableRules = []compliancev1alpha1.RuleReferenceSpec{ruleRef("ocp4-chrony", "")}
+		middle.Spec.DisableRules = []compliancev1alpha1.RuleReferenceSpec{ruleRef("ocp4-sshd", "")}
+		tp := newExtendingTailoredProfile("tp", "middle")
+		tp.Spec.EnableRules = []compliancev1alpha1.RuleReferenceSpec{ruleRef("ocp4-chrony", ""), ruleRef("ocp4-sshd", "")}
+		tp.Spec.DisableRules = []compliancev1alpha1.RuleReferenceSpec{ruleRef("ocp4-audit", "")}
+
+		Expect(getWarnings(tp, []*compliancev1alpha1.TailoredProfile{root, middle}, p)).To(Equal([]string{
+			"Rule 'ocp4-chrony' is already enabled in the extended profile",
+		}))
+	})
+
+	It("has no warnings without an extended profile", func() {
+		tp := newExtendingTailoredProfile("tp", "")
+		tp.Spec.EnableRules = []compliancev1alpha1.RuleReferenceSpec{ruleRef("ocp4-sshd", "")}
+		Expect(getWarnings(tp, nil, nil)).To(BeNil())
+	})
+})
@@ -0,0 +1,209 @@
+package tailoredprofile
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	compliancev1alpha1 "github.com/JAORMX/compliance-profile-operator/pkg/apis/compliance/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ruleSelectionState tracks the effective rule selection of a tailoring. All
+// the sets are keyed by the rule's XCCDF ID.
+type ruleSelectionState struct {
+	rulesByID map[string]*compliancev1alpha1.Rule
+	selected  map[string]bool
+	enabled   map[string]bool
+	disabled  map[string]bool
+}
+
+// newRuleSelectionState builds the selection state out of the rules of the
+// ProfileBundle. Rules of other bundles in the same namespace are ignored, as
+// bundles may share XCCDF IDs.
+func newRuleSelectionState(ruleList []compliancev1alpha1.Rule, pb *compliancev1alpha1.ProfileBundle, baseRules []compliancev1alpha1.ProfileRule, rules map[string]*compliancev1alpha1.Rule, tp *compliancev1alpha1.TailoredProfile) *ruleSelectionState {
+	state := &ruleSelectionState{
+		rulesByID: make(map[string]*compliancev1alpha1.Rule),
+		selected:  make(map[string]bool),
+		enabled:   make(map[string]bool),
+		disabled:  make(map[string]bool),
+	}
+	rulesByName := make(map[string]*compliancev1alpha1.Rule)
+	for i := range ruleList {
+		rule := &ruleList[i]
+		if !metav1.IsControlledBy(rule, pb) {
+			continue
+		}
+		state.rulesByID[rule.ID] = rule
+		rulesByName[rule.Name] = rule
+	}
+
+	for _, profileRule := range baseRules {
+		if rule, ok := rulesByName[string(profileRule)]; ok {
+			state.selected[rule.ID] = true
+		}
+	}
+	for _, selection := range tp.Spec.EnableRules {
+		rule := rules[selection.Name]
+		state.selected[rule.ID] = true
+		state.enabled[rule.ID] = true
+	}
+	for _, selection := range tp.Spec.DisableRules {
+		rule := rules[selection.Name]
+		delete(state.selected, rule.ID)
+		state.disabled[rule.ID] = true
+	}
+	return state
+}
+
+// requiredRule is a rule that got enabled because another rule requires it
+type requiredRule struct {
+	rule       *compliancev1alpha1.Rule
+	requiredBy string
+}
+
+// checkConflicts returns an error if any of the selected rules conflicts with
+// another selected rule, and at least one of them was enabled by the tailoring.
+// Only relationships involving rules touched by the tailoring are checked; the
+// base profile is trusted to be consistent.
+func (s *ruleSelectionState) checkConflicts() error {
+	for _, id := range sortedKeys(s.selected) {
+		rule, ok := s.rulesByID[id]
+		if !ok {
+			continue
+		}
+		for _, conflict := range rule.Conflicts {
+			if !s.selected[conflict] || !(s.enabled[id] || s.enabled[conflict]) {
+				continue
+			}
+			return fmt.Errorf("Rule '%s' conflicts with selected rule '%s'", rule.Name, s.ruleName(conflict))
+		}
+	}
+	return nil
+}
+
+// resolveRequirements checks that every requirement of the selected rules is
+// met. In AutoEnable mode, unmet requirements are selected and returned;
+// otherwise an error is returned. Requirements that don't point to a known
+// rule (e.g. groups) are considered to be met.
+func (s *ruleSelectionState) resolveRequirements(mode compliancev1alpha1.RuleDependencyMode) ([]requiredRule, error) {
+	autoEnabled := []requiredRule{}
+	for changed := true; changed; {
+		changed = false
+		for _, id := range sortedKeys(s.selected) {
+			rule, ok := s.rulesByID[id]
+			if !ok {
+				continue
+			}
+			for _, requirement := range rule.Requires {
+				alternatives := strings.Fields(requirement)
+				if s.requirementMet(alternatives) {
+					continue
+				}
+				if !s.enabled[id] && !s.anyDisabled(alternatives) {
+					// Not something this tailoring broke
+					continue
+				}
+				candidate := s.enableCandidate(alternatives)
+				if mode != compliancev1alpha1.RuleDependencyModeAutoEnable || candidate == nil {
+					return nil, fmt.Errorf("Rule '%s' requires one of %v to be selected", rule.Name, s.ruleNames(alternatives))
+				}
+				s.selected[candidate.ID] = true
+				s.enabled[candidate.ID] = true
+				autoEnabled = append(autoEnabled, requiredRule{rule: candidate, requiredBy: rule.Name})
+				changed = true
+			}
+		}
+	}
+	return autoEnabled, nil
+}
+
+func (s *ruleSelectionState) requirementMet(alternatives []string) bool {
+	for _, alt := range alternatives {
+		if _, isRule := s.rulesByID[alt]; !isRule || s.selected[alt] {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *ruleSelectionState) anyDisabled(alternatives []string) bool {
+	for _, alt := range alternatives {
+		if s.disabled[alt] {
+			return true
+		}
+	}
+	return false
+}
+
+// enableCandidate returns the first alternative that can be enabled. Rules
+// that were explicitly disabled are never enabled automatically.
+func (s *ruleSelectionState) enableCandidate(alternatives []string) *compliancev1alpha1.Rule {
+	for _, alt := range alternatives {
+		if s.disabled[alt] {
+			continue
+		}
+		if rule, ok := s.rulesByID[alt]; ok {
+			return rule
+		}
+	}
+	return nil
+}
+
+func (s *ruleSelectionState) ruleName(id string) string {
+	if rule, ok := s.rulesByID[id]; ok {
+		return rule.Name
+	}
+	return id
+}
+
+func (s *ruleSelectionState) ruleNames(ids []string) []string {
+	names := make([]string, 0, len(ids))
+	for _, id := range ids {
+		names = append(names, s.ruleName(id))
+	}
+	return names
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// resolveRuleDependencies enforces the requires and conflicts relationships of
+// the rules touched by the tailoring. It returns the rules that need to be
+// enabled in order to satisfy the requirements (AutoEnable mode only). The
+// Profile is nil for tailorings built from scratch. The boolean tells whether
+// the error is retriable.
+func (r *ReconcileTailoredProfile) resolveRuleDependencies(tp *compliancev1alpha1.TailoredProfile, p *compliancev1alpha1.Profile, pb *compliancev1alpha1.ProfileBundle, rules map[string]*compliancev1alpha1.Rule) ([]requiredRule, bool, error) {
+	ruleList := &compliancev1alpha1.RuleList{}
+	if err := r.client.List(context.TODO(), ruleList, client.InNamespace(pb.Namespace)); err != nil {
+		return nil, true, err
+	}
+
+	var baseRules []compliancev1alpha1.ProfileRule
+	if p != nil {
+		baseRules = p.Rules
+	}
+	state := newRuleSelectionState(ruleList.Items, pb, baseRules, rules, tp)
+	if err := state.checkConflicts(); err != nil {
+		return nil, false, err
+	}
+	autoEnabled, err := state.resolveRequirements(tp.Spec.DependencyMode)
+	if err != nil {
+		return nil, false, err
+	}
+	// Enabling requirements might have introduced new conflicts
+	if len(autoEnabled) > 0 {
+		if err := state.checkConflicts(); err != nil {
+			return nil, false, err
+		}
+	}
+	return autoEnabled, false, nil
+}
@@ -0,0 +1,157 @@
+package tailoredprofile
+
+import (
+	compliancev1alpha1 "github.com/JAORMX/compliance-profile-operator/pkg/apis/compliance/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+func newTestBundle(name string) *compliancev1alpha1.ProfileBundle {
+	return &compliancev1alpha1.ProfileBundle{
+		TypeMeta:   metav1.TypeMeta{APIVersion: compliancev1alpha1.SchemeGroupVersion.String(), Kind: "ProfileBundle"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test", UID: types.UID(name + "-uid")},
+	}
+}
+
+func newBundleRule(pb *compliancev1alpha1.ProfileBundle, id string, requires, conflicts []string) compliancev1alpha1.Rule {
+	controller := true
+	return compliancev1alpha1.Rule{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pb.Name + "-" + id,
+			Namespace: pb.Namespace,
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: pb.APIVersion,
+				Kind:       pb.Kind,
+				Name:       pb.Name,
+				UID:        pb.UID,
+				Controller: &controller,
+			}},
+		},
+		ID:        id,
+		Requires:  requires,
+		Conflicts: conflicts,
+	}
+}
+
+// newDependencyState builds the selection state of a tailoring of the ocp4
+// bundle out of the short names of the rules the base profile selects, and
+// the ones the TailoredProfile enables and disables
+func newDependencyState(base, enable, disable []string) *ruleSelectionState {
+	ocp4 := newTestBundle("ocp4")
+	rhcos4 := newTestBundle("rhcos4")
+	ruleList := []compliancev1alpha1.Rule{
+		newBundleRule(ocp4, "audit", []string{"auditd"}, nil),
+		newBundleRule(ocp4, "auditd", nil, nil),
+		newBundleRule(ocp4, "chrony", nil, []string{"ntpd"}),
+		newBundleRule(ocp4, "ntpd", nil, nil),
+		newBundleRule(ocp4, "sshd", []string{"sshd_keys sshd_certs"}, nil),
+		newBundleRule(ocp4, "sshd_keys", nil, nil),
+		newBundleRule(ocp4, "sshd_certs", nil, nil),
+		newBundleRule(ocp4, "grouped", []string{"some_group"}, nil),
+		// Shares its ID with the ocp4 rule, and must not be taken into
+		// account when tailoring the ocp4 bundle
+		newBundleRule(rhcos4, "auditd", nil, []string{"audit"}),
+	}
+	rules := map[string]*compliancev1alpha1.Rule{}
+	for i := range ruleList {
+		rules[ruleList[i].Name] = &ruleList[i]
+	}
+
+	tp := &compliancev1alpha1.TailoredProfile{}
+	for _, name := range enable {
+		tp.Spec.EnableRules = append(tp.Spec.EnableRules, compliancev1alpha1.RuleReferenceSpec{Name: "ocp4-" + name})
+	}
+	for _, name := range disable {
+		tp.Spec.DisableRules = append(tp.Spec.DisableRules, compliancev1alpha1.RuleReferenceSpec{Name: "ocp4-" + name})
+	}
+	var baseRules []compliancev1alpha1.ProfileRule
+	for _, name := range base {
+		baseRules = append(baseRules, compliancev1alpha1.ProfileRule("ocp4-"+name))
+	}
+	return newRuleSelectionState(ruleList, ocp4, baseRules, rules, tp)
+}
+
+var _ = Describe("Testing rule dependencies", func() {
+	It("ignores the rules of other bundles", func() {
+		state := newDependencyState(nil, []string{"auditd"}, nil)
+		Expect(state.rulesByID["auditd"].Name).To(Equal("ocp4-auditd"))
+	})
+
+	table.DescribeTable("checking conflicts",
+		func(base, enable, disable []string, expectedErr string) {
+			err := newDependencyState(base, enable, disable).checkConflicts()
+			if expectedErr == "" {
+				Expect(err).ToNot(HaveOccurred())
+			} else {
+				Expect(err).To(MatchError(expectedErr))
+			}
+		},
+		table.Entry("no conflicting rules", []string{"ntpd"}, []string{"audit", "auditd"}, nil, ""),
+		table.Entry("conflicting rules of the base profile", []string{"chrony", "ntpd"}, nil, nil, ""),
+		table.Entry("enabling a rule conflicting with the base profile", []string{"ntpd"}, []string{"chrony"}, nil,
+			"Rule 'ocp4-chrony' conflicts with selected rule 'ocp4-ntpd'"),
+		table.Entry("enabling a rule the base profile conflicts with", []string{"chrony"}, []string{"ntpd"}, nil,
+			"Rule 'ocp4-chrony' conflicts with selected rule 'ocp4-ntpd'"),
+		table.Entry("disabling the conflicting rule", []string{"ntpd"}, []string{"chrony"}, []string{"ntpd"}, ""),
+	)
+
+	table.DescribeTable("resolving requirements",
+		func(mode compliancev1alpha1.RuleDependencyMode, base, enable, disable []string, expectedEnabled []string, expectedErr string) {
+			state := newDependencyState(base, enable, disable)
+			required, err := state.resolveRequirements(mode)
+			if expectedErr != "" {
+				Expect(err).To(MatchError(expectedErr))
+				return
+			}
+			Expect(err).ToNot(HaveOccurred())
+			enabled := []string{}
+			for _, r := range required {
+				enabled = append(enabled, r.rule.Name+" by "+r.requiredBy)
+				Expect(state.selected[r.rule.ID]).To(BeTrue())
+			}
+			Expect(enabled).To(Equal(expectedEnabled))
+		},
+		table.Entry("met requirement", compliancev1alpha1.RuleDependencyModeError,
+			[]string{"auditd"}, []string{"audit"}, nil, []string{}, ""),
+		table.Entry("requirement on a group", compliancev1alpha1.RuleDependencyModeError,
+			nil, []string{"grouped"}, nil, []string{}, ""),
+		table.Entry("unmet requirement of the base profile", compliancev1alpha1.RuleDependencyModeError,
+			[]string{"audit"}, nil, nil, []string{}, ""),
+		table.Entry("unmet requirement of an enabled rule", compliancev1alpha1.RuleDependencyModeError,
+			nil, []string{"audit"}, nil, nil, "Rule 'ocp4-audit' requires one of [ocp4-auditd] to be selected"),
+		table.Entry("disabled requirement", compliancev1alpha1.RuleDependencyModeError,
+			[]string{"audit", "auditd"}, nil, []string{"auditd"}, nil, "Rule 'ocp4-audit' requires one of [ocp4-auditd] to be selected"),
+		table.Entry("auto-enabled requirement", compliancev1alpha1.RuleDependencyModeAutoEnable,
+			nil, []string{"audit"}, nil, []string{"ocp4-auditd by ocp4-audit"}, ""),
+		table.Entry("first alternative is auto-enabled", compliancev1alpha1.RuleDependencyModeAutoEnable,
+			nil, []string{"sshd"}, nil, []string{"ocp4-sshd_keys by ocp4-sshd"}, ""),
+		table.Entry("disabled alternatives aren't auto-enabled", compliancev1alpha1.RuleDependencyModeAutoEnable,
+			nil, []string{"sshd"}, []string{"sshd_keys"}, []string{"ocp4-sshd_certs by ocp4-sshd"}, ""),
+		table.Entry("disabled requirement isn't auto-enabled", compliancev1alpha1.RuleDependencyModeAutoEnable,
+			[]string{"audit", "auditd"}, nil, []string{"auditd"}, nil, "Rule 'ocp4-audit' requires one of [ocp4-auditd] to be selected"),
+	)
+})
+
+var _ = Describe("Testing required rules", func() {
+	It("doesn't modify the rules it's given", func() {
+		pb := newTestBundle("ocp4")
+		audit := newBundleRule(pb, "audit", []string{"auditd"}, nil)
+		auditd := newBundleRule(pb, "auditd", nil, nil)
+		tp := &compliancev1alpha1.TailoredProfile{}
+		tp.Spec.EnableRules = []compliancev1alpha1.RuleReferenceSpec{{Name: audit.Name}}
+		rules := map[string]*compliancev1alpha1.Rule{audit.Name: &audit}
+
+		tailored, tailoredRules := withRequiredRules(tp, rules, []requiredRule{{rule: &auditd, requiredBy: audit.Name}})
+		Expect(rules).To(HaveLen(1))
+		Expect(tp.Spec.EnableRules).To(HaveLen(1))
+		Expect(tailoredRules).To(HaveKey(auditd.Name))
+		Expect(tailored.Spec.EnableRules).To(ContainElement(compliancev1alpha1.RuleReferenceSpec{
+			Name:      auditd.Name,
+			Rationale: "Required by rule 'ocp4-audit'",
+		}))
+	})
+})
@@ -0,0 +1,163 @@
+package tailoredprofile
+
+import (
+	"context"
+	"fmt"
+
+	compliancev1alpha1 "github.com/JAORMX/compliance-profile-operator/pkg/apis/compliance/v1alpha1"
+	"github.com/JAORMX/compliance-profile-operator/pkg/policy"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// Most outputs are owned by their TailoredProfile, and garbage collected along
+// with it. The ones that can't be owned, because they're cluster-scoped, live
+// in another namespace or must not be collected by the hub's own controllers,
+// are annotated with the TailoredProfile they were generated for, and removed
+// by its finalizer.
+
+// unownedOutput identifies an output that isn't owned by its TailoredProfile.
+// Cluster-scoped outputs have no namespace.
+type unownedOutput struct {
+	gvk schema.GroupVersionKind
+	key types.NamespacedName
+}
+
+// getUnownedOutputs gets the outputs that may have been generated for the
+// TailoredProfile without being owned by it. The Policies of every known API
+// version are included, as the configured one may have changed since they
+// were generated.
+func getUnownedOutputs(tp *compliancev1alpha1.TailoredProfile) []unownedOutput {
+	policyKey := types.NamespacedName{Name: tp.Name, Namespace: tp.Namespace}
+	return []unownedOutput{
+		{schema.FromAPIVersionAndKind(policy.APIVersionV1, "Policy"), policyKey},
+		{schema.FromAPIVersionAndKind(policy.APIVersionMCMV1alpha1, "Policy"), policyKey},
+	}
+}
+
+// getGeneratedBy gets the value of the annotation outputs that aren't owned
+// by the TailoredProfile are marked with
+func getGeneratedBy(tp *compliancev1alpha1.TailoredProfile) string {
+	return tp.Namespace + "/" + tp.Name
+}
+
+func hasFinalizer(tp *compliancev1alpha1.TailoredProfile) bool {
+	for _, finalizer := range tp.GetFinalizers() {
+		if finalizer == compliancev1alpha1.TailoredProfileFinalizer {
+			return true
+		}
+	}
+	return false
+}
+
+// mayHaveGeneratedPolicy tells whether a Policy may have been generated for a
+// TailoredProfile whose output type isn't Policy, going by the output its
+// status references. The reference survives errors, and is only dropped once
+// the Policy was removed. Statuses that reference an output without its kind
+// predate the finalizer, so they may still have one. Looking the Policies up
+// reads from the API server, which is spared for the TailoredProfiles that
+// never had one.
+func mayHaveGeneratedPolicy(tp *compliancev1alpha1.TailoredProfile) bool {
+	ref := tp.Status.OutputRef
+	return ref.Kind == "Policy" || (ref.Kind == "" && ref.Name != "")
+}
+
+// ensureFinalizer adds the finalizer to the TailoredProfile if it generates
+// outputs that aren't garbage collected along with it: if its output type is
+// Policy, or if a Policy was generated for it before the finalizer existed.
+// It tells whether the TailoredProfile was updated.
+func (r *ReconcileTailoredProfile) ensureFinalizer(tp *compliancev1alpha1.TailoredProfile) (bool, error) {
+	if hasFinalizer(tp) {
+		return false, nil
+	}
+	if tp.Spec.OutputType != compliancev1alpha1.PolicyOutput {
+		if !mayHaveGeneratedPolicy(tp) {
+			return false, nil
+		}
+		outputs, err := r.getUnownedOutputObjects(tp)
+		if err != nil || len(outputs) == 0 {
+			return false, err
+		}
+	}
+	tpCopy := tp.DeepCopy()
+	controllerutil.AddFinalizer(tpCopy, compliancev1alpha1.TailoredProfileFinalizer)
+	return true, r.client.Update(context.TODO(), tpCopy)
+}
+
+// removeFinalizer lets the TailoredProfile go, or keep living without the
+// finalizer once it has no unowned outputs left
+func (r *ReconcileTailoredProfile) removeFinalizer(tp *compliancev1alpha1.TailoredProfile) error {
+	tpCopy := tp.DeepCopy()
+	controllerutil.RemoveFinalizer(tpCopy, compliancev1alpha1.TailoredProfileFinalizer)
+	return r.client.Update(context.TODO(), tpCopy)
+}
+
+// finalizeTailoredProfile removes the outputs of the TailoredProfile that
+// aren't garbage collected along with it, and then lets it go. Failures are
+// surfaced in its status, and retried.
+func (r *ReconcileTailoredProfile) finalizeTailoredProfile(tp *compliancev1alpha1.TailoredProfile, logger logr.Logger) (reconcile.Result, error) {
+	if !hasFinalizer(tp) {
+		return reconcile.Result{}, nil
+	}
+
+	if err := r.removeUnownedOutputs(tp, logger); err != nil {
+		updateErr := r.updateTailoredProfileStatusError(tp, compliancev1alpha1.TailoredProfileConditionOutputReady, compliancev1alpha1.ReasonCleanupFailed,
+			fmt.Errorf("Couldn't remove the outputs of TailoredProfile '%s': %s", tp.Name, err))
+		if updateErr != nil {
+			return reconcile.Result{}, updateErr
+		}
+		return reconcile.Result{}, err
+	}
+
+	return reconcile.Result{}, r.removeFinalizer(tp)
+}
+
+// isGeneratedFor tells whether the output was generated for the TailoredProfile
+func isGeneratedFor(obj metav1.Object, tp *compliancev1alpha1.TailoredProfile) bool {
+	return obj.GetAnnotations()[compliancev1alpha1.GeneratedByAnnotation] == getGeneratedBy(tp)
+}
+
+// getUnownedOutputObjects gets the outputs that were generated for the
+// TailoredProfile without being owned by it. Objects with the same name that
+// were generated for something else are left out.
+func (r *ReconcileTailoredProfile) getUnownedOutputObjects(tp *compliancev1alpha1.TailoredProfile) ([]*unstructured.Unstructured, error) {
+	objs := []*unstructured.Unstructured{}
+	for _, output := range getUnownedOutputs(tp) {
+		found := &unstructured.Unstructured{}
+		found.SetGroupVersionKind(output.gvk)
+		err := r.client.Get(context.TODO(), output.key, found)
+		if errors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			// Not generated, or the API isn't even served
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if isGeneratedFor(found, tp) {
+			objs = append(objs, found)
+		}
+	}
+	return objs, nil
+}
+
+// removeUnownedOutputs removes the outputs that were generated for the
+// TailoredProfile without being owned by it
+func (r *ReconcileTailoredProfile) removeUnownedOutputs(tp *compliancev1alpha1.TailoredProfile, logger logr.Logger) error {
+	objs, err := r.getUnownedOutputObjects(tp)
+	if err != nil {
+		return err
+	}
+	for _, obj := range objs {
+		logger.Info("Removing the "+obj.GetKind(), "Object.Namespace", obj.GetNamespace(), "Object.Name", obj.GetName())
+		if err := r.client.Delete(context.TODO(), obj); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
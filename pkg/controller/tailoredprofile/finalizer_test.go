@@ -0,0 +1,127 @@
+package tailoredprofile
+
+import (
+	"context"
+
+	compliancev1alpha1 "github.com/JAORMX/compliance-profile-operator/pkg/apis/compliance/v1alpha1"
+	"github.com/JAORMX/compliance-profile-operator/pkg/policy"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Testing the outputs removed by the finalizer", func() {
+	var tp *compliancev1alpha1.TailoredProfile
+	var r *ReconcileTailoredProfile
+
+	getTailoredProfile := func() *compliancev1alpha1.TailoredProfile {
+		found := &compliancev1alpha1.TailoredProfile{}
+		Expect(r.client.Get(context.TODO(), types.NamespacedName{Name: "tp", Namespace: "test"}, found)).To(Succeed())
+		return found
+	}
+
+	BeforeEach(func() {
+		tp = newTestTailoredProfile("tp")
+		tp.Spec.OutputType = compliancev1alpha1.ConfigMapOutput
+	})
+
+	It("covers the Policies of every API version", func() {
+		outputs := getUnownedOutputs(tp)
+		Expect(outputs).To(ConsistOf(
+			unownedOutput{schema.GroupVersionKind{Group: "policy.open-cluster-management.io", Version: "v1", Kind: "Policy"}, types.NamespacedName{Name: "tp", Namespace: "test"}},
+			unownedOutput{schema.GroupVersionKind{Group: "policy.mcm.ibm.com", Version: "v1alpha1", Kind: "Policy"}, types.NamespacedName{Name: "tp", Namespace: "test"}},
+		))
+	})
+
+	Context("matching the outputs generated for the TailoredProfile", func() {
+		It("finds the Policies generated for it", func() {
+			r = newTestReconciler(tp, newTestPolicy(tp, policy.APIVersionV1), newTestPolicy(tp, policy.APIVersionMCMV1alpha1))
+			outputs, err := r.getUnownedOutputObjects(tp)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(outputs).To(HaveLen(2))
+		})
+
+		It("ignores the Policies generated for a namesake of another namespace", func() {
+			other := newTestTailoredProfile("tp")
+			other.Namespace = "other"
+			otherPolicy := newTestPolicy(other, policy.APIVersionV1)
+			otherPolicy.SetNamespace("test")
+			r = newTestReconciler(tp, otherPolicy)
+			Expect(isGeneratedFor(otherPolicy, tp)).To(BeFalse())
+			outputs, err := r.getUnownedOutputObjects(tp)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(outputs).To(BeEmpty())
+		})
+
+		It("keeps the Policies it didn't generate", func() {
+			unannotated := newTestPolicy(tp, policy.APIVersionV1)
+			unannotated.SetAnnotations(nil)
+			r = newTestReconciler(tp, unannotated)
+			Expect(r.removeUnownedOutputs(tp, logf.Log)).To(Succeed())
+
+			found := &unstructured.Unstructured{}
+			found.SetGroupVersionKind(unannotated.GroupVersionKind())
+			Expect(r.client.Get(context.TODO(), types.NamespacedName{Name: "tp", Namespace: "test"}, found)).To(Succeed())
+		})
+	})
+
+	Context("adding the finalizer", func() {
+		It("adds it to TailoredProfiles generating a Policy", func() {
+			tp.Spec.OutputType = compliancev1alpha1.PolicyOutput
+			r = newTestReconciler(tp)
+			Expect(r.ensureFinalizer(tp)).To(BeTrue())
+			Expect(hasFinalizer(getTailoredProfile())).To(BeTrue())
+		})
+
+		It("leaves the other TailoredProfiles alone", func() {
+			r = newTestReconciler(tp)
+			Expect(r.ensureFinalizer(tp)).To(BeFalse())
+			Expect(hasFinalizer(getTailoredProfile())).To(BeFalse())
+		})
+
+		It("adds it to TailoredProfiles that generated a Policy before", func() {
+			tp.Status.OutputRef = compliancev1alpha1.OutputRef{Kind: "Policy", Name: "tp", Namespace: "test"}
+			r = newTestReconciler(tp, newTestPolicy(tp, policy.APIVersionV1))
+			Expect(r.ensureFinalizer(tp)).To(BeTrue())
+			Expect(hasFinalizer(getTailoredProfile())).To(BeTrue())
+		})
+
+		It("adds it to TailoredProfiles whose status predates the output kind", func() {
+			tp.Status.OutputRef = compliancev1alpha1.OutputRef{Name: "tp", Namespace: "test"}
+			r = newTestReconciler(tp, newTestPolicy(tp, policy.APIVersionMCMV1alpha1))
+			Expect(r.ensureFinalizer(tp)).To(BeTrue())
+			Expect(hasFinalizer(getTailoredProfile())).To(BeTrue())
+		})
+
+		It("doesn't look for Policies when the status references no output", func() {
+			r = newTestReconciler(tp, newTestPolicy(tp, policy.APIVersionV1))
+			Expect(r.ensureFinalizer(tp)).To(BeFalse())
+		})
+
+		It("doesn't look for Policies when the status references another output", func() {
+			tp.Status.OutputRef = compliancev1alpha1.OutputRef{Kind: "ConfigMap", Name: "tp-tp", Namespace: "test"}
+			r = newTestReconciler(tp, newTestPolicy(tp, policy.APIVersionV1))
+			Expect(r.ensureFinalizer(tp)).To(BeFalse())
+		})
+	})
+
+	It("removes the Policies and lets the TailoredProfile go", func() {
+		tp.Spec.OutputType = compliancev1alpha1.PolicyOutput
+		tp.SetFinalizers([]string{compliancev1alpha1.TailoredProfileFinalizer, "other"})
+		policyObj := newTestPolicy(tp, policy.APIVersionV1)
+		r = newTestReconciler(tp, policyObj)
+
+		_, err := r.finalizeTailoredProfile(tp, logf.Log)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(getTailoredProfile().GetFinalizers()).To(Equal([]string{"other"}))
+		found := &unstructured.Unstructured{}
+		found.SetGroupVersionKind(policyObj.GroupVersionKind())
+		err = r.client.Get(context.TODO(), types.NamespacedName{Name: "tp", Namespace: "test"}, found)
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+	})
+})
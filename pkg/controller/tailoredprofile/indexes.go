@@ -0,0 +1,251 @@
+package tailoredprofile
+
+import (
+	"context"
+	"strings"
+
+	compliancev1alpha1 "github.com/JAORMX/compliance-profile-operator/pkg/apis/compliance/v1alpha1"
+	"github.com/JAORMX/compliance-profile-operator/pkg/controller/common"
+	"github.com/JAORMX/compliance-profile-operator/pkg/xccdf"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// The indexes of the references to other objects hold keys in the
+// "namespace/name" form, so TailoredProfiles referencing the content of
+// another namespace are found as well
+const (
+	// extendsIndex indexes TailoredProfiles by the Profile they extend
+	extendsIndex = "spec.extends"
+	// extendsTailoredProfileIndex indexes TailoredProfiles by the
+	// TailoredProfile they extend
+	extendsTailoredProfileIndex = "spec.extendsTailoredProfile"
+	// profileBundleIndex indexes TailoredProfiles by the ProfileBundle
+	// they're built from
+	profileBundleIndex = "spec.profileBundle"
+	// variablesIndex indexes TailoredProfiles by the references to the
+	// Variables they set or refine
+	variablesIndex = "spec.setValues"
+	// profileBundleRulesIndex indexes TailoredProfiles by the ProfileBundle
+	// their rules are resolved from. Any Rule of the bundle may affect the
+	// tailoring, not only the ones it references by name: selectors may
+	// match new Rules, the selected rules may require or conflict with them,
+	// and tailorings built from scratch disable every Rule they don't
+	// enable. This covers the Rules they reference by name too.
+	profileBundleRulesIndex = "status.profileBundleRef"
+	// contentNamespaceIndex indexes TailoredProfiles by the namespace of the
+	// content they reference, if it's not their own
+	contentNamespaceIndex = "spec.contentNamespace"
+)
+
+// addIndexes registers the field indexes used to find the TailoredProfiles
+// that depend on a given Profile, Rule or Variable, as well as the ones used to
+// resolve references to Rules and Variables by XCCDF ID.
+func addIndexes(mgr manager.Manager) error {
+	indexer := mgr.GetFieldIndexer()
+	if err := common.AddIDIndexes(indexer); err != nil {
+		return err
+	}
+	if err := indexer.IndexField(&compliancev1alpha1.TailoredProfile{}, extendsIndex, extendsIndexFunc); err != nil {
+		return err
+	}
+	if err := indexer.IndexField(&compliancev1alpha1.TailoredProfile{}, extendsTailoredProfileIndex, extendsTailoredProfileIndexFunc); err != nil {
+		return err
+	}
+	if err := indexer.IndexField(&compliancev1alpha1.TailoredProfile{}, profileBundleIndex, profileBundleIndexFunc); err != nil {
+		return err
+	}
+	if err := indexer.IndexField(&compliancev1alpha1.TailoredProfile{}, variablesIndex, variablesIndexFunc); err != nil {
+		return err
+	}
+	if err := indexer.IndexField(&compliancev1alpha1.TailoredProfile{}, profileBundleRulesIndex, profileBundleRulesIndexFunc); err != nil {
+		return err
+	}
+	return indexer.IndexField(&compliancev1alpha1.TailoredProfile{}, contentNamespaceIndex, contentNamespaceIndexFunc)
+}
+
+func getIndexKey(namespace, name string) string {
+	return namespace + "/" + name
+}
+
+func extendsIndexFunc(obj runtime.Object) []string {
+	tp := obj.(*compliancev1alpha1.TailoredProfile)
+	if tp.Spec.Extends == "" || tp.Spec.ExtendsKind == compliancev1alpha1.ExtendsTailoredProfile {
+		return nil
+	}
+	return []string{getIndexKey(tp.GetContentNamespace(), tp.Spec.Extends)}
+}
+
+func extendsTailoredProfileIndexFunc(obj runtime.Object) []string {
+	tp := obj.(*compliancev1alpha1.TailoredProfile)
+	if tp.Spec.Extends == "" || tp.Spec.ExtendsKind != compliancev1alpha1.ExtendsTailoredProfile {
+		return nil
+	}
+	return []string{getIndexKey(tp.GetContentNamespace(), tp.Spec.Extends)}
+}
+
+func profileBundleIndexFunc(obj runtime.Object) []string {
+	tp := obj.(*compliancev1alpha1.TailoredProfile)
+	if tp.Spec.ProfileBundle == "" {
+		return nil
+	}
+	return []string{getIndexKey(tp.GetContentNamespace(), tp.Spec.ProfileBundle)}
+}
+
+// getResolvedContentNamespace gets the namespace the Variables of the
+// TailoredProfile are resolved from: the one of the ProfileBundle recorded in
+// its status, which may differ from its content namespace when it extends a
+// TailoredProfile of another namespace. Until the bundle is recorded, the
+// content namespace is the best guess.
+func getResolvedContentNamespace(tp *compliancev1alpha1.TailoredProfile) string {
+	if tp.Status.ProfileBundleRef != nil {
+		return tp.Status.ProfileBundleRef.Namespace
+	}
+	return tp.GetContentNamespace()
+}
+
+func variablesIndexFunc(obj runtime.Object) []string {
+	tp := obj.(*compliancev1alpha1.TailoredProfile)
+	namespace := getResolvedContentNamespace(tp)
+	variables := []string{}
+	for _, setValue := range tp.Spec.SetValues {
+		variables = append(variables, getIndexKey(namespace, setValue.Name))
+	}
+	for _, refinement := range tp.Spec.RefineValues {
+		variables = append(variables, getIndexKey(namespace, refinement.Name))
+	}
+	return variables
+}
+
+func profileBundleRulesIndexFunc(obj runtime.Object) []string {
+	tp := obj.(*compliancev1alpha1.TailoredProfile)
+	if tp.Status.ProfileBundleRef == nil {
+		return nil
+	}
+	return []string{getIndexKey(tp.Status.ProfileBundleRef.Namespace, tp.Status.ProfileBundleRef.Name)}
+}
+
+func contentNamespaceIndexFunc(obj runtime.Object) []string {
+	tp := obj.(*compliancev1alpha1.TailoredProfile)
+	if tp.GetContentNamespace() == tp.Namespace {
+		return nil
+	}
+	return []string{tp.GetContentNamespace()}
+}
+
+// dependentTailoredProfileMapper maps an object to the TailoredProfiles that
+// reference it through the given field index, whatever their namespace.
+type dependentTailoredProfileMapper struct {
+	client client.Client
+	index  string
+}
+
+// Map implements handler.Mapper
+func (m *dependentTailoredProfileMapper) Map(obj handler.MapObject) []reconcile.Request {
+	requests := []reconcile.Request{}
+	seen := make(map[types.NamespacedName]bool)
+	for _, key := range getReferenceKeys(obj) {
+		tpList := &compliancev1alpha1.TailoredProfileList{}
+		err := m.client.List(context.TODO(), tpList,
+			client.MatchingFields{m.index: getIndexKey(obj.Meta.GetNamespace(), key)})
+		if err != nil {
+			log.Error(err, "Couldn't list the TailoredProfiles depending on an object",
+				"Object.Namespace", obj.Meta.GetNamespace(), "Object.Name", obj.Meta.GetName(), "Index", m.index)
+			return nil
+		}
+
+		for _, tp := range tpList.Items {
+			key := types.NamespacedName{Name: tp.Name, Namespace: tp.Namespace}
+			if !seen[key] {
+				seen[key] = true
+				requests = append(requests, reconcile.Request{NamespacedName: key})
+			}
+		}
+	}
+	return requests
+}
+
+// getReferenceKeys gets the keys a TailoredProfile may reference the object
+// by: its name and, for Variables, their XCCDF ID and short names
+func getReferenceKeys(obj handler.MapObject) []string {
+	keys := []string{obj.Meta.GetName()}
+	variable, ok := obj.Object.(*compliancev1alpha1.Variable)
+	if !ok {
+		return keys
+	}
+	id := variable.ID
+	keys = append(keys, id)
+	if shortName := xccdf.GetShortNameFromID(id); shortName != "" {
+		keys = append(keys, shortName, strings.ReplaceAll(shortName, "_", "-"))
+	}
+	return keys
+}
+
+// bundleTailoredProfileMapper maps a Rule to the TailoredProfiles whose rules
+// are resolved from the ProfileBundle it belongs to
+type bundleTailoredProfileMapper struct {
+	client client.Client
+}
+
+// Map implements handler.Mapper
+func (m *bundleTailoredProfileMapper) Map(obj handler.MapObject) []reconcile.Request {
+	owner := metav1.GetControllerOf(obj.Meta)
+	if owner == nil || owner.Kind != "ProfileBundle" {
+		return nil
+	}
+	tpList := &compliancev1alpha1.TailoredProfileList{}
+	err := m.client.List(context.TODO(), tpList,
+		client.MatchingFields{profileBundleRulesIndex: getIndexKey(obj.Meta.GetNamespace(), owner.Name)})
+	if err != nil {
+		log.Error(err, "Couldn't list the TailoredProfiles tailoring the bundle of a Rule",
+			"Rule.Namespace", obj.Meta.GetNamespace(), "Rule.Name", obj.Meta.GetName())
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+	for _, tp := range tpList.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: tp.Name, Namespace: tp.Namespace},
+		})
+	}
+	return requests
+}
+
+// getProfileBundleRef gets the reference recorded in the status of the
+// TailoredProfiles whose content comes from the ProfileBundle
+func getProfileBundleRef(pb *compliancev1alpha1.ProfileBundle) *compliancev1alpha1.ProfileBundleRef {
+	return &compliancev1alpha1.ProfileBundleRef{Name: pb.Name, Namespace: pb.Namespace}
+}
+
+// grantedTailoredProfileMapper maps a ReferenceGrant to the TailoredProfiles
+// that reference the content of its namespace from another one. These are
+// reconciled whenever a grant changes, as they might have gained or lost
+// access to the content.
+type grantedTailoredProfileMapper struct {
+	client client.Client
+}
+
+// Map implements handler.Mapper
+func (m *grantedTailoredProfileMapper) Map(obj handler.MapObject) []reconcile.Request {
+	tpList := &compliancev1alpha1.TailoredProfileList{}
+	err := m.client.List(context.TODO(), tpList,
+		client.MatchingFields{contentNamespaceIndex: obj.Meta.GetNamespace()})
+	if err != nil {
+		log.Error(err, "Couldn't list the TailoredProfiles referencing the content of a namespace",
+			"ReferenceGrant.Namespace", obj.Meta.GetNamespace(), "ReferenceGrant.Name", obj.Meta.GetName())
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+	for _, tp := range tpList.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: tp.Name, Namespace: tp.Namespace},
+		})
+	}
+	return requests
+}
@@ -0,0 +1,29 @@
+package tailoredprofile
+
+import (
+	compliancev1alpha1 "github.com/JAORMX/compliance-profile-operator/pkg/apis/compliance/v1alpha1"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Testing the TailoredProfile indexes", func() {
+	var tp *compliancev1alpha1.TailoredProfile
+
+	BeforeEach(func() {
+		tp = newExtendingTailoredProfile("tp", "parent")
+		tp.Namespace = "my-app"
+		tp.Spec.ContentNamespace = "shared"
+		tp.Spec.SetValues = []compliancev1alpha1.VariableValueSpec{valueRef("var_timeout", "60")}
+	})
+
+	It("indexes references by the content namespace until the bundle is known", func() {
+		Expect(variablesIndexFunc(tp)).To(Equal([]string{"shared/var_timeout"}))
+	})
+
+	It("indexes references by the namespace of the bundle they're resolved from", func() {
+		tp.Status.ProfileBundleRef = &compliancev1alpha1.ProfileBundleRef{Name: "ocp4", Namespace: "compliance-content"}
+		Expect(variablesIndexFunc(tp)).To(Equal([]string{"compliance-content/var_timeout"}))
+		Expect(extendsTailoredProfileIndexFunc(tp)).To(Equal([]string{"shared/parent"}))
+	})
+})
@@ -0,0 +1,233 @@
+package tailoredprofile
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	compliancev1alpha1 "github.com/JAORMX/compliance-profile-operator/pkg/apis/compliance/v1alpha1"
+	"github.com/JAORMX/compliance-profile-operator/pkg/controller/common"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// resolveExtendsChain walks up the TailoredProfiles the given one extends until
+// it reaches a Profile. It returns that Profile and the TailoredProfiles in
+// between, ordered from the Profile downwards. If the chain starts with a
+// TailoredProfile built from scratch, the Profile is nil. Every reference to
+// another namespace needs to be granted. The boolean tells whether the error
+// is retriable.
+func (r *ReconcileTailoredProfile) resolveExtendsChain(tp *compliancev1alpha1.TailoredProfile) (*compliancev1alpha1.Profile, []*compliancev1alpha1.TailoredProfile, bool, error) {
+	parents := []*compliancev1alpha1.TailoredProfile{}
+	path := []string{tp.Name}
+	visited := map[types.NamespacedName]bool{{Name: tp.Name, Namespace: tp.Namespace}: true}
+
+	current := tp
+	for current.Spec.Extends != "" && current.Spec.ExtendsKind == compliancev1alpha1.ExtendsTailoredProfile {
+		key := types.NamespacedName{Name: current.Spec.Extends, Namespace: current.GetContentNamespace()}
+		path = append(path, getQualifiedName(tp, key.Namespace, key.Name))
+		if visited[key] {
+			return nil, nil, false, fmt.Errorf("TailoredProfile inheritance cycle: %s", strings.Join(path, " -> "))
+		}
+		visited[key] = true
+
+		if retriable, err := r.checkReferenceGrant(current); err != nil {
+			return nil, nil, retriable, err
+		}
+		parent := &compliancev1alpha1.TailoredProfile{}
+		err := r.client.Get(context.TODO(), key, parent)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				return nil, nil, false, fmt.Errorf("TailoredProfile '%s' extended by '%s' not found",
+					getQualifiedName(tp, key.Namespace, key.Name), current.Name)
+			}
+			return nil, nil, true, err
+		}
+		parents = append([]*compliancev1alpha1.TailoredProfile{parent}, parents...)
+		current = parent
+	}
+
+	if current.Spec.Extends == "" {
+		if current.Spec.ProfileBundle == "" {
+			return nil, nil, false, fmt.Errorf("TailoredProfile '%s' doesn't specify what it extends", current.Name)
+		}
+		// Built from scratch
+		if retriable, err := r.checkReferenceGrant(current); err != nil {
+			return nil, nil, retriable, err
+		}
+		return nil, parents, false, nil
+	}
+
+	if retriable, err := r.checkReferenceGrant(current); err != nil {
+		return nil, nil, retriable, err
+	}
+	p := &compliancev1alpha1.Profile{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: current.Spec.Extends, Namespace: current.GetContentNamespace()}, p)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			// the Profile object didn't exist. Surface the error.
+			return nil, nil, false, err
+		}
+		// could be a transient error that can be recovered from
+		return nil, nil, true, err
+	}
+	return p, parents, false, nil
+}
+
+// checkReferenceGrant makes sure the TailoredProfile may reference the object
+// it extends or is built from. The boolean tells whether the error is
+// retriable.
+func (r *ReconcileTailoredProfile) checkReferenceGrant(tp *compliancev1alpha1.TailoredProfile) (bool, error) {
+	kind, name := tp.GetReference()
+	granted, err := common.IsReferenceGranted(context.TODO(), r.client, tp.Namespace, kind, tp.GetContentNamespace(), name)
+	if err != nil {
+		return true, err
+	}
+	if !granted {
+		return false, fmt.Errorf("No ReferenceGrant in namespace '%s' allows TailoredProfile '%s' of namespace '%s' to reference %s '%s'",
+			tp.GetContentNamespace(), tp.Name, tp.Namespace, kind, name)
+	}
+	return false, nil
+}
+
+// getProfileBundleForChain gets the ProfileBundle the chain of TailoredProfiles
+// applies to: either the bundle of the Profile at its root, or the bundle the
+// root TailoredProfile was built from. The boolean tells whether the error is
+// retriable.
+func (r *ReconcileTailoredProfile) getProfileBundleForChain(tp *compliancev1alpha1.TailoredProfile, p *compliancev1alpha1.Profile, parents []*compliancev1alpha1.TailoredProfile) (*compliancev1alpha1.ProfileBundle, bool, error) {
+	if p != nil {
+		pb, err := r.getProfileBundleFromProfile(p)
+		return pb, true, err
+	}
+
+	root := tp
+	if len(parents) > 0 {
+		root = parents[0]
+	}
+	pb := &compliancev1alpha1.ProfileBundle{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: root.Spec.ProfileBundle, Namespace: root.GetContentNamespace()}, pb)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, false, fmt.Errorf("ProfileBundle '%s' not found", getQualifiedName(tp, root.GetContentNamespace(), root.Spec.ProfileBundle))
+		}
+		return nil, true, err
+	}
+	return pb, false, nil
+}
+
+// getQualifiedName gets the name of an object as seen from the TailoredProfile:
+// objects of other namespaces are prefixed with their namespace
+func getQualifiedName(tp *compliancev1alpha1.TailoredProfile, namespace, name string) string {
+	if namespace == tp.Namespace {
+		return name
+	}
+	return namespace + "/" + name
+}
+
+// getExtendsChain returns the chain of objects a TailoredProfile inherits from,
+// as shown in its status
+func getExtendsChain(tp *compliancev1alpha1.TailoredProfile, p *compliancev1alpha1.Profile, pb *compliancev1alpha1.ProfileBundle, parents []*compliancev1alpha1.TailoredProfile) []string {
+	chain := []string{"ProfileBundle/" + getQualifiedName(tp, pb.Namespace, pb.Name)}
+	if p != nil {
+		chain = []string{"Profile/" + getQualifiedName(tp, p.Namespace, p.Name)}
+	}
+	for _, parent := range parents {
+		chain = append(chain, "TailoredProfile/"+getQualifiedName(tp, parent.Namespace, parent.Name))
+	}
+	return chain
+}
+
+// flattenTailoredProfile returns a copy of the TailoredProfile that contains the
+// changes of all the TailoredProfiles it extends. The selections and values
+// of a TailoredProfile override the ones of the TailoredProfiles it extends,
+// as do its refinements, and its title and description if they're set.
+func flattenTailoredProfile(tp *compliancev1alpha1.TailoredProfile, parents []*compliancev1alpha1.TailoredProfile) *compliancev1alpha1.TailoredProfile {
+	if len(parents) == 0 {
+		return tp
+	}
+
+	flat := tp.DeepCopy()
+	flat.Spec.EnableRules = nil
+	flat.Spec.DisableRules = nil
+	flat.Spec.SetValues = nil
+	flat.Spec.RefineRules = nil
+	flat.Spec.RefineValues = nil
+	layers := make([]*compliancev1alpha1.TailoredProfile, 0, len(parents)+1)
+	layers = append(layers, parents...)
+	for _, layer := range append(layers, tp) {
+		overridden := make(map[string]bool)
+		// Selectors are expanded later on, so they're simply accumulated
+		for _, selection := range layer.Spec.EnableRules {
+			if selection.Selector == nil {
+				overridden[selection.Name] = true
+			}
+		}
+		for _, selection := range layer.Spec.DisableRules {
+			if selection.Selector == nil {
+				overridden[selection.Name] = true
+			}
+		}
+		flat.Spec.EnableRules = append(withoutRules(flat.Spec.EnableRules, overridden), layer.Spec.EnableRules...)
+		flat.Spec.DisableRules = append(withoutRules(flat.Spec.DisableRules, overridden), layer.Spec.DisableRules...)
+
+		overriddenValues := make(map[string]bool)
+		for _, setValue := range layer.Spec.SetValues {
+			overriddenValues[setValue.Name] = true
+		}
+		values := []compliancev1alpha1.VariableValueSpec{}
+		for _, setValue := range flat.Spec.SetValues {
+			if !overriddenValues[setValue.Name] {
+				values = append(values, setValue)
+			}
+		}
+		flat.Spec.SetValues = append(values, layer.Spec.SetValues...)
+
+		overriddenRefinements := make(map[string]bool)
+		for _, refinement := range layer.Spec.RefineRules {
+			overriddenRefinements[refinement.Name] = true
+		}
+		ruleRefinements := []compliancev1alpha1.RuleRefinementSpec{}
+		for _, refinement := range flat.Spec.RefineRules {
+			if !overriddenRefinements[refinement.Name] {
+				ruleRefinements = append(ruleRefinements, refinement)
+			}
+		}
+		flat.Spec.RefineRules = append(ruleRefinements, layer.Spec.RefineRules...)
+
+		overriddenRefinements = make(map[string]bool)
+		for _, refinement := range layer.Spec.RefineValues {
+			overriddenRefinements[refinement.Name] = true
+		}
+		valueRefinements := []compliancev1alpha1.ValueRefinementSpec{}
+		for _, refinement := range flat.Spec.RefineValues {
+			if !overriddenRefinements[refinement.Name] {
+				valueRefinements = append(valueRefinements, refinement)
+			}
+		}
+		flat.Spec.RefineValues = append(valueRefinements, layer.Spec.RefineValues...)
+
+		if layer == tp {
+			continue
+		}
+		// Inherit the texts the TailoredProfile doesn't override
+		if tp.Spec.Title == "" && layer.Spec.Title != "" {
+			flat.Spec.Title = layer.Spec.Title
+			flat.Spec.TitleTranslations = layer.Spec.TitleTranslations
+		}
+		if tp.Spec.Description == "" && layer.Spec.Description != "" {
+			flat.Spec.Description = layer.Spec.Description
+			flat.Spec.DescriptionTranslations = layer.Spec.DescriptionTranslations
+		}
+	}
+	return flat
+}
+
+func withoutRules(selections []compliancev1alpha1.RuleReferenceSpec, names map[string]bool) []compliancev1alpha1.RuleReferenceSpec {
+	filtered := []compliancev1alpha1.RuleReferenceSpec{}
+	for _, selection := range selections {
+		if !names[selection.Name] {
+			filtered = append(filtered, selection)
+		}
+	}
+	return filtered
+}
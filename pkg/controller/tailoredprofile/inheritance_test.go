@@ -0,0 +1,176 @@
+package tailoredprofile
+
+import (
+	compliancev1alpha1 "github.com/JAORMX/compliance-profile-operator/pkg/apis/compliance/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func newExtendingTailoredProfile(name, extends string) *compliancev1alpha1.TailoredProfile {
+	return &compliancev1alpha1.TailoredProfile{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test"},
+		Spec: compliancev1alpha1.TailoredProfileSpec{
+			Extends:     extends,
+			ExtendsKind: compliancev1alpha1.ExtendsTailoredProfile,
+		},
+	}
+}
+
+func ruleRef(name, rationale string) compliancev1alpha1.RuleReferenceSpec {
+	return compliancev1alpha1.RuleReferenceSpec{Name: name, Rationale: rationale}
+}
+
+func valueRef(name, value string) compliancev1alpha1.VariableValueSpec {
+	return compliancev1alpha1.VariableValueSpec{Name: name, Value: value, Rationale: "set to " + value}
+}
+
+var _ = Describe("Testing TailoredProfile inheritance", func() {
+	var root, middle, leaf *compliancev1alpha1.TailoredProfile
+
+	BeforeEach(func() {
+		root = newExtendingTailoredProfile("root", "")
+		root.Spec.Extends = "ocp4-moderate"
+		root.Spec.ExtendsKind = compliancev1alpha1.ExtendsProfile
+		root.Spec.Title = "Root"
+		root.Spec.Description = "The root"
+		root.Spec.EnableRules = []compliancev1alpha1.RuleReferenceSpec{ruleRef("audit", "root"), ruleRef("chrony", "root")}
+		root.Spec.DisableRules = []compliancev1alpha1.RuleReferenceSpec{ruleRef("sshd", "root")}
+		root.Spec.SetValues = []compliancev1alpha1.VariableValueSpec{valueRef("var_timeout", "60"), valueRef("var_retries", "3")}
+
+		middle = newExtendingTailoredProfile("middle", "root")
+		middle.Spec.Title = "Middle"
+		middle.Spec.EnableRules = []compliancev1alpha1.RuleReferenceSpec{ruleRef("audit", "middle")}
+		middle.Spec.DisableRules = []compliancev1alpha1.RuleReferenceSpec{ruleRef("chrony", "middle")}
+		middle.Spec.SetValues = []compliancev1alpha1.VariableValueSpec{valueRef("var_timeout", "120")}
+
+		leaf = newExtendingTailoredProfile("leaf", "middle")
+		leaf.Spec.SetValues = []compliancev1alpha1.VariableValueSpec{valueRef("var_timeout", "300")}
+	})
+
+	Context("flattening the chain", func() {
+		It("leaves a TailoredProfile without parents as is", func() {
+			Expect(flattenTailoredProfile(root, nil)).To(BeIdenticalTo(root))
+		})
+
+		It("applies the changes from the root downwards", func() {
+			flat := flattenTailoredProfile(leaf, []*compliancev1alpha1.TailoredProfile{root, middle})
+			Expect(flat.Name).To(Equal("leaf"))
+			Expect(flat.Spec.EnableRules).To(Equal([]compliancev1alpha1.RuleReferenceSpec{ruleRef("audit", "middle")}))
+			Expect(flat.Spec.DisableRules).To(Equal([]compliancev1alpha1.RuleReferenceSpec{ruleRef("sshd", "root"), ruleRef("chrony", "middle")}))
+			Expect(flat.Spec.SetValues).To(Equal([]compliancev1alpha1.VariableValueSpec{valueRef("var_retries", "3"), valueRef("var_timeout", "300")}))
+			Expect(flat.Spec.Title).To(Equal("Middle"))
+			Expect(flat.Spec.Description).To(Equal("The root"))
+		})
+
+		It("lets a child enable a rule its parent disables", func() {
+			leaf.Spec.EnableRules = []compliancev1alpha1.RuleReferenceSpec{ruleRef("chrony", "leaf"), ruleRef("sshd", "leaf")}
+			flat := flattenTailoredProfile(leaf, []*compliancev1alpha1.TailoredProfile{root, middle})
+			Expect(flat.Spec.EnableRules).To(Equal([]compliancev1alpha1.RuleReferenceSpec{
+				ruleRef("audit", "middle"), ruleRef("chrony", "leaf"), ruleRef("sshd", "leaf"),
+			}))
+			Expect(flat.Spec.DisableRules).To(BeEmpty())
+		})
+
+		It("keeps the texts of the TailoredProfile", func() {
+			leaf.Spec.Title = "Leaf"
+			Expect(flattenTailoredProfile(leaf, []*compliancev1alpha1.TailoredProfile{root, middle}).Spec.Title).To(Equal("Leaf"))
+		})
+
+		It("doesn't modify the chain", func() {
+			flattenTailoredProfile(leaf, []*compliancev1alpha1.TailoredProfile{root, middle})
+			Expect(leaf.Spec.EnableRules).To(BeEmpty())
+			Expect(middle.Spec.EnableRules).To(HaveLen(1))
+		})
+	})
+
+	Context("resolving the chain", func() {
+		It("returns the parents from the root downwards", func() {
+			profile := &compliancev1alpha1.Profile{ObjectMeta: metav1.ObjectMeta{Name: "ocp4-moderate", Namespace: "test"}}
+			r := newTestReconciler(profile, root, middle)
+			p, parents, _, err := r.resolveExtendsChain(leaf)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(p.Name).To(Equal("ocp4-moderate"))
+			Expect(parents).To(HaveLen(2))
+			Expect(parents[0].Name).To(Equal("root"))
+			Expect(parents[1].Name).To(Equal("middle"))
+		})
+
+		It("reports a missing parent", func() {
+			r := newTestReconciler(middle)
+			_, _, retriable, err := r.resolveExtendsChain(leaf)
+			Expect(err).To(MatchError("TailoredProfile 'root' extended by 'middle' not found"))
+			Expect(retriable).To(BeFalse())
+		})
+
+		It("reports cycles along with their path", func() {
+			root = newExtendingTailoredProfile("root", "leaf")
+			r := newTestReconciler(root, middle, leaf)
+			_, _, retriable, err := r.resolveExtendsChain(leaf)
+			Expect(err).To(MatchError("TailoredProfile inheritance cycle: leaf -> middle -> root -> leaf"))
+			Expect(retriable).To(BeFalse())
+		})
+
+		It("reports cycles through other namespaces", func() {
+			grant := &compliancev1alpha1.ReferenceGrant{
+				ObjectMeta: metav1.ObjectMeta{Name: "grant", Namespace: "shared"},
+				Spec: compliancev1alpha1.ReferenceGrantSpec{
+					From: []compliancev1alpha1.ReferenceGrantFrom{{Namespace: "test"}},
+					To:   []compliancev1alpha1.ReferenceGrantTo{{Kind: compliancev1alpha1.ReferenceKindTailoredProfile}},
+				},
+			}
+			backGrant := grant.DeepCopy()
+			backGrant.Namespace = "test"
+			backGrant.Spec.From[0].Namespace = "shared"
+			middle.Namespace = "shared"
+			middle.Spec.ContentNamespace = "test"
+			leaf.Spec.ContentNamespace = "shared"
+			middle.Spec.Extends = "leaf"
+			r := newTestReconciler(grant, backGrant, middle, leaf)
+			_, _, _, err := r.resolveExtendsChain(leaf)
+			Expect(err).To(MatchError("TailoredProfile inheritance cycle: leaf -> shared/middle -> leaf"))
+		})
+	})
+
+	Context("checking reference grants", func() {
+		BeforeEach(func() {
+			leaf.Namespace = "my-app"
+			leaf.Spec.ContentNamespace = "test"
+		})
+
+		It("allows references within the namespace", func() {
+			r := newTestReconciler()
+			retriable, err := r.checkReferenceGrant(middle)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(retriable).To(BeFalse())
+		})
+
+		It("allows granted references to another namespace", func() {
+			grant := &compliancev1alpha1.ReferenceGrant{
+				ObjectMeta: metav1.ObjectMeta{Name: "grant", Namespace: "test"},
+				Spec: compliancev1alpha1.ReferenceGrantSpec{
+					From: []compliancev1alpha1.ReferenceGrantFrom{{Namespace: "my-app"}},
+					To:   []compliancev1alpha1.ReferenceGrantTo{{Kind: compliancev1alpha1.ReferenceKindTailoredProfile, Name: "middle"}},
+				},
+			}
+			r := newTestReconciler(grant)
+			_, err := r.checkReferenceGrant(leaf)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("denies references to another namespace without a grant", func() {
+			grant := &compliancev1alpha1.ReferenceGrant{
+				ObjectMeta: metav1.ObjectMeta{Name: "grant", Namespace: "test"},
+				Spec: compliancev1alpha1.ReferenceGrantSpec{
+					From: []compliancev1alpha1.ReferenceGrantFrom{{Namespace: "my-app"}},
+					To:   []compliancev1alpha1.ReferenceGrantTo{{Kind: compliancev1alpha1.ReferenceKindTailoredProfile, Name: "root"}},
+				},
+			}
+			r := newTestReconciler(grant)
+			retriable, err := r.checkReferenceGrant(leaf)
+			Expect(err).To(MatchError("No ReferenceGrant in namespace 'test' allows TailoredProfile 'leaf' of namespace 'my-app' to reference TailoredProfile 'middle'"))
+			Expect(retriable).To(BeFalse())
+		})
+	})
+})
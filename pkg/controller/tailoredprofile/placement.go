@@ -0,0 +1,188 @@
+package tailoredprofile
+
+import (
+	"context"
+
+	compliancev1alpha1 "github.com/JAORMX/compliance-profile-operator/pkg/apis/compliance/v1alpha1"
+	"github.com/JAORMX/compliance-profile-operator/pkg/policy"
+	"github.com/JAORMX/compliance-profile-operator/pkg/utils"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// getPlacementRuleGVK gets the kind of the PlacementRules placing the Policies
+// of the given API version
+func getPlacementRuleGVK(apiVersion string) schema.GroupVersionKind {
+	return schema.FromAPIVersionAndKind(policy.GetPlacementRuleAPIVersion(apiVersion), "PlacementRule")
+}
+
+// getPlacementBindingGVK gets the kind of the PlacementBindings, which belong
+// to the policy API
+func getPlacementBindingGVK(apiVersion string) schema.GroupVersionKind {
+	return schema.FromAPIVersionAndKind(apiVersion, "PlacementBinding")
+}
+
+func getPlacementRuleName(tp *compliancev1alpha1.TailoredProfile) string {
+	return "placement-" + tp.Name
+}
+
+func getPlacementBindingName(tp *compliancev1alpha1.TailoredProfile) string {
+	return "binding-" + tp.Name
+}
+
+// newPlacementRule creates a PlacementRule selecting the managed clusters that
+// match the given selector, for the Policies of the given API version
+func newPlacementRule(tp *compliancev1alpha1.TailoredProfile, apiVersion string, selector *metav1.LabelSelector) (*unstructured.Unstructured, error) {
+	gvk := getPlacementRuleGVK(apiVersion)
+	return utils.ToUnstructured(&policy.PlacementRule{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: gvk.GroupVersion().String(),
+			Kind:       gvk.Kind,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      getPlacementRuleName(tp),
+			Namespace: tp.Namespace,
+		},
+		Spec: policy.PlacementRuleSpec{
+			ClusterSelector: selector,
+		},
+	})
+}
+
+// newPlacementBinding creates a PlacementBinding that places the Policy on the
+// clusters selected by the PlacementRule
+func newPlacementBinding(tp *compliancev1alpha1.TailoredProfile, policyObj, rule *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	return utils.ToUnstructured(&policy.PlacementBinding{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: policyObj.GetAPIVersion(),
+			Kind:       "PlacementBinding",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      getPlacementBindingName(tp),
+			Namespace: tp.Namespace,
+		},
+		PlacementRef: policy.Subject{
+			Name:     rule.GetName(),
+			Kind:     rule.GetKind(),
+			APIGroup: rule.GroupVersionKind().Group,
+		},
+		Subjects: []policy.Subject{
+			{
+				Name:     policyObj.GetName(),
+				Kind:     policyObj.GetKind(),
+				APIGroup: policyObj.GroupVersionKind().Group,
+			},
+		},
+	})
+}
+
+// getPlacedClusters gets the number of clusters the PlacementRule decided to
+// place the Policy on
+func getPlacedClusters(rule *unstructured.Unstructured) (int32, error) {
+	typedRule := &policy.PlacementRule{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(rule.Object, typedRule); err != nil {
+		return 0, err
+	}
+	return int32(len(typedRule.Status.Decisions)), nil
+}
+
+// ensurePlacement generates the PlacementRule and PlacementBinding placing the
+// Policy on the clusters matching the TailoredProfile's cluster selector, and
+// keeps them in sync. They're removed if the selector is unset. It returns the
+// number of clusters the Policy is placed on.
+func (r *ReconcileTailoredProfile) ensurePlacement(tp *compliancev1alpha1.TailoredProfile, selector *metav1.LabelSelector, policyObj *unstructured.Unstructured, logger logr.Logger) (int32, error) {
+	if selector == nil {
+		return 0, r.removePlacement(tp, policyObj.GetAPIVersion(), logger)
+	}
+
+	rule, err := newPlacementRule(tp, policyObj.GetAPIVersion(), selector)
+	if err != nil {
+		return 0, err
+	}
+	foundRule, err := r.ensurePlacementObject(tp, rule, []string{"spec"}, logger)
+	if err != nil {
+		return 0, err
+	}
+
+	binding, err := newPlacementBinding(tp, policyObj, rule)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := r.ensurePlacementObject(tp, binding, []string{"placementRef", "subjects"}, logger); err != nil {
+		return 0, err
+	}
+	return getPlacedClusters(foundRule)
+}
+
+// ensurePlacementObject creates the object, or updates the given fields of the
+// existing one if they differ. It returns the object as found in the cluster.
+func (r *ReconcileTailoredProfile) ensurePlacementObject(tp *compliancev1alpha1.TailoredProfile, obj *unstructured.Unstructured, fields []string, logger logr.Logger) (*unstructured.Unstructured, error) {
+	if err := controllerutil.SetControllerReference(tp, obj, r.scheme); err != nil {
+		return nil, err
+	}
+
+	found := &unstructured.Unstructured{}
+	found.SetGroupVersionKind(obj.GroupVersionKind())
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: obj.GetName(), Namespace: obj.GetNamespace()}, found)
+	if err != nil && errors.IsNotFound(err) {
+		logger.Info("Creating a new "+obj.GetKind(), "Object.Namespace", obj.GetNamespace(), "Object.Name", obj.GetName())
+		return obj, r.client.Create(context.TODO(), obj)
+	} else if err != nil {
+		return nil, err
+	}
+
+	foundCopy := found.DeepCopy()
+	changed := false
+	for _, field := range fields {
+		if !isSameJSON(found.Object[field], obj.Object[field]) {
+			foundCopy.Object[field] = obj.Object[field]
+			changed = true
+		}
+	}
+	if !changed {
+		return found, nil
+	}
+	logger.Info("Updating the "+obj.GetKind(), "Object.Namespace", found.GetNamespace(), "Object.Name", found.GetName())
+	return foundCopy, r.client.Update(context.TODO(), foundCopy)
+}
+
+// removePlacement removes the PlacementRule and PlacementBinding generated for
+// the TailoredProfile, if any. Both belong to the APIs matching the given
+// policy API version.
+func (r *ReconcileTailoredProfile) removePlacement(tp *compliancev1alpha1.TailoredProfile, apiVersion string, logger logr.Logger) error {
+	// The Policy is unbound before the rule it's bound through is removed
+	objs := []struct {
+		name string
+		gvk  schema.GroupVersionKind
+	}{
+		{getPlacementBindingName(tp), getPlacementBindingGVK(apiVersion)},
+		{getPlacementRuleName(tp), getPlacementRuleGVK(apiVersion)},
+	}
+	for _, obj := range objs {
+		found := &unstructured.Unstructured{}
+		found.SetGroupVersionKind(obj.gvk)
+		err := r.client.Get(context.TODO(), types.NamespacedName{Name: obj.name, Namespace: tp.Namespace}, found)
+		if errors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			// Nothing to remove, or RHACM isn't even installed
+			continue
+		} else if err != nil {
+			return err
+		}
+		if !metav1.IsControlledBy(found, tp) {
+			continue
+		}
+
+		logger.Info("Removing the "+obj.gvk.Kind, "Object.Namespace", found.GetNamespace(), "Object.Name", found.GetName())
+		if err := r.client.Delete(context.TODO(), found); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package tailoredprofile
+
+import (
+	"context"
+
+	compliancev1alpha1 "github.com/JAORMX/compliance-profile-operator/pkg/apis/compliance/v1alpha1"
+	"github.com/JAORMX/compliance-profile-operator/pkg/policy"
+	"github.com/JAORMX/compliance-profile-operator/pkg/utils"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func newTestPolicy(tp *compliancev1alpha1.TailoredProfile, apiVersion string) *unstructured.Unstructured {
+	policyObj := &unstructured.Unstructured{}
+	policyObj.SetAPIVersion(apiVersion)
+	policyObj.SetKind("Policy")
+	policyObj.SetName(tp.Name)
+	policyObj.SetNamespace(tp.Namespace)
+	policyObj.SetAnnotations(map[string]string{compliancev1alpha1.GeneratedByAnnotation: getGeneratedBy(tp)})
+	return policyObj
+}
+
+func nestedString(obj *unstructured.Unstructured, fields ...string) string {
+	value, _, err := unstructured.NestedString(obj.Object, fields...)
+	Expect(err).ToNot(HaveOccurred())
+	return value
+}
+
+var _ = Describe("Testing the placement of Policies", func() {
+	var tp *compliancev1alpha1.TailoredProfile
+	var r *ReconcileTailoredProfile
+	var policyObj *unstructured.Unstructured
+	var selector *metav1.LabelSelector
+
+	get := func(gvk schema.GroupVersionKind, name string) (*unstructured.Unstructured, error) {
+		found := &unstructured.Unstructured{}
+		found.SetGroupVersionKind(gvk)
+		return found, r.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: "test"}, found)
+	}
+
+	BeforeEach(func() {
+		tp = newTestTailoredProfile("tp")
+		r = newTestReconciler(tp)
+		policyObj = newTestPolicy(tp, policy.APIVersionV1)
+		selector = &metav1.LabelSelector{MatchLabels: map[string]string{"environment": "production"}}
+	})
+
+	It("counts the clusters the PlacementRule placed the Policy on", func() {
+		rule, err := utils.ToUnstructured(&policy.PlacementRule{
+			Status: policy.PlacementRuleStatus{
+				Decisions: []policy.PlacementDecision{{ClusterName: "a"}, {ClusterName: "b"}},
+			},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(getPlacedClusters(rule)).To(Equal(int32(2)))
+		Expect(getPlacedClusters(&unstructured.Unstructured{Object: map[string]interface{}{}})).To(Equal(int32(0)))
+	})
+
+	It("generates a PlacementRule and a PlacementBinding", func() {
+		placed, err := r.ensurePlacement(tp, selector, policyObj, logf.Log)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(placed).To(Equal(int32(0)))
+
+		rule, err := get(getPlacementRuleGVK(policy.APIVersionV1), "placement-tp")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(metav1.IsControlledBy(rule, tp)).To(BeTrue())
+		Expect(nestedString(rule, "spec", "clusterSelector", "matchLabels", "environment")).To(Equal("production"))
+
+		binding, err := get(getPlacementBindingGVK(policy.APIVersionV1), "binding-tp")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(metav1.IsControlledBy(binding, tp)).To(BeTrue())
+		Expect(nestedString(binding, "placementRef", "name")).To(Equal("placement-tp"))
+		subjects, _, _ := unstructured.NestedSlice(binding.Object, "subjects")
+		Expect(subjects).To(ConsistOf(map[string]interface{}{
+			"name":     "tp",
+			"kind":     "Policy",
+			"apiGroup": "policy.open-cluster-management.io",
+		}))
+	})
+
+	It("places Policies of the policy.mcm.ibm.com API with its own kinds", func() {
+		policyObj = newTestPolicy(tp, policy.APIVersionMCMV1alpha1)
+		_, err := r.ensurePlacement(tp, selector, policyObj, logf.Log)
+		Expect(err).ToNot(HaveOccurred())
+
+		rule, err := get(getPlacementRuleGVK(policy.APIVersionMCMV1alpha1), "placement-tp")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rule.GetAPIVersion()).To(Equal("policy.mcm.ibm.com/v1alpha1"))
+		binding, err := get(getPlacementBindingGVK(policy.APIVersionMCMV1alpha1), "binding-tp")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(nestedString(binding, "placementRef", "apiGroup")).To(Equal("policy.mcm.ibm.com"))
+
+		Expect(r.removePlacement(tp, policy.APIVersionMCMV1alpha1, logf.Log)).To(Succeed())
+		_, err = get(getPlacementRuleGVK(policy.APIVersionMCMV1alpha1), "placement-tp")
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("updates the PlacementRule and reports its decisions", func() {
+		_, err := r.ensurePlacement(tp, selector, policyObj, logf.Log)
+		Expect(err).ToNot(HaveOccurred())
+		rule, err := get(getPlacementRuleGVK(policy.APIVersionV1), "placement-tp")
+		Expect(err).ToNot(HaveOccurred())
+		decisions := []interface{}{map[string]interface{}{"clusterName": "a"}}
+		Expect(unstructured.SetNestedSlice(rule.Object, decisions, "status", "decisions")).To(Succeed())
+		Expect(r.client.Update(context.TODO(), rule)).To(Succeed())
+
+		selector.MatchLabels["environment"] = "staging"
+		placed, err := r.ensurePlacement(tp, selector, policyObj, logf.Log)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(placed).To(Equal(int32(1)))
+		rule, err = get(getPlacementRuleGVK(policy.APIVersionV1), "placement-tp")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(nestedString(rule, "spec", "clusterSelector", "matchLabels", "environment")).To(Equal("staging"))
+	})
+
+	It("removes the placement once the selector is unset", func() {
+		_, err := r.ensurePlacement(tp, selector, policyObj, logf.Log)
+		Expect(err).ToNot(HaveOccurred())
+
+		placed, err := r.ensurePlacement(tp, nil, policyObj, logf.Log)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(placed).To(Equal(int32(0)))
+		_, err = get(getPlacementRuleGVK(policy.APIVersionV1), "placement-tp")
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+		_, err = get(getPlacementBindingGVK(policy.APIVersionV1), "binding-tp")
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("keeps placements it didn't generate", func() {
+		rule, err := newPlacementRule(tp, policy.APIVersionV1, selector)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(r.client.Create(context.TODO(), rule)).To(Succeed())
+
+		_, err = r.ensurePlacement(tp, nil, policyObj, logf.Log)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = get(getPlacementRuleGVK(policy.APIVersionV1), "placement-tp")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	Context("switching to another output type", func() {
+		BeforeEach(func() {
+			tp.Spec.OutputType = compliancev1alpha1.PolicyOutput
+			tp.SetFinalizers([]string{compliancev1alpha1.TailoredProfileFinalizer})
+			Expect(r.client.Create(context.TODO(), policyObj)).To(Succeed())
+			_, err := r.ensurePlacement(tp, selector, policyObj, logf.Log)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("keeps the Policy while the output type is Policy", func() {
+			updated, err := r.removeStalePolicy(tp, logf.Log)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(updated).To(BeFalse())
+			_, err = get(policyObj.GroupVersionKind(), "tp")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("removes the Policy and its placement", func() {
+			tp.Spec.OutputType = compliancev1alpha1.ConfigMapOutput
+			updated, err := r.removeStalePolicy(tp, logf.Log)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(updated).To(BeTrue())
+
+			_, err = get(policyObj.GroupVersionKind(), "tp")
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+			_, err = get(getPlacementRuleGVK(policy.APIVersionV1), "placement-tp")
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+			_, err = get(getPlacementBindingGVK(policy.APIVersionV1), "binding-tp")
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+
+			found := &compliancev1alpha1.TailoredProfile{}
+			Expect(r.client.Get(context.TODO(), types.NamespacedName{Name: "tp", Namespace: "test"}, found)).To(Succeed())
+			Expect(hasFinalizer(found)).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,99 @@
+package tailoredprofile
+
+import (
+	"context"
+
+	compliancev1alpha1 "github.com/JAORMX/compliance-profile-operator/pkg/apis/compliance/v1alpha1"
+	"github.com/JAORMX/compliance-profile-operator/pkg/policy"
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// getSelectedRules gets the rules the tailoring evaluates: the rules of the
+// extended profile plus the enabled ones, minus the disabled ones. A
+// TailoredProfile built from scratch only evaluates the rules it enables.
+func (r *ReconcileTailoredProfile) getSelectedRules(p *compliancev1alpha1.Profile, pb *compliancev1alpha1.ProfileBundle, result *tailoringResult) ([]*compliancev1alpha1.Rule, error) {
+	selected := map[string]bool{}
+	if p != nil {
+		for _, rule := range p.Rules {
+			selected[string(rule)] = true
+		}
+	}
+	for _, name := range result.resolvedEnableRules {
+		selected[name] = true
+	}
+	for _, name := range result.resolvedDisableRules {
+		delete(selected, name)
+	}
+
+	ruleList := &compliancev1alpha1.RuleList{}
+	if err := r.client.List(context.TODO(), ruleList, client.InNamespace(pb.Namespace)); err != nil {
+		return nil, err
+	}
+	rules := []*compliancev1alpha1.Rule{}
+	for i := range ruleList.Items {
+		rule := &ruleList.Items[i]
+		if selected[rule.Name] && metav1.IsControlledBy(rule, pb) {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+// getPolicyAnnotationKeys gets the keys of the annotations the operator
+// manages on Policies of the given API version
+func getPolicyAnnotationKeys(apiVersion string) []string {
+	return append(policy.GetAnnotationKeys(apiVersion), compliancev1alpha1.GeneratedByAnnotation)
+}
+
+// hasAnnotations tells whether the annotations the operator manages on the
+// Policy match the given ones
+func hasAnnotations(found, annotations map[string]string, apiVersion string) bool {
+	for _, key := range getPolicyAnnotationKeys(apiVersion) {
+		if found[key] != annotations[key] {
+			return false
+		}
+	}
+	return true
+}
+
+// withAnnotations returns a copy of the Policy's annotations with the ones the
+// operator manages replaced by the given ones.
+// Other annotations, e.g. set by the hub, are kept.
+func withAnnotations(found, annotations map[string]string, apiVersion string) map[string]string {
+	merged := map[string]string{}
+	for key, value := range found {
+		merged[key] = value
+	}
+	for _, key := range getPolicyAnnotationKeys(apiVersion) {
+		delete(merged, key)
+	}
+	for key, value := range annotations {
+		merged[key] = value
+	}
+	return merged
+}
+
+// removeStalePolicy removes the Policy generated for the TailoredProfile, along
+// with the PlacementRule and PlacementBinding placing it, once its output type
+// isn't Policy anymore. The finalizer is then dropped, as nothing is left for
+// it to remove. Policies are only generated for TailoredProfiles with the
+// finalizer, so the others have nothing to remove. It tells whether the
+// TailoredProfile was updated.
+func (r *ReconcileTailoredProfile) removeStalePolicy(tp *compliancev1alpha1.TailoredProfile, logger logr.Logger) (bool, error) {
+	if tp.Spec.OutputType == compliancev1alpha1.PolicyOutput || !hasFinalizer(tp) {
+		return false, nil
+	}
+	// The PlacementBinding belongs to the API the Policy was generated for,
+	// which may not be the configured one anymore
+	for _, apiVersion := range []string{policy.APIVersionV1, policy.APIVersionMCMV1alpha1} {
+		if err := r.removePlacement(tp, apiVersion, logger); err != nil {
+			return false, err
+		}
+	}
+	if err := r.removeUnownedOutputs(tp, logger); err != nil {
+		return false, err
+	}
+	return true, r.removeFinalizer(tp)
+}
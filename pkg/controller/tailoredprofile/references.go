@@ -0,0 +1,90 @@
+package tailoredprofile
+
+import (
+	"context"
+
+	compliancev1alpha1 "github.com/JAORMX/compliance-profile-operator/pkg/apis/compliance/v1alpha1"
+	"github.com/JAORMX/compliance-profile-operator/pkg/controller/common"
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+// resolveChainReferences resolves the references to rules and variables by
+// XCCDF ID or short name of the TailoredProfile and the ones it extends.
+// This happens before the chain is flattened, so the layers override each
+// other whatever the way they reference the rules and variables is. The
+// boolean tells whether the error is retriable.
+func (r *ReconcileTailoredProfile) resolveChainReferences(tp *compliancev1alpha1.TailoredProfile, parents []*compliancev1alpha1.TailoredProfile, pb *compliancev1alpha1.ProfileBundle) (*compliancev1alpha1.TailoredProfile, []*compliancev1alpha1.TailoredProfile, bool, error) {
+	resolved, retriable, err := r.withResolvedReferences(tp, pb)
+	if err != nil {
+		return nil, nil, retriable, err
+	}
+
+	resolvedParents := make([]*compliancev1alpha1.TailoredProfile, 0, len(parents))
+	for _, parent := range parents {
+		resolvedParent, retriable, err := r.withResolvedReferences(parent, pb)
+		if err != nil {
+			return nil, nil, retriable, err
+		}
+		resolvedParents = append(resolvedParents, resolvedParent)
+	}
+	return resolved, resolvedParents, false, nil
+}
+
+// withResolvedReferences returns a copy of the TailoredProfile in which the
+// rules and variables are all referenced by the name of their objects. The
+// boolean tells whether the error is retriable.
+func (r *ReconcileTailoredProfile) withResolvedReferences(tp *compliancev1alpha1.TailoredProfile, pb *compliancev1alpha1.ProfileBundle) (*compliancev1alpha1.TailoredProfile, bool, error) {
+	// Never update the original (update the copy)
+	resolved := tp.DeepCopy()
+
+	resolveRule := func(ref *string) error {
+		if *ref == "" {
+			// Selector
+			return nil
+		}
+		name, err := common.ResolveRuleName(context.TODO(), r.client, pb.Namespace, *ref, pb)
+		*ref = name
+		return err
+	}
+	resolveVariable := func(ref *string) error {
+		name, err := common.ResolveVariableName(context.TODO(), r.client, pb.Namespace, *ref, pb)
+		*ref = name
+		return err
+	}
+
+	refs := []*string{}
+	for i := range resolved.Spec.EnableRules {
+		refs = append(refs, &resolved.Spec.EnableRules[i].Name)
+	}
+	for i := range resolved.Spec.DisableRules {
+		refs = append(refs, &resolved.Spec.DisableRules[i].Name)
+	}
+	for i := range resolved.Spec.RefineRules {
+		refs = append(refs, &resolved.Spec.RefineRules[i].Name)
+	}
+	for _, ref := range refs {
+		if err := resolveRule(ref); err != nil {
+			return nil, isRetriableReferenceError(err), err
+		}
+	}
+
+	refs = []*string{}
+	for i := range resolved.Spec.SetValues {
+		refs = append(refs, &resolved.Spec.SetValues[i].Name)
+	}
+	for i := range resolved.Spec.RefineValues {
+		refs = append(refs, &resolved.Spec.RefineValues[i].Name)
+	}
+	for _, ref := range refs {
+		if err := resolveVariable(ref); err != nil {
+			return nil, isRetriableReferenceError(err), err
+		}
+	}
+	return resolved, false, nil
+}
+
+// isRetriableReferenceError tells whether an error resolving a reference is
+// worth retrying. Missing and ambiguous references need the user to act.
+func isRetriableReferenceError(err error) bool {
+	return !errors.IsNotFound(err) && !common.IsAmbiguousReference(err)
+}
@@ -0,0 +1,64 @@
+package tailoredprofile
+
+import (
+	"context"
+	"fmt"
+
+	compliancev1alpha1 "github.com/JAORMX/compliance-profile-operator/pkg/apis/compliance/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// getRefinedRules gets the rules refined by the TailoredProfile, keyed by name,
+// and validates the refinements against them. The boolean tells whether the error is
+// retriable.
+func (r *ReconcileTailoredProfile) getRefinedRules(tp *compliancev1alpha1.TailoredProfile, pb *compliancev1alpha1.ProfileBundle) (map[string]*compliancev1alpha1.Rule, bool, error) {
+	rules := make(map[string]*compliancev1alpha1.Rule)
+	for _, refinement := range tp.Spec.RefineRules {
+		if _, ok := rules[refinement.Name]; ok {
+			return nil, false, &invalidSpecError{fmt.Errorf("Rule '%s' appears twice in refineRules", refinement.Name)}
+		}
+		rule := &compliancev1alpha1.Rule{}
+		ruleKey := types.NamespacedName{Name: refinement.Name, Namespace: pb.Namespace}
+		err := r.client.Get(context.TODO(), ruleKey, rule)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				return nil, false, err
+			}
+			return nil, true, err
+		}
+		// The selector needs to pick one of the rule's variants, otherwise
+		// the mistake would only show up when scanning
+		if err := refinement.ValidateFor(rule); err != nil {
+			return nil, false, &invalidSpecError{fmt.Errorf("Invalid refinement for rule '%s': %s", refinement.Name, err)}
+		}
+		rules[refinement.Name] = rule
+	}
+	return rules, false, nil
+}
+
+// getRefinedVariables gets the variables refined by the TailoredProfile, keyed
+// by name, and validates the refinements against them. The boolean tells
+// whether the error is retriable.
+func (r *ReconcileTailoredProfile) getRefinedVariables(tp *compliancev1alpha1.TailoredProfile, pb *compliancev1alpha1.ProfileBundle) (map[string]*compliancev1alpha1.Variable, bool, error) {
+	variables := make(map[string]*compliancev1alpha1.Variable)
+	for _, refinement := range tp.Spec.RefineValues {
+		if _, ok := variables[refinement.Name]; ok {
+			return nil, false, &invalidSpecError{fmt.Errorf("Variable '%s' appears twice in refineValues", refinement.Name)}
+		}
+		variable := &compliancev1alpha1.Variable{}
+		varKey := types.NamespacedName{Name: refinement.Name, Namespace: pb.Namespace}
+		err := r.client.Get(context.TODO(), varKey, variable)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				return nil, false, err
+			}
+			return nil, true, err
+		}
+		if err := refinement.ValidateFor(variable); err != nil {
+			return nil, false, &invalidSpecError{fmt.Errorf("Invalid refinement for variable '%s': %s", refinement.Name, err)}
+		}
+		variables[refinement.Name] = variable
+	}
+	return variables, false, nil
+}
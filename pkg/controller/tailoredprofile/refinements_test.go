@@ -0,0 +1,54 @@
+package tailoredprofile
+
+import (
+	compliancev1alpha1 "github.com/JAORMX/compliance-profile-operator/pkg/apis/compliance/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Testing rule refinements", func() {
+	var r *ReconcileTailoredProfile
+	var tp *compliancev1alpha1.TailoredProfile
+	pb := newTestBundle("ocp4")
+
+	BeforeEach(func() {
+		r = newTestReconciler(&compliancev1alpha1.Rule{
+			ObjectMeta: metav1.ObjectMeta{Name: "ocp4-sshd-set-idle-timeout", Namespace: pb.Namespace},
+			Selectors:  []string{"lenient", "strict"},
+		})
+		tp = &compliancev1alpha1.TailoredProfile{}
+	})
+
+	refine := func(selector string) {
+		tp.Spec.RefineRules = []compliancev1alpha1.RuleRefinementSpec{{Name: "ocp4-sshd-set-idle-timeout", Selector: selector}}
+	}
+
+	It("accepts the selectors of the rule", func() {
+		refine("strict")
+		rules, _, err := r.getRefinedRules(tp, pb)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rules).To(HaveKey("ocp4-sshd-set-idle-timeout"))
+	})
+
+	It("reports unknown selectors as an invalid spec", func() {
+		refine("stirct")
+		_, retriable, err := r.getRefinedRules(tp, pb)
+		Expect(err).To(MatchError("Invalid refinement for rule 'ocp4-sshd-set-idle-timeout': " +
+			"selector stirct isn't defined for the rule (available selectors: lenient, strict)"))
+		Expect(retriable).To(BeFalse())
+		condType, reason := getResolutionErrorCondition(err)
+		Expect(condType).To(Equal(compliancev1alpha1.TailoredProfileConditionValidated))
+		Expect(reason).To(Equal(compliancev1alpha1.ReasonInvalidSpec))
+	})
+
+	It("reports missing rules as a resolution failure", func() {
+		tp.Spec.RefineRules = []compliancev1alpha1.RuleRefinementSpec{{Name: "ocp4-missing", Severity: "high"}}
+		_, _, err := r.getRefinedRules(tp, pb)
+		Expect(err).To(HaveOccurred())
+		condType, reason := getResolutionErrorCondition(err)
+		Expect(condType).To(Equal(compliancev1alpha1.TailoredProfileConditionProfileResolved))
+		Expect(reason).To(Equal(compliancev1alpha1.ReasonResolutionFailed))
+	})
+})
@@ -0,0 +1,232 @@
+package tailoredprofile
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	compliancev1alpha1 "github.com/JAORMX/compliance-profile-operator/pkg/apis/compliance/v1alpha1"
+	"github.com/JAORMX/compliance-profile-operator/pkg/utils"
+	"github.com/JAORMX/compliance-profile-operator/pkg/xccdf"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// revisionSpecFile holds the spec that produced a revision
+	revisionSpecFile string = "spec.json"
+)
+
+// tailoringVersion identifies a version of a tailoring by its content
+type tailoringVersion struct {
+	version     int64
+	time        *metav1.Time
+	contentHash string
+}
+
+// getRevisionName gets the name of the ConfigMap holding the given revision
+// of the TailoredProfile's tailoring
+func getRevisionName(tp *compliancev1alpha1.TailoredProfile, revision int64) string {
+	return fmt.Sprintf("%s-rev-%d", tp.Name, revision)
+}
+
+// getRevisionNumber gets the revision number a revision ConfigMap is labeled
+// with. Zero means that the label is missing or invalid.
+func getRevisionNumber(cm *corev1.ConfigMap) int64 {
+	revision, err := strconv.ParseInt(cm.Labels[compliancev1alpha1.TailoredProfileRevisionNumberLabel], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return revision
+}
+
+// getRevisions gets the recorded revisions of the TailoredProfile's tailoring,
+// sorted from the oldest to the newest. Revisions stored as Secrets are
+// returned as ConfigMaps holding the same data.
+func (r *ReconcileTailoredProfile) getRevisions(tp *compliancev1alpha1.TailoredProfile) ([]*corev1.ConfigMap, error) {
+	listOpts := []client.ListOption{
+		client.InNamespace(tp.Namespace),
+		client.MatchingLabels{compliancev1alpha1.TailoredProfileLabel: tp.Name},
+	}
+	found := []*corev1.ConfigMap{}
+	if usesSecrets(tp) {
+		secretList := &corev1.SecretList{}
+		if err := r.secretReader.List(context.TODO(), secretList, listOpts...); err != nil {
+			return nil, err
+		}
+		for i := range secretList.Items {
+			found = append(found, newConfigMapFromSecret(&secretList.Items[i]))
+		}
+	} else {
+		cmList := &corev1.ConfigMapList{}
+		if err := r.client.List(context.TODO(), cmList, listOpts...); err != nil {
+			return nil, err
+		}
+		for i := range cmList.Items {
+			found = append(found, &cmList.Items[i])
+		}
+	}
+
+	revisions := []*corev1.ConfigMap{}
+	for _, cm := range found {
+		if !isOwnedBy(cm, tp) || getRevisionNumber(cm) == 0 {
+			continue
+		}
+		revisions = append(revisions, cm)
+	}
+	sort.Slice(revisions, func(i, j int) bool {
+		return getRevisionNumber(revisions[i]) < getRevisionNumber(revisions[j])
+	})
+	return revisions, nil
+}
+
+// findRevision finds the given revision in a list of revisions
+func findRevision(revisions []*corev1.ConfigMap, revision int64) *corev1.ConfigMap {
+	for _, cm := range revisions {
+		if getRevisionNumber(cm) == revision {
+			return cm
+		}
+	}
+	return nil
+}
+
+// getPreviousVersion gets the version the tailoring was last rendered with.
+// That's the one recorded in the status, unless a newer revision was recorded
+// already, e.g. because the status couldn't be updated afterwards.
+func getPreviousVersion(tp *compliancev1alpha1.TailoredProfile, revisions []*corev1.ConfigMap) tailoringVersion {
+	previous := tailoringVersion{
+		version:     tp.Status.Version,
+		time:        tp.Status.VersionTime,
+		contentHash: tp.Status.ContentHash,
+	}
+	if len(revisions) == 0 {
+		return previous
+	}
+
+	latest := revisions[len(revisions)-1]
+	if getRevisionNumber(latest) < previous.version {
+		return previous
+	}
+	previous.version = getRevisionNumber(latest)
+	previous.time = nil
+	if revisionTime, err := time.Parse(time.RFC3339, latest.Annotations[compliancev1alpha1.RevisionTimeAnnotation]); err == nil {
+		t := metav1.NewTime(revisionTime)
+		previous.time = &t
+	}
+	previous.contentHash = xccdf.GetTailoringContentHash(latest.Data[tailoringFile])
+	return previous
+}
+
+// ensureRevision records the rendered tailoring as a revision, unless it's
+// recorded already, and prunes the revisions beyond the history limit. It
+// returns the remaining revisions.
+func (r *ReconcileTailoredProfile) ensureRevision(tp *compliancev1alpha1.TailoredProfile, revisions []*corev1.ConfigMap, tpcm *corev1.ConfigMap, result *tailoringResult, logger logr.Logger) ([]*corev1.ConfigMap, error) {
+	if findRevision(revisions, result.version) == nil {
+		revision, err := newTailoredProfileRevisionCM(tp, revisions, tpcm, result)
+		if err != nil {
+			return nil, err
+		}
+		if err := controllerutil.SetControllerReference(tp, revision, r.scheme); err != nil {
+			return nil, err
+		}
+
+		stored, err := toImmutableObject(toStoredObject(tp, revision))
+		if err != nil {
+			return nil, err
+		}
+		logger.Info("Recording a new tailoring revision", "Revision.Namespace", revision.Namespace, "Revision.Name", revision.Name)
+		err = r.client.Create(context.TODO(), stored)
+		if err != nil && !errors.IsAlreadyExists(err) {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+
+	limit := compliancev1alpha1.DefaultRevisionHistoryLimit
+	if tp.Spec.RevisionHistoryLimit != nil && *tp.Spec.RevisionHistoryLimit > 0 {
+		limit = *tp.Spec.RevisionHistoryLimit
+	}
+
+	kept := []*corev1.ConfigMap{}
+	for i, revision := range revisions {
+		// The newest revisions are kept, as well as the pinned one
+		if len(revisions)-i <= int(limit) || getRevisionNumber(revision) == tp.Spec.PinnedRevision {
+			kept = append(kept, revision)
+			continue
+		}
+
+		logger.Info("Pruning a tailoring revision", "Revision.Namespace", revision.Namespace, "Revision.Name", revision.Name)
+		err := r.client.Delete(context.TODO(), toStoredObject(tp, revision))
+		if err != nil && !errors.IsNotFound(err) {
+			return nil, err
+		}
+	}
+	return kept, nil
+}
+
+// toImmutableObject converts a ConfigMap or Secret to an unstructured object
+// marked as immutable, so a recorded revision can't be modified. The API types
+// we build against predate the immutable field; API servers that don't know it
+// drop it.
+func toImmutableObject(obj runtime.Object) (*unstructured.Unstructured, error) {
+	u, err := utils.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	if err := unstructured.SetNestedField(u.Object, true, "immutable"); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// newTailoredProfileRevisionCM creates a ConfigMap recording a revision of the
+// rendered tailoring and its justification report, along with the spec that
+// produced them. The user that changed the spec is only recorded when the
+// spec differs from the previous revision's; otherwise the revision was caused
+// by a change to the referenced objects.
+func newTailoredProfileRevisionCM(tp *compliancev1alpha1.TailoredProfile, revisions []*corev1.ConfigMap, tpcm *corev1.ConfigMap, result *tailoringResult) (*corev1.ConfigMap, error) {
+	spec, err := json.Marshal(tp.Spec)
+	if err != nil {
+		return nil, err
+	}
+
+	annotations := map[string]string{}
+	if result.versionTime != nil {
+		annotations[compliancev1alpha1.RevisionTimeAnnotation] = result.versionTime.UTC().Format(time.RFC3339)
+	}
+	changedBy := tp.Annotations[compliancev1alpha1.LastModifiedByAnnotation]
+	specChanged := len(revisions) == 0 || revisions[len(revisions)-1].Data[revisionSpecFile] != string(spec)
+	if changedBy != "" && specChanged {
+		annotations[compliancev1alpha1.RevisionChangedByAnnotation] = changedBy
+	}
+
+	return &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      getRevisionName(tp, result.version),
+			Namespace: tp.Namespace,
+			Labels: map[string]string{
+				compliancev1alpha1.TailoredProfileLabel:               tp.Name,
+				compliancev1alpha1.TailoredProfileRevisionNumberLabel: strconv.FormatInt(result.version, 10),
+			},
+			Annotations: annotations,
+		},
+		Data: map[string]string{
+			tailoringFile:     tpcm.Data[tailoringFile],
+			justificationFile: tpcm.Data[justificationFile],
+			revisionSpecFile:  string(spec),
+		},
+	}, nil
+}
@@ -0,0 +1,213 @@
+package tailoredprofile
+
+import (
+	"context"
+	"strconv"
+
+	compliancev1alpha1 "github.com/JAORMX/compliance-profile-operator/pkg/apis/compliance/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func newTestTailoredProfile(name string) *compliancev1alpha1.TailoredProfile {
+	return &compliancev1alpha1.TailoredProfile{
+		TypeMeta:   metav1.TypeMeta{APIVersion: compliancev1alpha1.SchemeGroupVersion.String(), Kind: "TailoredProfile"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test", UID: types.UID(name + "-uid")},
+	}
+}
+
+// recordRevisions records revisions of the TailoredProfile's tailoring with
+// the given versions, and returns the revisions that were kept
+func recordRevisions(r *ReconcileTailoredProfile, tp *compliancev1alpha1.TailoredProfile, versions ...int64) []*corev1.ConfigMap {
+	var revisions []*corev1.ConfigMap
+	for _, version := range versions {
+		tpcm := newTailoredProfileCM(tp)
+		tpcm.Data[tailoringFile] = "tailoring " + strconv.FormatInt(version, 10)
+		var err error
+		revisions, err = r.ensureRevision(tp, revisions, tpcm, &tailoringResult{version: version}, logf.Log)
+		Expect(err).ToNot(HaveOccurred())
+	}
+	return revisions
+}
+
+func getRevisionNumbers(revisions []*corev1.ConfigMap) []int64 {
+	numbers := []int64{}
+	for _, revision := range revisions {
+		numbers = append(numbers, getRevisionNumber(revision))
+	}
+	return numbers
+}
+
+var _ = Describe("Testing tailoring revisions", func() {
+	var tp *compliancev1alpha1.TailoredProfile
+	var r *ReconcileTailoredProfile
+
+	BeforeEach(func() {
+		tp = newTestTailoredProfile("tp")
+		r = newTestReconciler(tp)
+	})
+
+	Context("recording revisions", func() {
+		It("names and labels revisions after their version", func() {
+			recordRevisions(r, tp, 1, 2)
+
+			revisions, err := r.getRevisions(tp)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(getRevisionNumbers(revisions)).To(Equal([]int64{1, 2}))
+			Expect(revisions[1].Name).To(Equal("tp-rev-2"))
+			Expect(revisions[1].Labels).To(HaveKeyWithValue(compliancev1alpha1.TailoredProfileLabel, "tp"))
+			Expect(revisions[1].Data[tailoringFile]).To(Equal("tailoring 2"))
+		})
+
+		It("records every version only once", func() {
+			revisions := recordRevisions(r, tp, 1, 1)
+			Expect(getRevisionNumbers(revisions)).To(Equal([]int64{1}))
+		})
+
+		It("ignores the output and the revisions of other TailoredProfiles", func() {
+			other := newTestTailoredProfile("other")
+			recordRevisions(r, other, 1)
+			output := newTailoredProfileCM(tp)
+			Expect(r.client.Create(context.TODO(), output)).To(Succeed())
+
+			revisions, err := r.getRevisions(tp)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(revisions).To(BeEmpty())
+		})
+
+		It("stores the revisions as Secrets with the Secret output", func() {
+			tp.Spec.OutputType = compliancev1alpha1.SecretOutput
+			recordRevisions(r, tp, 1)
+
+			secret := &corev1.Secret{}
+			Expect(r.client.Get(context.TODO(), types.NamespacedName{Name: "tp-rev-1", Namespace: "test"}, secret)).To(Succeed())
+			revisions, err := r.getRevisions(tp)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(revisions[0].Data[tailoringFile]).To(Equal("tailoring 1"))
+		})
+	})
+
+	Context("pruning revisions", func() {
+		It("keeps the newest revisions up to the history limit", func() {
+			limit := int32(2)
+			tp.Spec.RevisionHistoryLimit = &limit
+			kept := recordRevisions(r, tp, 1, 2, 3, 4)
+			Expect(getRevisionNumbers(kept)).To(Equal([]int64{3, 4}))
+
+			revisions, err := r.getRevisions(tp)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(getRevisionNumbers(revisions)).To(Equal([]int64{3, 4}))
+		})
+
+		It("keeps the pinned revision", func() {
+			limit := int32(2)
+			tp.Spec.RevisionHistoryLimit = &limit
+			tp.Spec.PinnedRevision = 1
+			kept := recordRevisions(r, tp, 1, 2, 3, 4)
+			Expect(getRevisionNumbers(kept)).To(Equal([]int64{1, 3, 4}))
+		})
+
+		It("defaults the history limit", func() {
+			versions := []int64{}
+			for v := int64(1); v <= int64(compliancev1alpha1.DefaultRevisionHistoryLimit)+2; v++ {
+				versions = append(versions, v)
+			}
+			kept := recordRevisions(r, tp, versions...)
+			Expect(kept).To(HaveLen(int(compliancev1alpha1.DefaultRevisionHistoryLimit)))
+			Expect(getRevisionNumber(kept[0])).To(Equal(int64(3)))
+		})
+	})
+
+	Context("finding revisions", func() {
+		It("finds a revision by its version", func() {
+			revisions := recordRevisions(r, tp, 1, 2, 3)
+			Expect(findRevision(revisions, 2).Name).To(Equal("tp-rev-2"))
+		})
+
+		It("returns nil for unknown versions", func() {
+			revisions := recordRevisions(r, tp, 1, 2)
+			Expect(findRevision(revisions, 3)).To(BeNil())
+			Expect(findRevision(nil, 1)).To(BeNil())
+		})
+	})
+
+	Context("getting the previous version", func() {
+		It("uses the status without revisions", func() {
+			tp.Status.Version = 4
+			tp.Status.ContentHash = "hash"
+			previous := getPreviousVersion(tp, nil)
+			Expect(previous.version).To(Equal(int64(4)))
+			Expect(previous.contentHash).To(Equal("hash"))
+		})
+
+		It("uses a revision newer than the status", func() {
+			tp.Status.Version = 1
+			revisions := recordRevisions(r, tp, 1, 2)
+			previous := getPreviousVersion(tp, revisions)
+			Expect(previous.version).To(Equal(int64(2)))
+		})
+
+		It("uses the status when it's newer than the revisions", func() {
+			tp.Status.Version = 3
+			revisions := recordRevisions(r, tp, 1, 2)
+			Expect(getPreviousVersion(tp, revisions).version).To(Equal(int64(3)))
+		})
+	})
+})
+
+var _ = Describe("Testing immutable revisions", func() {
+	It("marks the stored revision as immutable", func() {
+		tp := newTestTailoredProfile("tp")
+		u, err := toImmutableObject(newTailoredProfileCM(tp))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(u.Object).To(HaveKeyWithValue("immutable", true))
+		Expect(u.GetKind()).To(Equal("ConfigMap"))
+		Expect(u.GetLabels()).To(HaveKeyWithValue(compliancev1alpha1.TailoredProfileLabel, "tp"))
+	})
+})
+
+var _ = Describe("Testing tailoring versions", func() {
+	var tp *compliancev1alpha1.TailoredProfile
+	var p *compliancev1alpha1.Profile
+	var pb *compliancev1alpha1.ProfileBundle
+
+	render := func(previous tailoringVersion) *tailoringResult {
+		result := &tailoringResult{}
+		err := renderTailoring(newTailoredProfileCM(tp), tp, previous, p, pb, map[string]*compliancev1alpha1.Rule{}, nil, nil, result)
+		Expect(err).ToNot(HaveOccurred())
+		return result
+	}
+
+	BeforeEach(func() {
+		tp = newTestTailoredProfile("tp")
+		tp.Spec.Title = "Tailored"
+		pb = newTestBundle("ocp4")
+		p = &compliancev1alpha1.Profile{
+			ObjectMeta: metav1.ObjectMeta{Name: "ocp4-moderate", Namespace: "test"},
+			ID:         "xccdf_org.ssgproject.content_profile_moderate",
+		}
+	})
+
+	It("starts at version 1", func() {
+		Expect(render(tailoringVersion{}).version).To(Equal(int64(1)))
+	})
+
+	It("keeps the version while the content stays the same", func() {
+		first := render(tailoringVersion{})
+		second := render(tailoringVersion{version: first.version, time: first.versionTime, contentHash: first.contentHash})
+		Expect(second.version).To(Equal(first.version))
+		Expect(second.versionTime).To(Equal(first.versionTime))
+	})
+
+	It("increases the version when the content changes", func() {
+		first := render(tailoringVersion{})
+		tp.Spec.Title = "Changed"
+		second := render(tailoringVersion{version: first.version, time: first.versionTime, contentHash: first.contentHash})
+		Expect(second.version).To(Equal(int64(2)))
+	})
+})
@@ -0,0 +1,203 @@
+package tailoredprofile
+
+import (
+	"context"
+	"reflect"
+	"strings"
+
+	compliancev1alpha1 "github.com/JAORMX/compliance-profile-operator/pkg/apis/compliance/v1alpha1"
+	"github.com/go-logr/logr"
+	"github.com/operator-framework/operator-sdk/pkg/k8sutil"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// The tailoring is always rendered into a ConfigMap. TailoredProfiles with the
+// Secret output type store it, as well as its revisions, as Secrets holding
+// the same data instead.
+
+// watchSecrets watches the Secrets holding tailorings. Watching them through
+// the manager's cache would cache every Secret of the watched namespaces, so
+// they're watched through informers of their own instead, which only list the
+// Secrets labeled with a TailoredProfile.
+func watchSecrets(mgr manager.Manager, c controller.Controller) error {
+	clientset, err := kubernetes.NewForConfig(mgr.GetConfig())
+	if err != nil {
+		return err
+	}
+	namespaces, err := k8sutil.GetWatchNamespace()
+	if err != nil {
+		return err
+	}
+
+	// An empty namespace watches all of them
+	for _, namespace := range strings.Split(namespaces, ",") {
+		lw := toolscache.NewFilteredListWatchFromClient(clientset.CoreV1().RESTClient(), "secrets", namespace, func(options *metav1.ListOptions) {
+			options.LabelSelector = compliancev1alpha1.TailoredProfileLabel
+		})
+		informer := toolscache.NewSharedIndexInformer(lw, &corev1.Secret{}, 0, toolscache.Indexers{})
+		err := mgr.Add(manager.RunnableFunc(func(stop <-chan struct{}) error {
+			informer.Run(stop)
+			return nil
+		}))
+		if err != nil {
+			return err
+		}
+		err = c.Watch(&source.Informer{Informer: informer}, &handler.EnqueueRequestForOwner{
+			IsController: true,
+			OwnerType:    &compliancev1alpha1.TailoredProfile{},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// usesSecrets tells whether the tailorings of the TailoredProfile are stored in
+// Secrets rather than in ConfigMaps
+func usesSecrets(tp *compliancev1alpha1.TailoredProfile) bool {
+	return tp.Spec.OutputType == compliancev1alpha1.SecretOutput
+}
+
+// toStoredObject gets the object the ConfigMap is stored as: the ConfigMap
+// itself, or a Secret holding the same data if the TailoredProfile uses
+// Secrets
+func toStoredObject(tp *compliancev1alpha1.TailoredProfile, cm *corev1.ConfigMap) runtime.Object {
+	if usesSecrets(tp) {
+		return newSecretFromConfigMap(cm)
+	}
+	return cm
+}
+
+// newSecretFromConfigMap creates a Secret with the metadata and data of the
+// ConfigMap
+func newSecretFromConfigMap(cm *corev1.ConfigMap) *corev1.Secret {
+	data := make(map[string][]byte, len(cm.Data))
+	for key, value := range cm.Data {
+		data[key] = []byte(value)
+	}
+	return &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: *cm.ObjectMeta.DeepCopy(),
+		Type:       corev1.SecretTypeOpaque,
+		Data:       data,
+	}
+}
+
+// newConfigMapFromSecret creates a ConfigMap with the metadata and data of the
+// Secret
+func newConfigMapFromSecret(secret *corev1.Secret) *corev1.ConfigMap {
+	data := make(map[string]string, len(secret.Data))
+	for key, value := range secret.Data {
+		data[key] = string(value)
+	}
+	return &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+		},
+		ObjectMeta: *secret.ObjectMeta.DeepCopy(),
+		Data:       data,
+	}
+}
+
+func (r *ReconcileTailoredProfile) ensureSecretOutputObject(tp *compliancev1alpha1.TailoredProfile, tpcm *corev1.ConfigMap, result *tailoringResult, logger logr.Logger) (reconcile.Result, error) {
+	secret := newSecretFromConfigMap(tpcm)
+	// Set TailoredProfile instance as the owner and controller
+	if err := controllerutil.SetControllerReference(tp, secret, r.scheme); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	// Check if this Secret already exists
+	found := &corev1.Secret{}
+	err := r.secretReader.Get(context.TODO(), types.NamespacedName{Name: secret.Name, Namespace: secret.Namespace}, found)
+	if err != nil && errors.IsNotFound(err) {
+		logger.Info("Creating a new Secret", "Secret.Namespace", secret.Namespace, "Secret.Name", secret.Name)
+		err = r.client.Create(context.TODO(), secret)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+	} else if err != nil {
+		return reconcile.Result{}, err
+	} else if !reflect.DeepEqual(found.Data, secret.Data) || !hasTailoredProfileLabel(tp, found) {
+		// The tailoring changed, or somebody modified the Secret. Either
+		// way, its content needs to match the TailoredProfile.
+		logger.Info("Updating the Secret's tailoring", "Secret.Namespace", found.Namespace, "Secret.Name", found.Name)
+		foundCopy := found.DeepCopy()
+		foundCopy.Data = secret.Data
+		setTailoredProfileLabel(tp, foundCopy)
+		err = r.client.Update(context.TODO(), foundCopy)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	if !isTailoredProfileStatusReady(tp, secret, result) {
+		err = r.updateTailoredProfileStatusReady(tp, secret, result)
+		if err != nil {
+			logger.Error(err, "Couldn't update TailoredProfile status")
+			return reconcile.Result{}, err
+		}
+	}
+
+	// Secret is up to date - don't requeue
+	return reconcile.Result{}, nil
+}
+
+// removeStaleObjects removes the output and revisions the TailoredProfile
+// stored as ConfigMaps while it uses Secrets, or the other way around. This
+// way a tailoring holding sensitive values doesn't linger in ConfigMaps once
+// the output type is switched to Secret.
+func (r *ReconcileTailoredProfile) removeStaleObjects(tp *compliancev1alpha1.TailoredProfile, logger logr.Logger) error {
+	listOpts := []client.ListOption{
+		client.InNamespace(tp.Namespace),
+		client.MatchingLabels{compliancev1alpha1.TailoredProfileLabel: tp.Name},
+	}
+	var stale []runtime.Object
+	if usesSecrets(tp) {
+		cmList := &corev1.ConfigMapList{}
+		if err := r.client.List(context.TODO(), cmList, listOpts...); err != nil {
+			return err
+		}
+		for i := range cmList.Items {
+			if metav1.IsControlledBy(&cmList.Items[i], tp) {
+				stale = append(stale, &cmList.Items[i])
+			}
+		}
+	} else {
+		secretList := &corev1.SecretList{}
+		if err := r.secretReader.List(context.TODO(), secretList, listOpts...); err != nil {
+			return err
+		}
+		for i := range secretList.Items {
+			if metav1.IsControlledBy(&secretList.Items[i], tp) {
+				stale = append(stale, &secretList.Items[i])
+			}
+		}
+	}
+
+	for _, obj := range stale {
+		meta := obj.(metav1.Object)
+		logger.Info("Removing a tailoring stored with another output type", "Object.Namespace", meta.GetNamespace(), "Object.Name", meta.GetName())
+		if err := r.client.Delete(context.TODO(), obj); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package tailoredprofile
+
+import (
+	"context"
+
+	compliancev1alpha1 "github.com/JAORMX/compliance-profile-operator/pkg/apis/compliance/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Testing the Secret output", func() {
+	var tp *compliancev1alpha1.TailoredProfile
+	var r *ReconcileTailoredProfile
+	var tpcm *corev1.ConfigMap
+
+	BeforeEach(func() {
+		tp = newTestTailoredProfile("tp")
+		tp.Spec.OutputType = compliancev1alpha1.SecretOutput
+		r = newTestReconciler(tp)
+		tpcm = newTailoredProfileCM(tp)
+		tpcm.Data[tailoringFile] = "<tailoring/>"
+	})
+
+	getSecret := func() *corev1.Secret {
+		secret := &corev1.Secret{}
+		Expect(r.client.Get(context.TODO(), types.NamespacedName{Name: "tp-tp", Namespace: "test"}, secret)).To(Succeed())
+		return secret
+	}
+
+	getTailoredProfile := func() *compliancev1alpha1.TailoredProfile {
+		found := &compliancev1alpha1.TailoredProfile{}
+		Expect(r.client.Get(context.TODO(), types.NamespacedName{Name: "tp", Namespace: "test"}, found)).To(Succeed())
+		return found
+	}
+
+	It("converts between ConfigMaps and Secrets", func() {
+		secret := newSecretFromConfigMap(tpcm)
+		Expect(secret.Kind).To(Equal("Secret"))
+		Expect(secret.Name).To(Equal(tpcm.Name))
+		Expect(secret.Labels).To(Equal(tpcm.Labels))
+		Expect(secret.Data[tailoringFile]).To(Equal([]byte("<tailoring/>")))
+		Expect(newConfigMapFromSecret(secret)).To(Equal(tpcm))
+	})
+
+	Context("writing the output", func() {
+		It("creates the Secret and references it in the status", func() {
+			_, err := r.ensureSecretOutputObject(tp, tpcm, &tailoringResult{}, logf.Log)
+			Expect(err).ToNot(HaveOccurred())
+
+			secret := getSecret()
+			Expect(secret.Data[tailoringFile]).To(Equal([]byte("<tailoring/>")))
+			Expect(metav1.IsControlledBy(secret, tp)).To(BeTrue())
+			Expect(getTailoredProfile().Status.OutputRef).To(Equal(compliancev1alpha1.OutputRef{
+				APIVersion: "v1",
+				Kind:       "Secret",
+				Name:       "tp-tp",
+				Namespace:  "test",
+			}))
+		})
+
+		It("restores the tailoring and the label of the Secret", func() {
+			_, err := r.ensureSecretOutputObject(tp, tpcm, &tailoringResult{}, logf.Log)
+			Expect(err).ToNot(HaveOccurred())
+			secret := getSecret()
+			secret.Data[tailoringFile] = []byte("modified")
+			secret.Labels = map[string]string{"other": "label"}
+			Expect(r.client.Update(context.TODO(), secret)).To(Succeed())
+
+			_, err = r.ensureSecretOutputObject(getTailoredProfile(), tpcm, &tailoringResult{}, logf.Log)
+			Expect(err).ToNot(HaveOccurred())
+			secret = getSecret()
+			Expect(secret.Data[tailoringFile]).To(Equal([]byte("<tailoring/>")))
+			Expect(secret.Labels).To(Equal(map[string]string{
+				"other":                                 "label",
+				compliancev1alpha1.TailoredProfileLabel: "tp",
+			}))
+		})
+	})
+
+	Context("removing stale objects", func() {
+		newOwnedConfigMap := func(owner *compliancev1alpha1.TailoredProfile, name string, labeled bool) *corev1.ConfigMap {
+			cm := newTailoredProfileCM(owner)
+			cm.Name = name
+			if !labeled {
+				cm.Labels = nil
+			}
+			Expect(controllerutil.SetControllerReference(owner, cm, r.scheme)).To(Succeed())
+			return cm
+		}
+
+		It("removes the tailorings stored in ConfigMaps when using Secrets", func() {
+			other := newTestTailoredProfile("other")
+			otherCM := newOwnedConfigMap(other, "other-tp", false)
+			otherCM.Labels = map[string]string{compliancev1alpha1.TailoredProfileLabel: "tp"}
+			for _, cm := range []*corev1.ConfigMap{
+				newOwnedConfigMap(tp, "tp-tp", true),
+				newOwnedConfigMap(tp, "tp-rev-1", true),
+				newOwnedConfigMap(tp, "unlabeled", false),
+				otherCM,
+			} {
+				Expect(r.client.Create(context.TODO(), cm)).To(Succeed())
+			}
+			Expect(r.client.Create(context.TODO(), newSecretFromConfigMap(newOwnedConfigMap(tp, "tp-tp", true)))).To(Succeed())
+
+			Expect(r.removeStaleObjects(tp, logf.Log)).To(Succeed())
+
+			cmList := &corev1.ConfigMapList{}
+			Expect(r.client.List(context.TODO(), cmList)).To(Succeed())
+			names := []string{}
+			for _, cm := range cmList.Items {
+				names = append(names, cm.Name)
+			}
+			Expect(names).To(ConsistOf("unlabeled", "other-tp"))
+			getSecret()
+		})
+
+		It("removes the tailorings stored in Secrets when using ConfigMaps", func() {
+			tp.Spec.OutputType = compliancev1alpha1.ConfigMapOutput
+			Expect(r.client.Create(context.TODO(), newSecretFromConfigMap(newOwnedConfigMap(tp, "tp-tp", true)))).To(Succeed())
+			Expect(r.client.Create(context.TODO(), newOwnedConfigMap(tp, "tp-tp", true))).To(Succeed())
+
+			Expect(r.removeStaleObjects(tp, logf.Log)).To(Succeed())
+
+			err := r.client.Get(context.TODO(), types.NamespacedName{Name: "tp-tp", Namespace: "test"}, &corev1.Secret{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+			Expect(r.client.Get(context.TODO(), types.NamespacedName{Name: "tp-tp", Namespace: "test"}, &corev1.ConfigMap{})).To(Succeed())
+		})
+	})
+})
@@ -0,0 +1,133 @@
+package tailoredprofile
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	compliancev1alpha1 "github.com/JAORMX/compliance-profile-operator/pkg/apis/compliance/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func hasRuleSelectors(tp *compliancev1alpha1.TailoredProfile) bool {
+	for _, selection := range tp.Spec.EnableRules {
+		if selection.Selector != nil {
+			return true
+		}
+	}
+	for _, selection := range tp.Spec.DisableRules {
+		if selection.Selector != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// expandRuleSelectors returns a copy of the TailoredProfile in which the rule
+// selectors are replaced by the rules of the bundle they match. Rules
+// referenced by name take precedence over the ones matched by selectors, and
+// rules matched by a disabling selector are never enabled by a selector. The
+// boolean tells whether the error is retriable.
+func (r *ReconcileTailoredProfile) expandRuleSelectors(tp *compliancev1alpha1.TailoredProfile, pb *compliancev1alpha1.ProfileBundle) (*compliancev1alpha1.TailoredProfile, bool, error) {
+	for _, selections := range [][]compliancev1alpha1.RuleReferenceSpec{tp.Spec.EnableRules, tp.Spec.DisableRules} {
+		for _, selection := range selections {
+			if err := validateRuleReference(selection); err != nil {
+				return nil, false, err
+			}
+		}
+	}
+	if !hasRuleSelectors(tp) {
+		return tp, false, nil
+	}
+
+	ruleList := &compliancev1alpha1.RuleList{}
+	if err := r.client.List(context.TODO(), ruleList, client.InNamespace(pb.Namespace)); err != nil {
+		return nil, true, err
+	}
+	// Keep the output stable, the list isn't sorted
+	sort.Slice(ruleList.Items, func(i, j int) bool {
+		return ruleList.Items[i].Name < ruleList.Items[j].Name
+	})
+
+	tpCopy := tp.DeepCopy()
+	tpCopy.Spec.EnableRules = []compliancev1alpha1.RuleReferenceSpec{}
+	tpCopy.Spec.DisableRules = []compliancev1alpha1.RuleReferenceSpec{}
+
+	// rules that are already referenced, be it by name or by a selector
+	referenced := make(map[string]bool)
+	for _, selection := range tp.Spec.EnableRules {
+		if selection.Selector == nil {
+			referenced[selection.Name] = true
+			tpCopy.Spec.EnableRules = append(tpCopy.Spec.EnableRules, selection)
+		}
+	}
+	for _, selection := range tp.Spec.DisableRules {
+		if selection.Selector == nil {
+			referenced[selection.Name] = true
+			tpCopy.Spec.DisableRules = append(tpCopy.Spec.DisableRules, selection)
+		}
+	}
+
+	expand := func(selections []compliancev1alpha1.RuleReferenceSpec) ([]compliancev1alpha1.RuleReferenceSpec, error) {
+		expanded := []compliancev1alpha1.RuleReferenceSpec{}
+		for _, selection := range selections {
+			if selection.Selector == nil {
+				continue
+			}
+			for i := range ruleList.Items {
+				rule := &ruleList.Items[i]
+				if referenced[rule.Name] || !metav1.IsControlledBy(rule, pb) {
+					continue
+				}
+				matches, err := selection.Selector.Matches(rule)
+				if err != nil {
+					return nil, fmt.Errorf("Invalid rule selector: %s", err)
+				}
+				if matches {
+					referenced[rule.Name] = true
+					expanded = append(expanded, compliancev1alpha1.RuleReferenceSpec{
+						Name:      rule.Name,
+						Rationale: selection.Rationale,
+					})
+				}
+			}
+		}
+		return expanded, nil
+	}
+
+	// Disabling selectors go first, so they win over the enabling ones
+	disabled, err := expand(tp.Spec.DisableRules)
+	if err != nil {
+		return nil, false, err
+	}
+	enabled, err := expand(tp.Spec.EnableRules)
+	if err != nil {
+		return nil, false, err
+	}
+	tpCopy.Spec.DisableRules = append(tpCopy.Spec.DisableRules, disabled...)
+	tpCopy.Spec.EnableRules = append(tpCopy.Spec.EnableRules, enabled...)
+	return tpCopy, false, nil
+}
+
+func validateRuleReference(selection compliancev1alpha1.RuleReferenceSpec) error {
+	switch {
+	case selection.Name != "" && selection.Selector != nil:
+		return fmt.Errorf("Rule references can't have both a name ('%s') and a selector", selection.Name)
+	case selection.Name == "" && selection.Selector == nil:
+		return fmt.Errorf("Rule references need either a name or a selector")
+	case selection.Selector != nil && selection.Selector.IsEmpty():
+		return fmt.Errorf("Rule selectors need at least one criteria")
+	}
+	return nil
+}
+
+// getRuleNames returns the sorted names of the referenced rules
+func getRuleNames(selections []compliancev1alpha1.RuleReferenceSpec) []string {
+	names := make([]string, 0, len(selections))
+	for _, selection := range selections {
+		names = append(names, selection.Name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,112 @@
+package tailoredprofile
+
+import (
+	"context"
+	"fmt"
+
+	compliancev1alpha1 "github.com/JAORMX/compliance-profile-operator/pkg/apis/compliance/v1alpha1"
+	"github.com/JAORMX/compliance-profile-operator/pkg/compliancesuite"
+	"github.com/JAORMX/compliance-profile-operator/pkg/xccdf"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// ensureComplianceSuiteOutputObject generates the ConfigMap holding the
+// tailoring, along with a ComplianceSuite scanning the cluster with it. The
+// ComplianceSuite is named after the TailoredProfile.
+func (r *ReconcileTailoredProfile) ensureComplianceSuiteOutputObject(tp *compliancev1alpha1.TailoredProfile, tpcm *corev1.ConfigMap, pb *compliancev1alpha1.ProfileBundle, result *tailoringResult, logger logr.Logger) (reconcile.Result, error) {
+	// The settings were validated along with the rest of the spec
+	settings := tp.Spec.ScanSettings.WithDefaults()
+
+	if err := r.ensureTailoringConfigMap(tp, tpcm, logger); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	suite := compliancesuite.NewComplianceSuite(tp.Name, tp.Namespace, settings, xccdf.GetXCCDFProfileID(tp), pb, tpcm.Name)
+	suiteObj, err := suite.ToUnstructured()
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	// Set TailoredProfile instance as the owner and controller
+	if err := controllerutil.SetControllerReference(tp, suiteObj, r.scheme); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	// Check if this ComplianceSuite already exists
+	found := &unstructured.Unstructured{}
+	found.SetGroupVersionKind(compliancesuite.GroupVersionKind)
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: suite.Name, Namespace: suite.Namespace}, found)
+	if err != nil && errors.IsNotFound(err) {
+		logger.Info("Creating a new ComplianceSuite", "ComplianceSuite.Namespace", suite.Namespace, "ComplianceSuite.Name", suite.Name)
+		err = r.client.Create(context.TODO(), suiteObj)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+	} else if meta.IsNoMatchError(err) {
+		updateErr := r.updateTailoredProfileStatusError(tp, compliancev1alpha1.TailoredProfileConditionOutputReady, compliancev1alpha1.ReasonOutputFailed, fmt.Errorf(
+			"ComplianceSuites aren't available in the cluster, the compliance-operator needs to be installed"))
+		return reconcile.Result{}, updateErr
+	} else if err != nil {
+		return reconcile.Result{}, err
+	} else if !metav1.IsControlledBy(found, tp) {
+		updateErr := r.updateTailoredProfileStatusError(tp, compliancev1alpha1.TailoredProfileConditionOutputReady, compliancev1alpha1.ReasonOutputFailed, fmt.Errorf(
+			"ComplianceSuite '%s' already exists and doesn't belong to TailoredProfile '%s'", found.GetName(), tp.Name))
+		return reconcile.Result{}, updateErr
+	} else if !isSameJSON(found.Object["spec"], suiteObj.Object["spec"]) {
+		// The scan settings or the bundle changed, or somebody modified
+		// the ComplianceSuite. Either way, it needs to match the
+		// TailoredProfile.
+		logger.Info("Updating the ComplianceSuite", "ComplianceSuite.Namespace", found.GetNamespace(), "ComplianceSuite.Name", found.GetName())
+		foundCopy := found.DeepCopy()
+		foundCopy.Object["spec"] = suiteObj.Object["spec"]
+		err = r.client.Update(context.TODO(), foundCopy)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	if !isTailoredProfileStatusReady(tp, suiteObj, result) {
+		err = r.updateTailoredProfileStatusReady(tp, suiteObj, result)
+		if err != nil {
+			logger.Error(err, "Couldn't update TailoredProfile status")
+			return reconcile.Result{}, err
+		}
+	}
+
+	// ComplianceSuite is up to date - don't requeue
+	return reconcile.Result{}, nil
+}
+
+// removeStaleComplianceSuite removes the ComplianceSuite generated for the
+// TailoredProfile once its output type isn't ComplianceSuite anymore
+func (r *ReconcileTailoredProfile) removeStaleComplianceSuite(tp *compliancev1alpha1.TailoredProfile, logger logr.Logger) error {
+	if tp.Spec.OutputType == compliancev1alpha1.ComplianceSuiteOutput {
+		return nil
+	}
+
+	found := &unstructured.Unstructured{}
+	found.SetGroupVersionKind(compliancesuite.GroupVersionKind)
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: tp.Name, Namespace: tp.Namespace}, found)
+	if errors.IsNotFound(err) || meta.IsNoMatchError(err) {
+		// Nothing to remove, or the compliance-operator isn't even installed
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if !metav1.IsControlledBy(found, tp) {
+		return nil
+	}
+
+	logger.Info("Removing the ComplianceSuite of another output type", "ComplianceSuite.Namespace", found.GetNamespace(), "ComplianceSuite.Name", found.GetName())
+	if err := r.client.Delete(context.TODO(), found); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
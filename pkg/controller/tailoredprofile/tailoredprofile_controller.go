@@ -1,19 +1,31 @@
 package tailoredprofile
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"reflect"
+	"sort"
+	"time"
 
 	"github.com/JAORMX/compliance-profile-operator/pkg/xccdf"
 	"github.com/go-logr/logr"
 
 	compliancev1alpha1 "github.com/JAORMX/compliance-profile-operator/pkg/apis/compliance/v1alpha1"
+	"github.com/JAORMX/compliance-profile-operator/pkg/compliancesuite"
+	"github.com/JAORMX/compliance-profile-operator/pkg/controller/common"
+	"github.com/JAORMX/compliance-profile-operator/pkg/policy"
+	"github.com/JAORMX/compliance-profile-operator/pkg/utils"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -28,6 +40,9 @@ var log = logf.Log.WithName("controller_tailoredprofile")
 
 const (
 	tailoringFile string = "tailoring.xml"
+	// justificationFile holds a readable report of the rationales of the
+	// tailoring
+	justificationFile string = "justification.md"
 )
 
 // Add creates a new TailoredProfile Controller and adds it to the Manager. The Manager will set fields on the Controller
@@ -38,7 +53,7 @@ func Add(mgr manager.Manager) error {
 
 // newReconciler returns a new reconcile.Reconciler
 func newReconciler(mgr manager.Manager) reconcile.Reconciler {
-	return &ReconcileTailoredProfile{client: mgr.GetClient(), scheme: mgr.GetScheme()}
+	return &ReconcileTailoredProfile{client: mgr.GetClient(), secretReader: mgr.GetAPIReader(), scheme: mgr.GetScheme()}
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
@@ -63,9 +78,93 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	if err := watchSecrets(mgr, c); err != nil {
+		return err
+	}
+
+	// Keep the number of clusters a Policy is placed on up to date, once
+	// RHACM's API is there
+	if err := watchOwnedWhenServed(mgr, c, getPlacementRuleGVK(common.GetPolicyAPIVersion())); err != nil {
+		return err
+	}
+
+	// Keep the generated ComplianceSuites in sync, once the
+	// compliance-operator's API is there
+	if err := watchOwnedWhenServed(mgr, c, compliancesuite.GroupVersionKind); err != nil {
+		return err
+	}
+
+	// Re-tailor whenever the objects a TailoredProfile references change
+	if err := addIndexes(mgr); err != nil {
+		return err
+	}
+	dependencies := map[string]runtime.Object{
+		extendsIndex:                &compliancev1alpha1.Profile{},
+		extendsTailoredProfileIndex: &compliancev1alpha1.TailoredProfile{},
+		profileBundleIndex:          &compliancev1alpha1.ProfileBundle{},
+		variablesIndex:              &compliancev1alpha1.Variable{},
+	}
+	for index, obj := range dependencies {
+		err = c.Watch(&source.Kind{Type: obj}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: &dependentTailoredProfileMapper{client: mgr.GetClient(), index: index},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	// Any Rule of the tailored bundle may affect the tailoring, including the
+	// ones referenced by name
+	err = c.Watch(&source.Kind{Type: &compliancev1alpha1.Rule{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: &bundleTailoredProfileMapper{client: mgr.GetClient()},
+	})
+	if err != nil {
+		return err
+	}
+	err = c.Watch(&source.Kind{Type: &compliancev1alpha1.ReferenceGrant{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: &grantedTailoredProfileMapper{client: mgr.GetClient()},
+	})
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// optionalAPIPollInterval is how often the operator checks whether an optional
+// API it watches has been installed
+const optionalAPIPollInterval = time.Minute
+
+// watchOwnedWhenServed watches the objects of the given kind owned by
+// TailoredProfiles. The kind belongs to an optional API, such as RHACM's, that
+// may only be installed after the operator started, so until it's served the
+// operator checks for it periodically and starts watching once it shows up.
+func watchOwnedWhenServed(mgr manager.Manager, c controller.Controller, gvk schema.GroupVersionKind) error {
+	watch := func() (bool, error) {
+		if _, err := mgr.GetRESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version); err != nil {
+			return false, nil
+		}
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(gvk)
+		return true, c.Watch(&source.Kind{Type: obj}, &handler.EnqueueRequestForOwner{
+			IsController: true,
+			OwnerType:    &compliancev1alpha1.TailoredProfile{},
+		})
+	}
+	if served, err := watch(); served || err != nil {
+		return err
+	}
+
+	log.Info("Not watching an API that isn't served yet", "Kind", gvk.String())
+	return mgr.Add(manager.RunnableFunc(func(stop <-chan struct{}) error {
+		err := wait.PollUntil(optionalAPIPollInterval, watch, stop)
+		if err == wait.ErrWaitTimeout {
+			// Stopped before the API showed up
+			return nil
+		}
+		return err
+	}))
+}
+
 // blank assignment to verify that ReconcileTailoredProfile implements reconcile.Reconciler
 var _ reconcile.Reconciler = &ReconcileTailoredProfile{}
 
@@ -74,7 +173,10 @@ type ReconcileTailoredProfile struct {
 	// This client, initialized using mgr.Client() above, is a split client
 	// that reads objects from the cache and writes to the apiserver
 	client client.Client
-	scheme *runtime.Scheme
+	// secretReader reads Secrets from the apiserver, so that the cache
+	// doesn't hold every Secret of the watched namespaces
+	secretReader client.Reader
+	scheme       *runtime.Scheme
 }
 
 // Reconcile reads that state of the cluster for a TailoredProfile object and makes changes based on the state read
@@ -100,88 +202,188 @@ func (r *ReconcileTailoredProfile) Reconcile(request reconcile.Request) (reconci
 		return reconcile.Result{}, err
 	}
 
+	if instance.GetDeletionTimestamp() != nil {
+		return r.finalizeTailoredProfile(instance, reqLogger)
+	}
+	// Make sure the outputs that aren't garbage collected are removed along
+	// with the TailoredProfile, if it has any. This update will trigger a
+	// requeue.
+	if updated, err := r.ensureFinalizer(instance); updated || err != nil {
+		return reconcile.Result{}, err
+	}
+
 	if canContinue, err := r.validateTailoredProfile(instance); !canContinue {
 		return reconcile.Result{}, err
 	}
 
-	// Get the Profile being extended
-	p := &compliancev1alpha1.Profile{}
-	err = r.client.Get(context.TODO(), types.NamespacedName{Name: instance.Spec.Extends, Namespace: instance.Namespace}, p)
+	// Get the Profile being extended, as well as the TailoredProfiles in
+	// between if this one extends another TailoredProfile. There's no
+	// Profile if the chain was built from scratch.
+	p, parents, retriableErr, err := r.resolveExtendsChain(instance)
 	if err != nil {
-		if errors.IsNotFound(err) {
-			// the Profile object didn't exist. Surface the error.
-			err = r.updateTailoredProfileStatusError(instance, err)
-			if err != nil {
-				// error udpating status - requeue
-				return reconcile.Result{}, err
-			}
-			return reconcile.Result{}, nil
-		}
-		// could be a transient error that can be recovered from
-		return reconcile.Result{}, err
+		return r.handleResolutionError(instance, retriableErr, err)
+	}
+
+	pb, retriableErr, err := r.getProfileBundleForChain(instance, p, parents)
+	if err != nil {
+		return r.handleResolutionError(instance, retriableErr, err)
 	}
 
-	// Make TailoredProfile be owned by the Profile it extends. This way
+	// Make TailoredProfile be owned by the object it extends. This way
 	// we can ensure garbage collection happens.
 	// This update will trigger a requeue with the new object.
-	if !isOwnedBy(instance, p) {
-		if err := controllerutil.SetOwnerReference(p, instance, r.scheme); err != nil {
+	var owner metav1.Object = pb
+	if len(parents) > 0 {
+		owner = parents[len(parents)-1]
+	} else if p != nil {
+		owner = p
+	}
+	// OwnerReferences can't cross namespaces, so a TailoredProfile that
+	// references the content of another namespace isn't garbage collected
+	// along with it. It surfaces an error instead, as it does whenever the
+	// objects it references are gone.
+	if owner.GetNamespace() == instance.Namespace && !isOwnedBy(instance, owner) {
+		if err := controllerutil.SetOwnerReference(owner, instance, r.scheme); err != nil {
 			return reconcile.Result{}, err
 		}
 		err = r.client.Update(context.TODO(), instance)
 		return reconcile.Result{}, err
 	}
 
-	pb, err := r.getProfileBundleFromProfile(p)
-	if err != nil {
+	// Record the ProfileBundle the content is resolved from, as changes to
+	// any of its Rules may affect the tailoring.
+	// This update will trigger a requeue with the new object.
+	if ref := getProfileBundleRef(pb); !reflect.DeepEqual(instance.Status.ProfileBundleRef, ref) {
+		tpCopy := instance.DeepCopy()
+		tpCopy.Status.ProfileBundleRef = ref
+		err = r.client.Status().Update(context.TODO(), tpCopy)
 		return reconcile.Result{}, err
 	}
 
-	rules, retriableErr, err := r.getRulesFromSelections(instance)
+	// Rules and variables may be referenced by XCCDF ID or short name
+	resolved, resolvedParents, retriableErr, err := r.resolveChainReferences(instance, parents, pb)
 	if err != nil {
-		if !retriableErr {
-			// Surface the error.
-			err = r.updateTailoredProfileStatusError(instance, err)
-			if err != nil {
-				// error udpating status - requeue
-				return reconcile.Result{}, err
-			}
-			return reconcile.Result{}, nil
-		}
-		return reconcile.Result{}, err
+		return r.handleResolutionError(instance, retriableErr, err)
 	}
 
-	variables, retriableErr, err := r.getVariablesFromSelections(instance)
+	// From here on, the changes of the whole chain are taken into account
+	tailored, retriableErr, err := r.expandRuleSelectors(flattenTailoredProfile(resolved, resolvedParents), pb)
 	if err != nil {
-		if !retriableErr {
-			// Surface the error.
-			err = r.updateTailoredProfileStatusError(instance, err)
-			if err != nil {
-				// error udpating status - requeue
-				return reconcile.Result{}, err
-			}
-			return reconcile.Result{}, nil
-		}
-		return reconcile.Result{}, err
+		return r.handleResolutionError(instance, retriableErr, err)
+	}
+
+	rules, retriableErr, err := r.getRulesFromSelections(tailored, pb)
+	if err != nil {
+		return r.handleResolutionError(instance, retriableErr, err)
+	}
+
+	requiredRules, retriableErr, err := r.resolveRuleDependencies(tailored, p, pb, rules)
+	if err != nil {
+		return r.handleResolutionError(instance, retriableErr, err)
+	}
+
+	variables, retriableErr, err := r.getVariablesFromSelections(tailored, pb)
+	if err != nil {
+		return r.handleResolutionError(instance, retriableErr, err)
+	}
+
+	refinedRules, retriableErr, err := r.getRefinedRules(tailored, pb)
+	if err != nil {
+		return r.handleResolutionError(instance, retriableErr, err)
+	}
+
+	refinedVariables, retriableErr, err := r.getRefinedVariables(tailored, pb)
+	if err != nil {
+		return r.handleResolutionError(instance, retriableErr, err)
 	}
 
 	// Get tailored profile config map
 	tpcm := newTailoredProfileCM(instance)
 
-	tpcm.Data[tailoringFile], err = xccdf.TailoredProfileToXML(instance, p, pb, rules, variables)
+	tailored, rules = withRequiredRules(tailored, rules, requiredRules)
+	result := &tailoringResult{
+		extendsChain:         getExtendsChain(instance, p, pb, parents),
+		resolvedEnableRules:  getRuleNames(tailored.Spec.EnableRules),
+		resolvedDisableRules: getRuleNames(tailored.Spec.DisableRules),
+		warnings:             getWarnings(resolved, resolvedParents, p),
+	}
+	if p == nil {
+		// Built from scratch: only the enabled rules are to be evaluated
+		tailored, err = r.withUnselectedRules(tailored, pb, rules)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	// The refined rules are only added now, as they don't affect the selection
+	for name, rule := range refinedRules {
+		if _, ok := rules[name]; !ok {
+			rules[name] = rule
+		}
+	}
+
+	revisions, err := r.getRevisions(instance)
 	if err != nil {
 		return reconcile.Result{}, err
 	}
 
-	return r.ensureOutputObject(instance, tpcm, pb, reqLogger)
+	previous := getPreviousVersion(instance, revisions)
+	err = renderTailoring(tpcm, tailored, previous, p, pb, rules, variables, refinedVariables, result)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	revisions, err = r.ensureRevision(instance, revisions, tpcm, result, reqLogger)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	// The output may be pinned to an older revision
+	result.outputRevision = result.version
+	if instance.Spec.PinnedRevision != 0 && instance.Spec.PinnedRevision != result.version {
+		pinned := findRevision(revisions, instance.Spec.PinnedRevision)
+		if pinned == nil {
+			updateErr := r.updateTailoredProfileStatusError(instance, compliancev1alpha1.TailoredProfileConditionOutputReady, compliancev1alpha1.ReasonOutputFailed, fmt.Errorf(
+				"Revision %d of TailoredProfile '%s' doesn't exist", instance.Spec.PinnedRevision, instance.Name))
+			return reconcile.Result{}, updateErr
+		}
+		tpcm.Data[tailoringFile] = pinned.Data[tailoringFile]
+		tpcm.Data[justificationFile] = pinned.Data[justificationFile]
+		result.outputRevision = instance.Spec.PinnedRevision
+	}
+
+	return r.ensureOutputObject(instance, tpcm, p, pb, result, reqLogger)
 }
 
 // validates the given TailoredProfile. true means that we can continue since the
 // tailored profile is valid, false means that we can't.
 func (r *ReconcileTailoredProfile) validateTailoredProfile(tp *compliancev1alpha1.TailoredProfile) (bool, error) {
 	// Validate TailoredProfile
-	if tp.Spec.Extends == "" {
-		err := r.updateTailoredProfileStatusError(tp, fmt.Errorf(".spec.extends can't be empty"))
+	if tp.Spec.Extends == "" && tp.Spec.ProfileBundle == "" {
+		err := r.updateTailoredProfileStatusError(tp, compliancev1alpha1.TailoredProfileConditionValidated, compliancev1alpha1.ReasonInvalidSpec, fmt.Errorf(".spec.extends can't be empty unless .spec.profileBundle is set"))
+		if err != nil {
+			return false, err
+		}
+		// don't return an error in the reconciler. The error will surface via the CR's status
+		return false, nil
+	}
+
+	if tp.Spec.Extends != "" && tp.Spec.ProfileBundle != "" {
+		err := r.updateTailoredProfileStatusError(tp, compliancev1alpha1.TailoredProfileConditionValidated, compliancev1alpha1.ReasonInvalidSpec, fmt.Errorf(".spec.extends and .spec.profileBundle are mutually exclusive"))
+		if err != nil {
+			return false, err
+		}
+		// don't return an error in the reconciler. The error will surface via the CR's status
+		return false, nil
+	}
+
+	switch tp.Spec.ExtendsKind {
+	case "", compliancev1alpha1.ExtendsProfile, compliancev1alpha1.ExtendsTailoredProfile:
+	default:
+		err := r.updateTailoredProfileStatusError(
+			tp, compliancev1alpha1.TailoredProfileConditionValidated, compliancev1alpha1.ReasonInvalidSpec,
+			fmt.Errorf(".spec.extendsKind is invalid (accepted values: Profile, TailoredProfile)"),
+		)
 		if err != nil {
 			return false, err
 		}
@@ -192,6 +394,8 @@ func (r *ReconcileTailoredProfile) validateTailoredProfile(tp *compliancev1alpha
 	switch tp.Spec.OutputType {
 	case compliancev1alpha1.ConfigMapOutput: // Do nothing, we're good
 	case compliancev1alpha1.PolicyOutput: // Do nothing, we're good
+	case compliancev1alpha1.SecretOutput: // Do nothing, we're good
+	case compliancev1alpha1.ComplianceSuiteOutput: // Do nothing, we're good
 	case "":
 		tpCopy := tp.DeepCopy()
 		tpCopy.Spec.OutputType = compliancev1alpha1.ConfigMapOutput
@@ -199,8 +403,21 @@ func (r *ReconcileTailoredProfile) validateTailoredProfile(tp *compliancev1alpha
 		return false, r.client.Update(context.TODO(), tpCopy)
 	default:
 		err := r.updateTailoredProfileStatusError(
-			tp,
-			fmt.Errorf(".spec.outputType is invalid (accepted values: ConfigMap"),
+			tp, compliancev1alpha1.TailoredProfileConditionValidated, compliancev1alpha1.ReasonInvalidSpec,
+			fmt.Errorf(".spec.outputType is invalid (accepted values: ConfigMap, Policy, Secret, ComplianceSuite)"),
+		)
+		if err != nil {
+			return false, err
+		}
+		// don't return an error in the reconciler. The error will surface via the CR's status
+		return false, nil
+	}
+
+	// ComplianceSuites and Policies only take tailorings from ConfigMaps
+	if tp.Spec.OutputType == compliancev1alpha1.SecretOutput && (tp.Spec.PolicyOptions != nil || tp.Spec.ScanSettings != nil) {
+		err := r.updateTailoredProfileStatusError(
+			tp, compliancev1alpha1.TailoredProfileConditionValidated, compliancev1alpha1.ReasonInvalidSpec,
+			fmt.Errorf(".spec.policyOptions and .spec.scanSettings can't be used with the Secret output type, as ComplianceSuites and Policies only take tailorings from ConfigMaps"),
 		)
 		if err != nil {
 			return false, err
@@ -209,10 +426,35 @@ func (r *ReconcileTailoredProfile) validateTailoredProfile(tp *compliancev1alpha
 		return false, nil
 	}
 
+	// Unset options are defaulted, which makes them valid
+	if tp.Spec.PolicyOptions != nil {
+		if err := tp.Spec.PolicyOptions.Validate(); err != nil {
+			updateErr := r.updateTailoredProfileStatusError(tp, compliancev1alpha1.TailoredProfileConditionValidated, compliancev1alpha1.ReasonInvalidSpec,
+				fmt.Errorf("Invalid policyOptions: %s", err))
+			if updateErr != nil {
+				return false, updateErr
+			}
+			// don't return an error in the reconciler. The error will surface via the CR's status
+			return false, nil
+		}
+	}
+
+	if tp.Spec.ScanSettings != nil {
+		if err := tp.Spec.ScanSettings.Validate(); err != nil {
+			updateErr := r.updateTailoredProfileStatusError(tp, compliancev1alpha1.TailoredProfileConditionValidated, compliancev1alpha1.ReasonInvalidSpec,
+				fmt.Errorf("Invalid scanSettings: %s", err))
+			if updateErr != nil {
+				return false, updateErr
+			}
+			// don't return an error in the reconciler. The error will surface via the CR's status
+			return false, nil
+		}
+	}
+
 	return true, nil
 }
 
-func (r *ReconcileTailoredProfile) getRulesFromSelections(tp *compliancev1alpha1.TailoredProfile) (map[string]*compliancev1alpha1.Rule, bool, error) {
+func (r *ReconcileTailoredProfile) getRulesFromSelections(tp *compliancev1alpha1.TailoredProfile, pb *compliancev1alpha1.ProfileBundle) (map[string]*compliancev1alpha1.Rule, bool, error) {
 	rules := make(map[string]*compliancev1alpha1.Rule)
 	for _, selection := range append(tp.Spec.EnableRules, tp.Spec.DisableRules...) {
 		_, ok := rules[selection.Name]
@@ -220,7 +462,7 @@ func (r *ReconcileTailoredProfile) getRulesFromSelections(tp *compliancev1alpha1
 			return nil, false, fmt.Errorf("Rule '%s' appears twice in selections (enableRules or disableRules)", selection.Name)
 		}
 		rule := &compliancev1alpha1.Rule{}
-		ruleKey := types.NamespacedName{Name: selection.Name, Namespace: tp.Namespace}
+		ruleKey := types.NamespacedName{Name: selection.Name, Namespace: pb.Namespace}
 		err := r.client.Get(context.TODO(), ruleKey, rule)
 		if err != nil {
 			if errors.IsNotFound(err) {
@@ -233,11 +475,63 @@ func (r *ReconcileTailoredProfile) getRulesFromSelections(tp *compliancev1alpha1
 	return rules, false, nil
 }
 
-func (r *ReconcileTailoredProfile) getVariablesFromSelections(tp *compliancev1alpha1.TailoredProfile) ([]*compliancev1alpha1.Variable, bool, error) {
+// withRequiredRules returns copies of the TailoredProfile and the rules map
+// that also enable and hold the given required rules. Neither the
+// TailoredProfile nor the rules map are modified.
+func withRequiredRules(tp *compliancev1alpha1.TailoredProfile, rules map[string]*compliancev1alpha1.Rule, requiredRules []requiredRule) (*compliancev1alpha1.TailoredProfile, map[string]*compliancev1alpha1.Rule) {
+	if len(requiredRules) == 0 {
+		return tp, rules
+	}
+	tpCopy := tp.DeepCopy()
+	rulesCopy := make(map[string]*compliancev1alpha1.Rule, len(rules)+len(requiredRules))
+	for name, rule := range rules {
+		rulesCopy[name] = rule
+	}
+	for _, required := range requiredRules {
+		rulesCopy[required.rule.Name] = required.rule
+		tpCopy.Spec.EnableRules = append(tpCopy.Spec.EnableRules, compliancev1alpha1.RuleReferenceSpec{
+			Name:      required.rule.Name,
+			Rationale: fmt.Sprintf("Required by rule '%s'", required.requiredBy),
+		})
+	}
+	return tpCopy, rulesCopy
+}
+
+// withUnselectedRules returns a copy of the TailoredProfile that also disables
+// every rule of the bundle it doesn't enable. This is how TailoredProfiles
+// built from scratch make sure only the enabled rules are evaluated. The rules
+// map is updated accordingly.
+func (r *ReconcileTailoredProfile) withUnselectedRules(tp *compliancev1alpha1.TailoredProfile, pb *compliancev1alpha1.ProfileBundle, rules map[string]*compliancev1alpha1.Rule) (*compliancev1alpha1.TailoredProfile, error) {
+	ruleList := &compliancev1alpha1.RuleList{}
+	if err := r.client.List(context.TODO(), ruleList, client.InNamespace(pb.Namespace)); err != nil {
+		return nil, err
+	}
+
+	// Keep the output stable, the list isn't sorted
+	sort.Slice(ruleList.Items, func(i, j int) bool {
+		return ruleList.Items[i].Name < ruleList.Items[j].Name
+	})
+
+	tpCopy := tp.DeepCopy()
+	for i := range ruleList.Items {
+		rule := &ruleList.Items[i]
+		if _, selected := rules[rule.Name]; selected || !metav1.IsControlledBy(rule, pb) {
+			continue
+		}
+		rules[rule.Name] = rule
+		tpCopy.Spec.DisableRules = append(tpCopy.Spec.DisableRules, compliancev1alpha1.RuleReferenceSpec{
+			Name:      rule.Name,
+			Rationale: "Not enabled by a tailored profile built from scratch",
+		})
+	}
+	return tpCopy, nil
+}
+
+func (r *ReconcileTailoredProfile) getVariablesFromSelections(tp *compliancev1alpha1.TailoredProfile, pb *compliancev1alpha1.ProfileBundle) ([]*compliancev1alpha1.Variable, bool, error) {
 	variableList := []*compliancev1alpha1.Variable{}
 	for _, setValues := range tp.Spec.SetValues {
 		variable := &compliancev1alpha1.Variable{}
-		varKey := types.NamespacedName{Name: setValues.Name, Namespace: tp.Namespace}
+		varKey := types.NamespacedName{Name: setValues.Name, Namespace: pb.Namespace}
 		err := r.client.Get(context.TODO(), varKey, variable)
 		if err != nil {
 			if errors.IsNotFound(err) {
@@ -249,7 +543,7 @@ func (r *ReconcileTailoredProfile) getVariablesFromSelections(tp *compliancev1al
 		// try setting the variable, this also validates the value
 		err = variable.SetValue(setValues.Value)
 		if err != nil {
-			return nil, false, err
+			return nil, false, &invalidSpecError{fmt.Errorf("Invalid value for variable '%s': %s", setValues.Name, err)}
 		}
 
 		variableList = append(variableList, variable)
@@ -257,26 +551,152 @@ func (r *ReconcileTailoredProfile) getVariablesFromSelections(tp *compliancev1al
 	return variableList, false, nil
 }
 
-func (r *ReconcileTailoredProfile) updateTailoredProfileStatusReady(tp *compliancev1alpha1.TailoredProfile, out metav1.Object) error {
+// renderTailoring renders the tailoring XML of the given TailoredProfile, along
+// with its justification report, into the given ConfigMap. The previous
+// version is kept as long as the content of the tailoring stays the same;
+// otherwise the version is increased and stamped with the current time. The
+// resulting version and content hash are recorded in the result.
+func renderTailoring(cm *corev1.ConfigMap, tailored *compliancev1alpha1.TailoredProfile, previous tailoringVersion, p *compliancev1alpha1.Profile, pb *compliancev1alpha1.ProfileBundle,
+	rules map[string]*compliancev1alpha1.Rule, variables []*compliancev1alpha1.Variable, refinedVariables map[string]*compliancev1alpha1.Variable,
+	result *tailoringResult) error {
+	// Never update the original (update the copy)
+	versioned := tailored.DeepCopy()
+	versioned.Status.Version = previous.version
+	versioned.Status.VersionTime = previous.time
+
+	output, err := xccdf.TailoredProfileToXML(versioned, p, pb, rules, variables, refinedVariables)
+	if err != nil {
+		return err
+	}
+
+	contentHash := xccdf.GetTailoringContentHash(output)
+	if previous.version == 0 || previous.time == nil || contentHash != previous.contentHash {
+		now := metav1.NewTime(time.Now().Truncate(time.Second))
+		versioned.Status.Version = previous.version + 1
+		versioned.Status.VersionTime = &now
+		output, err = xccdf.TailoredProfileToXML(versioned, p, pb, rules, variables, refinedVariables)
+		if err != nil {
+			return err
+		}
+	}
+
+	cm.Data[tailoringFile] = output
+	cm.Data[justificationFile] = xccdf.TailoredProfileToJustificationReport(versioned, p, pb, rules, variables, refinedVariables)
+
+	result.contentHash = contentHash
+	result.version = versioned.Status.Version
+	result.versionTime = versioned.Status.VersionTime
+	return nil
+}
+
+// tailoringResult holds the outcome of processing a TailoredProfile, as
+// reflected in its status
+type tailoringResult struct {
+	contentHash          string
+	version              int64
+	versionTime          *metav1.Time
+	outputRevision       int64
+	placedClusters       int32
+	extendsChain         []string
+	resolvedEnableRules  []string
+	resolvedDisableRules []string
+	warnings             []string
+}
+
+func (r *ReconcileTailoredProfile) updateTailoredProfileStatusReady(tp *compliancev1alpha1.TailoredProfile, out runtime.Object, result *tailoringResult) error {
 	// Never update the original (update the copy)
 	tpCopy := tp.DeepCopy()
 	tpCopy.Status.State = compliancev1alpha1.TailoredProfileStateReady
-	tpCopy.Status.OutputRef = compliancev1alpha1.OutputRef{
-		Name:      out.GetName(),
-		Namespace: out.GetNamespace(),
+	tpCopy.Status.ErrorMessage = ""
+	tpCopy.Status.ObservedGeneration = tp.Generation
+	for _, condition := range getReadyConditions(tp.Generation) {
+		tpCopy.Status.SetCondition(condition)
 	}
+	tpCopy.Status.Warnings = result.warnings
+	tpCopy.Status.OutputRef = newOutputRef(out)
 	tpCopy.Status.ID = xccdf.GetXCCDFProfileID(tp)
+	tpCopy.Status.ContentHash = result.contentHash
+	tpCopy.Status.Version = result.version
+	tpCopy.Status.VersionTime = result.versionTime
+	tpCopy.Status.OutputRevision = result.outputRevision
+	tpCopy.Status.PlacedClusters = result.placedClusters
+	tpCopy.Status.ExtendsChain = result.extendsChain
+	tpCopy.Status.ResolvedEnableRules = result.resolvedEnableRules
+	tpCopy.Status.ResolvedDisableRules = result.resolvedDisableRules
 	return r.client.Status().Update(context.TODO(), tpCopy)
 }
 
-func (r *ReconcileTailoredProfile) updateTailoredProfileStatusError(tp *compliancev1alpha1.TailoredProfile, err error) error {
+// newOutputRef creates the reference to the output object recorded in the
+// status. The output objects are built with their kind set, so it's taken
+// from them.
+func newOutputRef(out runtime.Object) compliancev1alpha1.OutputRef {
+	apiVersion, kind := out.GetObjectKind().GroupVersionKind().ToAPIVersionAndKind()
+	meta := out.(metav1.Object)
+	return compliancev1alpha1.OutputRef{
+		APIVersion: apiVersion,
+		Kind:       kind,
+		Name:       meta.GetName(),
+		Namespace:  meta.GetNamespace(),
+	}
+}
+
+// isTailoredProfileStatusReady tells whether the status already reflects the
+// given output object and result. This avoids needless status updates, which
+// would trigger yet another reconcile.
+func isTailoredProfileStatusReady(tp *compliancev1alpha1.TailoredProfile, out runtime.Object, result *tailoringResult) bool {
+	for _, condition := range getReadyConditions(tp.Generation) {
+		if !tp.Status.HasCondition(condition) {
+			return false
+		}
+	}
+	return tp.Status.State == compliancev1alpha1.TailoredProfileStateReady &&
+		tp.Status.ErrorMessage == "" &&
+		tp.Status.ObservedGeneration == tp.Generation &&
+		reflect.DeepEqual(tp.Status.Warnings, result.warnings) &&
+		tp.Status.OutputRef == newOutputRef(out) &&
+		tp.Status.ID == xccdf.GetXCCDFProfileID(tp) &&
+		tp.Status.ContentHash == result.contentHash &&
+		tp.Status.Version == result.version &&
+		equality.Semantic.DeepEqual(tp.Status.VersionTime, result.versionTime) &&
+		tp.Status.OutputRevision == result.outputRevision &&
+		tp.Status.PlacedClusters == result.placedClusters &&
+		reflect.DeepEqual(tp.Status.ExtendsChain, result.extendsChain) &&
+		reflect.DeepEqual(tp.Status.ResolvedEnableRules, result.resolvedEnableRules) &&
+		reflect.DeepEqual(tp.Status.ResolvedDisableRules, result.resolvedDisableRules)
+}
+
+// updateTailoredProfileStatusError surfaces the error in the status of the
+// TailoredProfile. The condition of the step that failed is set to False with
+// the given reason.
+func (r *ReconcileTailoredProfile) updateTailoredProfileStatusError(tp *compliancev1alpha1.TailoredProfile,
+	failed compliancev1alpha1.TailoredProfileConditionType, reason string, err error) error {
 	// Never update the original (update the copy)
 	tpCopy := tp.DeepCopy()
 	tpCopy.Status.State = compliancev1alpha1.TailoredProfileStateError
 	tpCopy.Status.ErrorMessage = err.Error()
+	tpCopy.Status.ObservedGeneration = tp.Generation
+	// The warnings belong to a tailoring that can't be generated anymore.
+	// The output is kept referenced though, as it's still around until it
+	// gets replaced or removed.
+	tpCopy.Status.Warnings = nil
+	for _, condition := range getErrorConditions(tp.Generation, failed, reason, err) {
+		tpCopy.Status.SetCondition(condition)
+	}
 	return r.client.Status().Update(context.TODO(), tpCopy)
 }
 
+// handleResolutionError handles an error found while resolving the content the
+// TailoredProfile references. Errors that retrying won't fix are surfaced in
+// its status without requeueing, while the others requeue.
+func (r *ReconcileTailoredProfile) handleResolutionError(tp *compliancev1alpha1.TailoredProfile, retriable bool, err error) (reconcile.Result, error) {
+	if retriable {
+		return reconcile.Result{}, err
+	}
+	// An error updating the status requeues
+	condType, reason := getResolutionErrorCondition(err)
+	return reconcile.Result{}, r.updateTailoredProfileStatusError(tp, condType, reason, err)
+}
+
 func (r *ReconcileTailoredProfile) getProfileBundleFromProfile(p *compliancev1alpha1.Profile) (*compliancev1alpha1.ProfileBundle, error) {
 	pbRef, err := getProfileBundleReferenceFromProfile(p)
 	if err != nil {
@@ -290,149 +710,220 @@ func (r *ReconcileTailoredProfile) getProfileBundleFromProfile(p *compliancev1al
 	return &pb, err
 }
 
-func (r *ReconcileTailoredProfile) ensureOutputObject(tp *compliancev1alpha1.TailoredProfile, cm *corev1.ConfigMap, pb *compliancev1alpha1.ProfileBundle, logger logr.Logger) (reconcile.Result, error) {
+func (r *ReconcileTailoredProfile) ensureOutputObject(tp *compliancev1alpha1.TailoredProfile, cm *corev1.ConfigMap, p *compliancev1alpha1.Profile, pb *compliancev1alpha1.ProfileBundle, result *tailoringResult, logger logr.Logger) (reconcile.Result, error) {
+	if err := r.removeStaleObjects(tp, logger); err != nil {
+		return reconcile.Result{}, err
+	}
+	if err := r.removeStaleComplianceSuite(tp, logger); err != nil {
+		return reconcile.Result{}, err
+	}
+	// This update will trigger a requeue
+	if updated, err := r.removeStalePolicy(tp, logger); updated || err != nil {
+		return reconcile.Result{}, err
+	}
+	// This update will trigger a requeue
+	if updated, err := r.removeStaleOutputRef(tp); updated || err != nil {
+		return reconcile.Result{}, err
+	}
+
 	switch tp.Spec.OutputType {
 	case compliancev1alpha1.ConfigMapOutput:
-		return r.ensureConfigMapOutputObject(tp, cm, logger)
+		return r.ensureConfigMapOutputObject(tp, cm, result, logger)
+	case compliancev1alpha1.SecretOutput:
+		return r.ensureSecretOutputObject(tp, cm, result, logger)
 	case compliancev1alpha1.PolicyOutput:
-		return r.ensurePolicyOutputObject(tp, cm, pb, logger)
+		return r.ensurePolicyOutputObject(tp, cm, p, pb, result, logger)
+	case compliancev1alpha1.ComplianceSuiteOutput:
+		return r.ensureComplianceSuiteOutputObject(tp, cm, pb, result, logger)
 	default:
 		logger.Info("WARNING: unkown output type. We shouldn't get here as this should have been validated already")
 	}
 	return reconcile.Result{}, nil
 }
 
-func (r *ReconcileTailoredProfile) ensureConfigMapOutputObject(tp *compliancev1alpha1.TailoredProfile, tpcm *corev1.ConfigMap, logger logr.Logger) (reconcile.Result, error) {
+// removeStaleOutputRef drops the reference to the output of another output
+// type from the status, once that output was removed. It tells whether the
+// status was updated.
+func (r *ReconcileTailoredProfile) removeStaleOutputRef(tp *compliancev1alpha1.TailoredProfile) (bool, error) {
+	// Statuses that don't record the kind of their output can't tell
+	kind := tp.Status.OutputRef.Kind
+	if kind == "" || kind == string(tp.Spec.OutputType) {
+		return false, nil
+	}
+	tpCopy := tp.DeepCopy()
+	tpCopy.Status.OutputRef = compliancev1alpha1.OutputRef{}
+	return true, r.client.Status().Update(context.TODO(), tpCopy)
+}
+
+func (r *ReconcileTailoredProfile) ensureConfigMapOutputObject(tp *compliancev1alpha1.TailoredProfile, tpcm *corev1.ConfigMap, result *tailoringResult, logger logr.Logger) (reconcile.Result, error) {
+	if err := r.ensureTailoringConfigMap(tp, tpcm, logger); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if !isTailoredProfileStatusReady(tp, tpcm, result) {
+		err := r.updateTailoredProfileStatusReady(tp, tpcm, result)
+		if err != nil {
+			logger.Error(err, "Couldn't update TailoredProfile status")
+			return reconcile.Result{}, err
+		}
+	}
+
+	// ConfigMap is up to date - don't requeue
+	return reconcile.Result{}, nil
+}
+
+// ensureTailoringConfigMap creates the ConfigMap holding the tailoring, or
+// updates the existing one if its content differs
+func (r *ReconcileTailoredProfile) ensureTailoringConfigMap(tp *compliancev1alpha1.TailoredProfile, tpcm *corev1.ConfigMap, logger logr.Logger) error {
 	// Set TailoredProfile instance as the owner and controller
 	if err := controllerutil.SetControllerReference(tp, tpcm, r.scheme); err != nil {
-		return reconcile.Result{}, err
+		return err
 	}
 
 	// Check if this ConfigMap already exists
 	found := &corev1.ConfigMap{}
 	err := r.client.Get(context.TODO(), types.NamespacedName{Name: tpcm.Name, Namespace: tpcm.Namespace}, found)
 	if err != nil && errors.IsNotFound(err) {
-		// update status
-		err = r.updateTailoredProfileStatusReady(tp, tpcm)
-		if err != nil {
-			fmt.Printf("Couldn't update TailoredProfile status: %v\n", err)
-			return reconcile.Result{}, err
-		}
-
-		// create CM
 		logger.Info("Creating a new ConfigMap", "ConfigMap.Namespace", tpcm.Namespace, "ConfigMap.Name", tpcm.Name)
-		err = r.client.Create(context.TODO(), tpcm)
-		if err != nil {
-			return reconcile.Result{}, err
-		}
-
-		// ConfigMap created successfully - don't requeue
-		return reconcile.Result{}, nil
+		return r.client.Create(context.TODO(), tpcm)
 	} else if err != nil {
-		return reconcile.Result{}, err
+		return err
 	}
 
-	// ConfigMap already exists - don't requeue
-	logger.Info("Skip reconcile: ConfigMap already exists", "ConfigMap.Namespace", found.Namespace, "ConfigMap.Name", found.Name)
-	return reconcile.Result{}, nil
+	// The tailoring changed, or somebody modified the ConfigMap. Either way,
+	// its content needs to match the TailoredProfile. ConfigMaps created by
+	// older versions lack the TailoredProfile label, so it's added as well.
+	if !reflect.DeepEqual(found.Data, tpcm.Data) || !hasTailoredProfileLabel(tp, found) {
+		logger.Info("Updating the ConfigMap's tailoring", "ConfigMap.Namespace", found.Namespace, "ConfigMap.Name", found.Name)
+		foundCopy := found.DeepCopy()
+		foundCopy.Data = tpcm.Data
+		setTailoredProfileLabel(tp, foundCopy)
+		return r.client.Update(context.TODO(), foundCopy)
+	}
+	return nil
+}
+
+// hasTailoredProfileLabel tells whether the object is labeled with the name
+// of the TailoredProfile
+func hasTailoredProfileLabel(tp *compliancev1alpha1.TailoredProfile, obj metav1.Object) bool {
+	return obj.GetLabels()[compliancev1alpha1.TailoredProfileLabel] == tp.Name
 }
 
-func (r *ReconcileTailoredProfile) ensurePolicyOutputObject(tp *compliancev1alpha1.TailoredProfile, tpcm *corev1.ConfigMap, pb *compliancev1alpha1.ProfileBundle, logger logr.Logger) (reconcile.Result, error) {
+// setTailoredProfileLabel labels the object with the name of the
+// TailoredProfile, keeping its other labels
+func setTailoredProfileLabel(tp *compliancev1alpha1.TailoredProfile, obj metav1.Object) {
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[compliancev1alpha1.TailoredProfileLabel] = tp.Name
+	obj.SetLabels(labels)
+}
+
+func (r *ReconcileTailoredProfile) ensurePolicyOutputObject(tp *compliancev1alpha1.TailoredProfile, tpcm *corev1.ConfigMap, p *compliancev1alpha1.Profile, pb *compliancev1alpha1.ProfileBundle, result *tailoringResult, logger logr.Logger) (reconcile.Result, error) {
+	// The options were validated along with the rest of the spec
+	options := tp.Spec.PolicyOptions.WithDefaults()
+
+	apiVersion := common.GetPolicyAPIVersion()
+	if err := policy.ValidateAPIVersion(apiVersion); err != nil {
+		updateErr := r.updateTailoredProfileStatusError(tp, compliancev1alpha1.TailoredProfileConditionOutputReady, compliancev1alpha1.ReasonOutputFailed, err)
+		return reconcile.Result{}, updateErr
+	}
+
 	objKey := types.NamespacedName{Name: tp.GetName(), Namespace: tp.GetNamespace()}
 	// reset namespace
 	tpcm.SetNamespace("")
-	cmUnstructured, err := runtime.DefaultUnstructuredConverter.ToUnstructured(tpcm)
+	cmUnstructured, err := utils.ToUnstructured(tpcm)
 	if err != nil {
 		updateErr := r.updateTailoredProfileStatusError(
-			tp,
+			tp, compliancev1alpha1.TailoredProfileConditionOutputReady, compliancev1alpha1.ReasonOutputFailed,
 			fmt.Errorf("Couldn't convert ConfigMap to Unstructured: %s", err),
 		)
 		return reconcile.Result{}, updateErr
 	}
 
-	// TODO(jaosorior): Import library and use actual object instead of unstructured
-	suiteObj := map[string]interface{}{
-		"apiVersion": "compliance.openshift.io/v1alpha1",
-		"kind":       "ComplianceSuite",
-		"metadata": map[string]interface{}{
-			"name": objKey.Name,
-		},
-		"spec": map[string]interface{}{
-			"schedule":              "0 1 * * *",
-			"autoApplyRemediations": false,
-			"scans": []interface{}{
-				map[string]interface{}{
-					"name":         objKey.Name + "-worker-scan",
-					"profile":      xccdf.GetXCCDFProfileID(tp),
-					"content":      pb.Spec.ContentFile,
-					"contentImage": pb.Spec.ContentImage,
-					"nodeSelector": map[string]interface{}{
-						"node-role.kubernetes.io/worker": "",
-					},
-					"tailoringConfigMap": map[string]interface{}{
-						"name": tpcm.GetName(),
-					},
-				},
-			},
-		},
+	suite := compliancesuite.NewComplianceSuite(objKey.Name, "", &options.ScanSettings, xccdf.GetXCCDFProfileID(tp), pb, tpcm.GetName())
+	suiteObj, err := suite.ToUnstructured()
+	if err != nil {
+		return reconcile.Result{}, err
 	}
 
-	// TODO(jaosorior): Import library and use actual object instead of unstructured
-	policyObj := &unstructured.Unstructured{
-		Object: map[string]interface{}{
-			"apiVersion": "policy.mcm.ibm.com/v1alpha1",
-			"kind":       "Policy",
-			"metadata": map[string]interface{}{
-				"name":      objKey.Name,
-				"namespace": objKey.Namespace,
-			},
-			"spec": map[string]interface{}{
-				"disabled":          false,
-				"remediationAction": "inform",
-				"namespaces": map[string]interface{}{
-					"exclude": []interface{}{"kube-*"},
-					"include": []interface{}{"default"},
-				},
-				"policy-templates": []interface{}{
-					map[string]interface{}{
-						"objectDefinition": cmUnstructured,
-					},
-					map[string]interface{}{
-						"objectDefinition": suiteObj,
-					},
-				},
-			},
-		},
+	selectedRules, err := r.getSelectedRules(p, pb, result)
+	if err != nil {
+		return reconcile.Result{}, err
 	}
-	// Check if this ConfigMap already exists
-	found := policyObj.DeepCopy()
+	annotations := policy.GetAnnotations(apiVersion, selectedRules)
+	// The Policy isn't owned by the TailoredProfile, it's removed by its
+	// finalizer instead
+	annotations[compliancev1alpha1.GeneratedByAnnotation] = getGeneratedBy(tp)
+
+	typedPolicy, err := policy.NewPolicy(apiVersion, objKey.Name, objKey.Namespace, options, annotations, cmUnstructured.Object, suiteObj.Object)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	policyObj, err := utils.ToUnstructured(typedPolicy)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	// Check if this Policy already exists
+	found := &unstructured.Unstructured{}
+	found.SetGroupVersionKind(policyObj.GroupVersionKind())
 	err = r.client.Get(context.TODO(), objKey, found)
 	if err != nil && errors.IsNotFound(err) {
-		// update status
-		err = r.updateTailoredProfileStatusReady(tp, tpcm)
-		if err != nil {
-			logger.Error(err, "Couldn't update TailoredProfile status")
-			return reconcile.Result{}, err
-		}
-
 		// create Policy
 		logger.Info("Creating a new Policy", "Policy.Namespace", objKey.Namespace, "Policy.Name", objKey.Name)
 		err = r.client.Create(context.TODO(), policyObj)
 		if err != nil {
 			return reconcile.Result{}, err
 		}
-
-		// Policy created successfully - don't requeue
-		return reconcile.Result{}, nil
 	} else if err != nil {
 		return reconcile.Result{}, err
+	} else if !isSameJSON(found.Object["spec"], policyObj.Object["spec"]) || !hasAnnotations(found.GetAnnotations(), annotations, apiVersion) {
+		// The policy options, the tailoring or the selected rules
+		// changed, or somebody modified the Policy. Either way, it needs
+		// to match the TailoredProfile.
+		logger.Info("Updating the Policy", "Policy.Namespace", found.GetNamespace(), "Policy.Name", found.GetName())
+		foundCopy := found.DeepCopy()
+		foundCopy.Object["spec"] = policyObj.Object["spec"]
+		foundCopy.SetAnnotations(withAnnotations(found.GetAnnotations(), annotations, apiVersion))
+		err = r.client.Update(context.TODO(), foundCopy)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	result.placedClusters, err = r.ensurePlacement(tp, options.ClusterSelector, policyObj, logger)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if !isTailoredProfileStatusReady(tp, policyObj, result) {
+		err = r.updateTailoredProfileStatusReady(tp, policyObj, result)
+		if err != nil {
+			logger.Error(err, "Couldn't update TailoredProfile status")
+			return reconcile.Result{}, err
+		}
 	}
 
-	// Policy already exists - don't requeue
-	logger.Info("Skip reconcile: Policy already exists", "Policy.Namespace", found.GetNamespace(), "Policy.Name", found.GetName())
+	// Policy is up to date - don't requeue
 	return reconcile.Result{}, nil
 }
 
+// isSameJSON tells whether both values serialize to the same JSON. This makes
+// up for the different types unstructured content may use for a same value.
+func isSameJSON(a, b interface{}) bool {
+	aJSON, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	bJSON, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(aJSON, bJSON)
+}
+
 func getProfileBundleReferenceFromProfile(p *compliancev1alpha1.Profile) (*metav1.OwnerReference, error) {
 	for _, ref := range p.GetOwnerReferences() {
 		if ref.Kind == "ProfileBundle" && ref.APIVersion == compliancev1alpha1.SchemeGroupVersion.String() {
@@ -445,7 +936,7 @@ func getProfileBundleReferenceFromProfile(p *compliancev1alpha1.Profile) (*metav
 // newTailoredProfileCM creates a tailored profile XML inside a configmap
 func newTailoredProfileCM(tp *compliancev1alpha1.TailoredProfile) *corev1.ConfigMap {
 	labels := map[string]string{
-		"tailored-profile": tp.Name,
+		compliancev1alpha1.TailoredProfileLabel: tp.Name,
 	}
 	return &corev1.ConfigMap{
 		TypeMeta: metav1.TypeMeta{
@@ -0,0 +1,123 @@
+package tailoredprofile
+
+import (
+	"context"
+	"errors"
+
+	compliancev1alpha1 "github.com/JAORMX/compliance-profile-operator/pkg/apis/compliance/v1alpha1"
+	"k8s.io/apimachinery/pkg/types"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Testing the validation of TailoredProfiles", func() {
+	var tp *compliancev1alpha1.TailoredProfile
+	var r *ReconcileTailoredProfile
+
+	BeforeEach(func() {
+		tp = newTestTailoredProfile("tp")
+		tp.Spec.Extends = "ocp4-moderate"
+		tp.Spec.OutputType = compliancev1alpha1.ConfigMapOutput
+	})
+
+	validate := func() (bool, *compliancev1alpha1.TailoredProfile) {
+		r = newTestReconciler(tp)
+		valid, err := r.validateTailoredProfile(tp)
+		Expect(err).ToNot(HaveOccurred())
+		found := &compliancev1alpha1.TailoredProfile{}
+		Expect(r.client.Get(context.TODO(), types.NamespacedName{Name: "tp", Namespace: "test"}, found)).To(Succeed())
+		return valid, found
+	}
+
+	It("accepts unset options", func() {
+		valid, _ := validate()
+		Expect(valid).To(BeTrue())
+	})
+
+	table.DescribeTable("rejecting invalid options before writing any output",
+		func(policyOptions *compliancev1alpha1.PolicyOptions, scanSettings *compliancev1alpha1.ScanSettings, expectedErr string) {
+			tp.Spec.PolicyOptions = policyOptions
+			tp.Spec.ScanSettings = scanSettings
+			valid, found := validate()
+			Expect(valid).To(BeFalse())
+			Expect(found.Status.State).To(Equal(compliancev1alpha1.TailoredProfileStateError))
+			Expect(found.Status.ErrorMessage).To(Equal(expectedErr))
+			Expect(found.Status.GetCondition(compliancev1alpha1.TailoredProfileConditionValidated).Reason).To(Equal(compliancev1alpha1.ReasonInvalidSpec))
+		},
+		table.Entry("invalid policyOptions",
+			&compliancev1alpha1.PolicyOptions{RemediationAction: "ignore"}, nil,
+			"Invalid policyOptions: remediationAction ignore is invalid (accepted values: inform, enforce)"),
+		table.Entry("invalid scans of the policyOptions",
+			&compliancev1alpha1.PolicyOptions{ScanSettings: compliancev1alpha1.ScanSettings{Scans: []compliancev1alpha1.ScanSpec{{Role: compliancev1alpha1.ScanRoleCustom}}}}, nil,
+			"Invalid policyOptions: custom scans need a name"),
+		table.Entry("invalid scanSettings",
+			nil, &compliancev1alpha1.ScanSettings{Schedule: "daily"},
+			"Invalid scanSettings: schedule daily isn't in cron format"),
+	)
+
+	It("rejects options along with the Secret output", func() {
+		tp.Spec.OutputType = compliancev1alpha1.SecretOutput
+		tp.Spec.ScanSettings = &compliancev1alpha1.ScanSettings{}
+		valid, found := validate()
+		Expect(valid).To(BeFalse())
+		Expect(found.Status.ErrorMessage).To(Equal(".spec.policyOptions and .spec.scanSettings can't be used with the Secret output type, as ComplianceSuites and Policies only take tailorings from ConfigMaps"))
+		Expect(found.Status.GetCondition(compliancev1alpha1.TailoredProfileConditionValidated).Reason).To(Equal(compliancev1alpha1.ReasonInvalidSpec))
+	})
+})
+
+var _ = Describe("Testing the error status", func() {
+	It("drops the warnings but keeps the output of the previous tailoring", func() {
+		tp := newTestTailoredProfile("tp")
+		tp.Generation = 2
+		tp.Status.State = compliancev1alpha1.TailoredProfileStateReady
+		tp.Status.Warnings = []string{"Rule 'ocp4-audit' is already enabled in the extended profile"}
+		tp.Status.OutputRef = compliancev1alpha1.OutputRef{Kind: "ConfigMap", Name: "tp-tp", Namespace: "test"}
+		r := newTestReconciler(tp)
+
+		err := r.updateTailoredProfileStatusError(tp, compliancev1alpha1.TailoredProfileConditionOutputReady,
+			compliancev1alpha1.ReasonOutputFailed, errors.New("some error"))
+		Expect(err).ToNot(HaveOccurred())
+
+		found := &compliancev1alpha1.TailoredProfile{}
+		Expect(r.client.Get(context.TODO(), types.NamespacedName{Name: "tp", Namespace: "test"}, found)).To(Succeed())
+		Expect(found.Status.State).To(Equal(compliancev1alpha1.TailoredProfileStateError))
+		Expect(found.Status.ErrorMessage).To(Equal("some error"))
+		Expect(found.Status.ObservedGeneration).To(Equal(int64(2)))
+		Expect(found.Status.Warnings).To(BeEmpty())
+		Expect(found.Status.OutputRef).To(Equal(tp.Status.OutputRef))
+	})
+})
+
+var _ = Describe("Testing the output reference", func() {
+	var tp *compliancev1alpha1.TailoredProfile
+
+	BeforeEach(func() {
+		tp = newTestTailoredProfile("tp")
+		tp.Spec.OutputType = compliancev1alpha1.ConfigMapOutput
+	})
+
+	removeStaleOutputRef := func() (bool, *compliancev1alpha1.TailoredProfile) {
+		r := newTestReconciler(tp)
+		updated, err := r.removeStaleOutputRef(tp)
+		Expect(err).ToNot(HaveOccurred())
+		found := &compliancev1alpha1.TailoredProfile{}
+		Expect(r.client.Get(context.TODO(), types.NamespacedName{Name: "tp", Namespace: "test"}, found)).To(Succeed())
+		return updated, found
+	}
+
+	It("is dropped once the output of another output type was removed", func() {
+		tp.Status.OutputRef = compliancev1alpha1.OutputRef{Kind: "Policy", Name: "tp", Namespace: "test"}
+		updated, found := removeStaleOutputRef()
+		Expect(updated).To(BeTrue())
+		Expect(found.Status.OutputRef).To(Equal(compliancev1alpha1.OutputRef{}))
+	})
+
+	It("is kept for the current output type", func() {
+		tp.Status.OutputRef = compliancev1alpha1.OutputRef{Kind: "ConfigMap", Name: "tp-tp", Namespace: "test"}
+		updated, found := removeStaleOutputRef()
+		Expect(updated).To(BeFalse())
+		Expect(found.Status.OutputRef).To(Equal(tp.Status.OutputRef))
+	})
+})
@@ -0,0 +1,52 @@
+package tailoredprofile
+
+import (
+	"context"
+	"testing"
+
+	"github.com/JAORMX/compliance-profile-operator/pkg/apis"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestTailoredProfile(t *testing.T) {
+	RegisterFailHandler(Fail)
+	if err := apis.AddToScheme(scheme.Scheme); err != nil {
+		t.Fatal(err)
+	}
+	RunSpecs(t, "tailoredprofile Suite")
+}
+
+// testClient wraps the fake client, which can't read objects created as
+// Unstructured back into typed ones. Unstructured objects of known types are
+// converted before being created.
+type testClient struct {
+	client.Client
+}
+
+func (c *testClient) Create(ctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return c.Client.Create(ctx, obj, opts...)
+	}
+	typed, err := scheme.Scheme.New(u.GroupVersionKind())
+	if err != nil {
+		// Not a type of the scheme, e.g. a Policy
+		return c.Client.Create(ctx, obj, opts...)
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, typed); err != nil {
+		return err
+	}
+	return c.Client.Create(ctx, typed, opts...)
+}
+
+func newTestReconciler(objs ...runtime.Object) *ReconcileTailoredProfile {
+	c := &testClient{fake.NewFakeClientWithScheme(scheme.Scheme, objs...)}
+	return &ReconcileTailoredProfile{client: c, secretReader: c, scheme: scheme.Scheme}
+}
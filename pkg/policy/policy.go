@@ -0,0 +1,202 @@
+package policy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	compliancev1alpha1 "github.com/JAORMX/compliance-profile-operator/pkg/apis/compliance/v1alpha1"
+	"github.com/JAORMX/compliance-profile-operator/pkg/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// The annotations of a Policy are prefixed with the group of its API
+	standardsAnnotation  = "standards"
+	categoriesAnnotation = "categories"
+	controlsAnnotation   = "controls"
+
+	nistStandard = "NIST-800-53"
+)
+
+// nistFamilies maps the NIST-800-53 control families to the categories
+// Policies are annotated with
+var nistFamilies = map[string]string{
+	"AC": "AC Access Control",
+	"AT": "AT Awareness and Training",
+	"AU": "AU Audit and Accountability",
+	"CA": "CA Security Assessment and Authorization",
+	"CM": "CM Configuration Management",
+	"CP": "CP Contingency Planning",
+	"IA": "IA Identification and Authentication",
+	"IR": "IR Incident Response",
+	"MA": "MA Maintenance",
+	"MP": "MP Media Protection",
+	"PE": "PE Physical and Environmental Protection",
+	"PL": "PL Planning",
+	"PM": "PM Program Management",
+	"PS": "PS Personnel Security",
+	"RA": "RA Risk Assessment",
+	"SA": "SA System and Services Acquisition",
+	"SC": "SC System and Communications Protection",
+	"SI": "SI System and Information Integrity",
+}
+
+// ValidateAPIVersion checks that Policies can be generated for the given API
+// version
+func ValidateAPIVersion(apiVersion string) error {
+	switch apiVersion {
+	case APIVersionV1, APIVersionMCMV1alpha1:
+		return nil
+	}
+	return fmt.Errorf("Unknown policy API version '%s' (accepted values: %s, %s)", apiVersion, APIVersionV1, APIVersionMCMV1alpha1)
+}
+
+// GetPlacementRuleAPIVersion gets the API version of the PlacementRules
+// placing the Policies of the given API version
+func GetPlacementRuleAPIVersion(apiVersion string) string {
+	if apiVersion == APIVersionMCMV1alpha1 {
+		return APIVersionMCMV1alpha1
+	}
+	return PlacementRuleAPIVersionV1
+}
+
+// GetGroup gets the API group of the given API version
+func GetGroup(apiVersion string) string {
+	gv, err := schema.ParseGroupVersion(apiVersion)
+	if err != nil {
+		return ""
+	}
+	return gv.Group
+}
+
+// NewPolicy creates a Policy distributing the given objects according to the
+// policy options. With the current API, every object is wrapped in a
+// ConfigurationPolicy enforcing it in the selected namespaces. The
+// policy.mcm.ibm.com API holds the objects directly, and selects the
+// namespaces in the Policy itself.
+func NewPolicy(apiVersion, name, namespace string, options *compliancev1alpha1.PolicyOptions, annotations map[string]string, objects ...map[string]interface{}) (*Policy, error) {
+	namespaces := NamespaceSelector{
+		Include: options.Namespaces.Include,
+		Exclude: options.Namespaces.Exclude,
+	}
+	policy := &Policy{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: apiVersion,
+			Kind:       "Policy",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   namespace,
+			Annotations: annotations,
+		},
+		Spec: PolicySpec{
+			Disabled:          false,
+			RemediationAction: string(options.RemediationAction),
+			PolicyTemplates:   []PolicyTemplate{},
+		},
+	}
+
+	if apiVersion == APIVersionMCMV1alpha1 {
+		policy.Spec.Namespaces = &namespaces
+		for _, obj := range objects {
+			policy.Spec.PolicyTemplates = append(policy.Spec.PolicyTemplates, PolicyTemplate{ObjectDefinition: obj})
+		}
+		return policy, nil
+	}
+
+	for _, obj := range objects {
+		kind, _, _ := unstructured.NestedString(obj, "kind")
+		configPolicy := &ConfigurationPolicy{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: APIVersionV1,
+				Kind:       "ConfigurationPolicy",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name + "-" + strings.ToLower(kind),
+			},
+			Spec: ConfigurationPolicySpec{
+				RemediationAction: string(options.RemediationAction),
+				NamespaceSelector: namespaces,
+				ObjectTemplates: []ObjectTemplate{
+					{
+						ComplianceType:   MustHave,
+						ObjectDefinition: obj,
+					},
+				},
+			},
+		}
+		definition, err := utils.ToUnstructured(configPolicy)
+		if err != nil {
+			return nil, err
+		}
+		policy.Spec.PolicyTemplates = append(policy.Spec.PolicyTemplates, PolicyTemplate{ObjectDefinition: definition.Object})
+	}
+	return policy, nil
+}
+
+// GetAnnotations aggregates the standards, categories and controls the given
+// rules are mapped to into the annotations of a Policy of the given API
+// version. The categories are the families of the NIST-800-53 controls, which
+// are told apart from the controls of other standards by their per-standard
+// annotation.
+func GetAnnotations(apiVersion string, rules []*compliancev1alpha1.Rule) map[string]string {
+	standards := map[string]bool{}
+	categories := map[string]bool{}
+	controls := map[string]bool{}
+	for _, rule := range rules {
+		for _, std := range splitAnnotation(rule.Annotations[compliancev1alpha1.RuleStandardsAnnotationKey], ",") {
+			standards[std] = true
+		}
+		for _, ctrl := range splitAnnotation(rule.Annotations[compliancev1alpha1.RuleControlsAnnotationKey], ",") {
+			controls[ctrl] = true
+		}
+		for _, ctrl := range splitAnnotation(rule.Annotations[compliancev1alpha1.ControlAnnotationBase+nistStandard], ";") {
+			if category, ok := nistFamilies[strings.SplitN(ctrl, "-", 2)[0]]; ok {
+				categories[category] = true
+			}
+		}
+	}
+
+	annotations := map[string]string{}
+	keys := GetAnnotationKeys(apiVersion)
+	for i, values := range []map[string]bool{standards, categories, controls} {
+		if len(values) == 0 {
+			continue
+		}
+		annotations[keys[i]] = joinAnnotation(values)
+	}
+	return annotations
+}
+
+// GetAnnotationKeys gets the keys of the standards, categories and controls
+// annotations of a Policy of the given API version
+func GetAnnotationKeys(apiVersion string) []string {
+	group := GetGroup(apiVersion)
+	return []string{
+		group + "/" + standardsAnnotation,
+		group + "/" + categoriesAnnotation,
+		group + "/" + controlsAnnotation,
+	}
+}
+
+func splitAnnotation(value, sep string) []string {
+	var items []string
+	for _, item := range strings.Split(value, sep) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func joinAnnotation(values map[string]bool) string {
+	items := make([]string, 0, len(values))
+	for value := range values {
+		items = append(items, value)
+	}
+	sort.Strings(items)
+	return strings.Join(items, ", ")
+}
@@ -0,0 +1,148 @@
+package policy
+
+import (
+	"strings"
+
+	compliancev1alpha1 "github.com/JAORMX/compliance-profile-operator/pkg/apis/compliance/v1alpha1"
+	"github.com/JAORMX/compliance-profile-operator/pkg/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// newRule creates a rule annotated the way the profile parser does, with the
+// controls of the given standards
+func newRule(name string, controlsByStandard map[string][]string) *compliancev1alpha1.Rule {
+	annotations := map[string]string{}
+	var standards, controls []string
+	for std, stdControls := range controlsByStandard {
+		standards = append(standards, std)
+		controls = append(controls, stdControls...)
+		annotations[compliancev1alpha1.ControlAnnotationBase+std] = strings.Join(stdControls, ";")
+	}
+	annotations[compliancev1alpha1.RuleStandardsAnnotationKey] = strings.Join(standards, ",")
+	annotations[compliancev1alpha1.RuleControlsAnnotationKey] = strings.Join(controls, ",")
+	return &compliancev1alpha1.Rule{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Annotations: annotations},
+	}
+}
+
+var _ = Describe("Testing policy API versions", func() {
+	It("accepts the known API versions", func() {
+		Expect(ValidateAPIVersion(APIVersionV1)).To(Succeed())
+		Expect(ValidateAPIVersion(APIVersionMCMV1alpha1)).To(Succeed())
+		Expect(ValidateAPIVersion("policy.example.com/v1")).NotTo(Succeed())
+	})
+})
+
+var _ = Describe("Testing policy annotations", func() {
+	var rules []*compliancev1alpha1.Rule
+
+	BeforeEach(func() {
+		rules = []*compliancev1alpha1.Rule{
+			newRule("ocp4-password-min-len", map[string][]string{"NIST-800-53": {"IA-5(f)", "IA-5(1)(a)", "CM-6(a)"}}),
+			newRule("ocp4-audit-log-forwarding", map[string][]string{"NIST-800-53": {"AU-4(1)", "CM-6(a)"}}),
+			{ObjectMeta: metav1.ObjectMeta{Name: "ocp4-unmapped"}},
+		}
+	})
+
+	It("aggregates the standards, categories and controls of the rules", func() {
+		annotations := GetAnnotations(APIVersionV1, rules)
+		Expect(annotations).To(HaveKeyWithValue("policy.open-cluster-management.io/standards", "NIST-800-53"))
+		Expect(annotations).To(HaveKeyWithValue("policy.open-cluster-management.io/categories",
+			"AU Audit and Accountability, CM Configuration Management, IA Identification and Authentication"))
+		Expect(annotations).To(HaveKeyWithValue("policy.open-cluster-management.io/controls",
+			"AU-4(1), CM-6(a), IA-5(1)(a), IA-5(f)"))
+	})
+
+	It("uses the group of the API version", func() {
+		annotations := GetAnnotations(APIVersionMCMV1alpha1, rules)
+		Expect(annotations).To(HaveKey("policy.mcm.ibm.com/standards"))
+		Expect(annotations).NotTo(HaveKey("policy.open-cluster-management.io/standards"))
+	})
+
+	It("only derives categories from NIST-800-53 controls", func() {
+		annotations := GetAnnotations(APIVersionV1, []*compliancev1alpha1.Rule{newRule("ocp4-other", map[string][]string{"PCI-DSS": {"AC-1"}})})
+		Expect(annotations).To(HaveKeyWithValue("policy.open-cluster-management.io/controls", "AC-1"))
+		Expect(annotations).NotTo(HaveKey("policy.open-cluster-management.io/categories"))
+	})
+
+	It("ignores the controls of other standards of NIST-800-53 rules", func() {
+		annotations := GetAnnotations(APIVersionV1, []*compliancev1alpha1.Rule{newRule("ocp4-mixed", map[string][]string{
+			"NIST-800-53": {"AU-4(1)"},
+			"PCI-DSS":     {"Req-2.2", "SC-1"},
+		})})
+		Expect(annotations).To(HaveKeyWithValue("policy.open-cluster-management.io/standards", "NIST-800-53, PCI-DSS"))
+		Expect(annotations).To(HaveKeyWithValue("policy.open-cluster-management.io/controls", "AU-4(1), Req-2.2, SC-1"))
+		Expect(annotations).To(HaveKeyWithValue("policy.open-cluster-management.io/categories", "AU Audit and Accountability"))
+	})
+
+	It("has no annotations for unmapped rules", func() {
+		Expect(GetAnnotations(APIVersionV1, rules[2:])).To(BeEmpty())
+	})
+})
+
+var _ = Describe("Testing policies", func() {
+	var options *compliancev1alpha1.PolicyOptions
+	var objects []map[string]interface{}
+
+	BeforeEach(func() {
+		options = (&compliancev1alpha1.PolicyOptions{
+			RemediationAction: compliancev1alpha1.PolicyRemediationEnforce,
+		}).WithDefaults()
+		objects = []map[string]interface{}{
+			{"apiVersion": "v1", "kind": "ConfigMap", "metadata": map[string]interface{}{"name": "my-profile-tp"}},
+			{"apiVersion": "compliance.openshift.io/v1alpha1", "kind": "ComplianceSuite", "metadata": map[string]interface{}{"name": "my-profile"}},
+		}
+	})
+
+	It("wraps every object in a ConfigurationPolicy", func() {
+		policy, err := NewPolicy(APIVersionV1, "my-profile", "policies", options, nil, objects...)
+		Expect(err).To(BeNil())
+		Expect(policy.Spec.Namespaces).To(BeNil())
+		Expect(policy.Spec.PolicyTemplates).To(HaveLen(2))
+
+		configPolicy := &unstructured.Unstructured{Object: policy.Spec.PolicyTemplates[0].ObjectDefinition}
+		Expect(configPolicy.GetAPIVersion()).To(Equal(APIVersionV1))
+		Expect(configPolicy.GetKind()).To(Equal("ConfigurationPolicy"))
+		Expect(configPolicy.GetName()).To(Equal("my-profile-configmap"))
+		action, _, _ := unstructured.NestedString(configPolicy.Object, "spec", "remediationAction")
+		Expect(action).To(Equal("enforce"))
+		include, _, _ := unstructured.NestedStringSlice(configPolicy.Object, "spec", "namespaceSelector", "include")
+		Expect(include).To(Equal([]string{"default"}))
+		templates, _, _ := unstructured.NestedSlice(configPolicy.Object, "spec", "object-templates")
+		Expect(templates).To(HaveLen(1))
+		Expect(templates[0]).To(HaveKeyWithValue("complianceType", "musthave"))
+		Expect(templates[0]).To(HaveKeyWithValue("objectDefinition", objects[0]))
+
+		suitePolicy := &unstructured.Unstructured{Object: policy.Spec.PolicyTemplates[1].ObjectDefinition}
+		Expect(suitePolicy.GetName()).To(Equal("my-profile-compliancesuite"))
+	})
+
+	It("holds the objects directly with the policy.mcm.ibm.com API", func() {
+		policy, err := NewPolicy(APIVersionMCMV1alpha1, "my-profile", "policies", options, nil, objects...)
+		Expect(err).To(BeNil())
+		Expect(policy.Spec.Namespaces).NotTo(BeNil())
+		Expect(policy.Spec.Namespaces.Exclude).To(Equal([]string{"kube-*"}))
+		Expect(policy.Spec.PolicyTemplates).To(Equal([]PolicyTemplate{
+			{ObjectDefinition: objects[0]},
+			{ObjectDefinition: objects[1]},
+		}))
+	})
+
+	It("converts to an unstructured object without an empty timestamp", func() {
+		policy, err := NewPolicy(APIVersionV1, "my-profile", "policies", options, map[string]string{"a": "b"}, objects...)
+		Expect(err).To(BeNil())
+		obj, err := utils.ToUnstructured(policy)
+		Expect(err).To(BeNil())
+		Expect(obj.GetAPIVersion()).To(Equal(APIVersionV1))
+		Expect(obj.GetAnnotations()).To(HaveKeyWithValue("a", "b"))
+		_, found, _ := unstructured.NestedFieldNoCopy(obj.Object, "metadata", "creationTimestamp")
+		Expect(found).To(BeFalse())
+		disabled, found, _ := unstructured.NestedBool(obj.Object, "spec", "disabled")
+		Expect(found).To(BeTrue())
+		Expect(disabled).To(BeFalse())
+	})
+})
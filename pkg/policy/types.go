@@ -0,0 +1,126 @@
+// Package policy holds the types of the open-cluster-management governance
+// API the operator generates when a TailoredProfile's output is a Policy. The
+// types only model the fields the operator sets, and aren't registered in
+// the operator's scheme: they're converted to unstructured objects instead,
+// since the API version depends on the hub the Policies are created on.
+package policy
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// APIVersionV1 is the policy API of current open-cluster-management hubs
+	APIVersionV1 = "policy.open-cluster-management.io/v1"
+	// APIVersionMCMV1alpha1 is the policy API of older hubs. Its Policies
+	// hold the objects to enforce directly, without ConfigurationPolicies.
+	APIVersionMCMV1alpha1 = "policy.mcm.ibm.com/v1alpha1"
+	// DefaultAPIVersion is the policy API used unless configured otherwise
+	DefaultAPIVersion = APIVersionV1
+	// PlacementRuleAPIVersionV1 is the API of the PlacementRules of current
+	// hubs. Older hubs serve them from the policy.mcm.ibm.com API.
+	PlacementRuleAPIVersionV1 = "apps.open-cluster-management.io/v1"
+)
+
+// ComplianceType tells how an object template is compared with the objects
+// of the managed clusters
+type ComplianceType string
+
+const (
+	// MustHave means the object must exist and match the template
+	MustHave ComplianceType = "musthave"
+)
+
+// Policy distributes policy templates to the managed clusters it's placed on
+type Policy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec PolicySpec `json:"spec"`
+}
+
+// PolicySpec defines the desired state of a Policy
+type PolicySpec struct {
+	Disabled          bool   `json:"disabled"`
+	RemediationAction string `json:"remediationAction,omitempty"`
+	// Namespaces is only known to the policy.mcm.ibm.com API. The current
+	// API selects the namespaces in each ConfigurationPolicy.
+	Namespaces      *NamespaceSelector `json:"namespaces,omitempty"`
+	PolicyTemplates []PolicyTemplate   `json:"policy-templates"`
+}
+
+// PolicyTemplate wraps an object the Policy distributes
+type PolicyTemplate struct {
+	ObjectDefinition map[string]interface{} `json:"objectDefinition"`
+}
+
+// NamespaceSelector selects the namespaces of the managed clusters objects
+// are enforced in
+type NamespaceSelector struct {
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+// ConfigurationPolicy checks, and possibly enforces, that objects exist on the
+// managed clusters as described by its object templates
+type ConfigurationPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ConfigurationPolicySpec `json:"spec"`
+}
+
+// ConfigurationPolicySpec defines the desired state of a ConfigurationPolicy
+type ConfigurationPolicySpec struct {
+	RemediationAction string            `json:"remediationAction"`
+	NamespaceSelector NamespaceSelector `json:"namespaceSelector"`
+	ObjectTemplates   []ObjectTemplate  `json:"object-templates"`
+}
+
+// ObjectTemplate describes an object of the managed clusters
+type ObjectTemplate struct {
+	ComplianceType   ComplianceType         `json:"complianceType"`
+	ObjectDefinition map[string]interface{} `json:"objectDefinition"`
+}
+
+// PlacementRule selects the managed clusters Policies are placed on
+type PlacementRule struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PlacementRuleSpec   `json:"spec"`
+	Status PlacementRuleStatus `json:"status,omitempty"`
+}
+
+// PlacementRuleSpec defines the desired state of a PlacementRule
+type PlacementRuleSpec struct {
+	ClusterSelector *metav1.LabelSelector `json:"clusterSelector,omitempty"`
+}
+
+// PlacementRuleStatus defines the observed state of a PlacementRule
+type PlacementRuleStatus struct {
+	Decisions []PlacementDecision `json:"decisions,omitempty"`
+}
+
+// PlacementDecision is a managed cluster the PlacementRule selected
+type PlacementDecision struct {
+	ClusterName      string `json:"clusterName,omitempty"`
+	ClusterNamespace string `json:"clusterNamespace,omitempty"`
+}
+
+// PlacementBinding places its subjects on the clusters selected by a
+// PlacementRule
+type PlacementBinding struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	PlacementRef Subject   `json:"placementRef"`
+	Subjects     []Subject `json:"subjects"`
+}
+
+// Subject references an object of the hub
+type Subject struct {
+	Name     string `json:"name"`
+	Kind     string `json:"kind"`
+	APIGroup string `json:"apiGroup"`
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"regexp"
+	"sort"
 	"strings"
 
 	cmpv1alpha1 "github.com/JAORMX/compliance-profile-operator/pkg/apis/compliance/v1alpha1"
@@ -21,11 +22,6 @@ import (
 const (
 	machineConfigFixType = "urn:xccdf:fix:script:ignition"
 	kubernetesFixType    = "urn:xccdf:fix:script:kubernetes"
-
-	controlAnnotationBase = "control.compliance.openshift.io/"
-
-	rhacmStdsAnnotationKey   = "policies.open-cluster-management.io/standards"
-	rhacmCtrlsAnnotationsKey = "policies.open-cluster-management.io/controls"
 )
 
 var log = logf.Log.WithName("profileparser")
@@ -52,25 +48,89 @@ func LogAndReturnError(errormsg string) error {
 	return fmt.Errorf(errormsg)
 }
 
-func getVariableType(varNode *xmldom.Node) cmpv1alpha1.VariableType {
+// getVariableType maps the XCCDF type of a variable. If the type can't be
+// mapped reliably, a warning is returned as well.
+func getVariableType(varNode *xmldom.Node) (cmpv1alpha1.VariableType, string) {
 	typeAttr := varNode.GetAttribute("type")
 	if typeAttr == nil {
-		return cmpv1alpha1.VarTypeString
+		// string is the default type as per the XCCDF spec
+		return cmpv1alpha1.VarTypeString, ""
 	}
 
 	switch typeAttr.Value {
 	case "string":
-		return cmpv1alpha1.VarTypeString
+		return cmpv1alpha1.VarTypeString, ""
 	case "number":
-		return cmpv1alpha1.VarTypeNumber
+		return cmpv1alpha1.VarTypeNumber, ""
 	case "boolean":
-		return cmpv1alpha1.VarTypeBool
+		return cmpv1alpha1.VarTypeBool, ""
+	case "int", "integer", "decimal", "float":
+		return cmpv1alpha1.VarTypeNumber, fmt.Sprintf("non-standard type '%s' is handled as a number", typeAttr.Value)
+	case "bool":
+		return cmpv1alpha1.VarTypeBool, fmt.Sprintf("non-standard type '%s' is handled as a boolean", typeAttr.Value)
+	}
+
+	return cmpv1alpha1.VarTypeString, fmt.Sprintf("unknown type '%s' is handled as a string", typeAttr.Value)
+}
+
+// checkVariableValues verifies that the values defined for the variable
+// actually are of the variable's type, returning a warning for each value
+// that isn't.
+func checkVariableValues(v *cmpv1alpha1.Variable) []string {
+	warnings := []string{}
+	values := []string{}
+	if v.Value != "" {
+		values = append(values, v.Value)
+	}
+	for _, selection := range v.Selections {
+		values = append(values, selection.Value)
+	}
+	for _, val := range values {
+		// Only check the type, the rest of the constraints apply to the
+		// values being set.
+		typeCheck := cmpv1alpha1.Variable{Type: v.Type}
+		if err := typeCheck.SetValue(val); err != nil {
+			warnings = append(warnings, fmt.Sprintf("value '%s' doesn't match the type %s: %s", val, v.Type, err))
+		}
+	}
+	if v.Type != cmpv1alpha1.VarTypeNumber && (v.LowerBound != "" || v.UpperBound != "") {
+		warnings = append(warnings, fmt.Sprintf("bounds are ignored for variables of type %s", v.Type))
 	}
+	return warnings
+}
+
+// getSubResolver returns a function that resolves <sub> references to the
+// title of the referenced variable
+func getSubResolver(contentDom *xmldom.Document) xccdf.SubResolverFn {
+	titles := make(map[string]string)
+	for _, varObj := range contentDom.Root.Query("//Value") {
+		title, _ := ParseLocalizedTexts(varObj.GetChildren("title"), GetLocalizedNodeText)
+		titles[varObj.GetAttributeValue("id")] = title
+	}
+	return func(idref string) string {
+		if title, ok := titles[idref]; ok && title != "" {
+			return title
+		}
+		return idref
+	}
+}
 
-	return cmpv1alpha1.VarTypeString
+// renderMarkdown renders the given XCCDF text as Markdown. Errors are logged
+// and result in an empty string.
+func renderMarkdown(raw string, resolveSub xccdf.SubResolverFn) string {
+	if raw == "" {
+		return ""
+	}
+	rendered, err := xccdf.XHTMLToMarkdown(raw, resolveSub)
+	if err != nil {
+		log.Error(err, "couldn't render text as Markdown")
+		return ""
+	}
+	return rendered
 }
 
 func ParseVariablesAndDo(contentDom *xmldom.Document, pcfg *ParserConfig, action func(v *cmpv1alpha1.Variable) error) error {
+	resolveSub := getSubResolver(contentDom)
 	varObjs := contentDom.Root.Query("//Value")
 	for _, varObj := range varObjs {
 		hidden := varObj.GetAttributeValue("hidden")
@@ -99,22 +159,19 @@ func ParseVariablesAndDo(contentDom *xmldom.Document, pcfg *ParserConfig, action
 				Name:      xccdf.GetVariableNameFromID(id),
 				Namespace: pcfg.ProfileBundleKey.Namespace,
 			},
-			ID:    id,
-			Title: title.Text,
+			ID: id,
 		}
-
-		description := varObj.FindOneByName("description")
-		if description != nil {
-			desc, err := xccdf.GetDescriptionFromXMLString(description.XML())
-			if err != nil {
-				log.Error(err, "couldn't parse a rule's description")
-				desc = ""
-			}
-			v.Description = desc
+		v.Title, v.TitleTranslations = ParseLocalizedTexts(varObj.GetChildren("title"), GetLocalizedNodeText)
+		v.Description, v.DescriptionTranslations = ParseLocalizedTexts(varObj.GetChildren("description"), getLocalizedDescription)
+		v.RenderedDescription = renderMarkdown(v.Description, resolveSub)
+
+		var typeWarning string
+		v.Type, typeWarning = getVariableType(varObj)
+		if typeWarning != "" {
+			log.Info("Variable type couldn't be mapped reliably", "id", id, "warning", typeWarning)
+			v.Warnings = append(v.Warnings, typeWarning)
 		}
 
-		v.Type = getVariableType(varObj)
-
 		// extract the value and optionally the allowed value list
 		err := parseVarValues(varObj, &v)
 		if err != nil {
@@ -123,6 +180,9 @@ func ParseVariablesAndDo(contentDom *xmldom.Document, pcfg *ParserConfig, action
 			continue
 		}
 
+		parseVarConstraints(varObj, &v)
+		v.Warnings = append(v.Warnings, checkVariableValues(&v)...)
+
 		err = action(&v)
 		if err != nil {
 			log.Error(err, "couldn't execute action for variable")
@@ -155,7 +215,31 @@ func parseVarValues(varNode *xmldom.Node, v *cmpv1alpha1.Variable) error {
 	return nil
 }
 
+// parseVarConstraints extracts the constraints on the values of the variable.
+// Only the default constraints (the ones without a selector) are taken into
+// account.
+func parseVarConstraints(varNode *xmldom.Node, v *cmpv1alpha1.Variable) {
+	v.Operator = cmpv1alpha1.VariableOperator(varNode.GetAttributeValue("operator"))
+	interactive := varNode.GetAttributeValue("interactive")
+	v.Interactive = interactive == "true" || interactive == "1"
+	v.LowerBound = getDefaultChildText(varNode, "lower-bound")
+	v.UpperBound = getDefaultChildText(varNode, "upper-bound")
+	v.Match = getDefaultChildText(varNode, "match")
+}
+
+// getDefaultChildText gets the text of the child with the given name that has
+// no selector
+func getDefaultChildText(node *xmldom.Node, name string) string {
+	for _, child := range node.GetChildren(name) {
+		if child.GetAttribute("selector") == nil {
+			return child.Text
+		}
+	}
+	return ""
+}
+
 func ParseRulesAndDo(contentDom *xmldom.Document, pcfg *ParserConfig, action func(p *cmpv1alpha1.Rule) error) error {
+	resolveSub := getSubResolver(contentDom)
 	ruleObjs := contentDom.Root.Query("//Rule")
 	for _, ruleObj := range ruleObjs {
 		id := ruleObj.GetAttributeValue("id")
@@ -168,9 +252,6 @@ func ParseRulesAndDo(contentDom *xmldom.Document, pcfg *ParserConfig, action fun
 		}
 		log.Info("Found rule", "id", id)
 
-		description := ruleObj.FindOneByName("description")
-		rationale := ruleObj.FindOneByName("rationale")
-		warning := ruleObj.FindOneByName("warning")
 		severity := ruleObj.FindOneByName("severity")
 
 		fixes := []cmpv1alpha1.FixDefinition{}
@@ -222,39 +303,24 @@ func ParseRulesAndDo(contentDom *xmldom.Document, pcfg *ParserConfig, action fun
 				Annotations: annotations,
 			},
 			ID:             id,
-			Title:          title.Text,
 			AvailableFixes: nil,
 		}
-		if description != nil {
-			desc, err := xccdf.GetDescriptionFromXMLString(description.XML())
-			if err != nil {
-				log.Error(err, "couldn't parse a rule's description")
-				desc = ""
-			}
-			p.Description = desc
-		}
-		if rationale != nil {
-			rat, err := xccdf.GetRationaleFromXMLString(rationale.XML())
-			if err != nil {
-				log.Error(err, "couldn't parse a rule's rationale")
-				rat = ""
-			}
-			p.Rationale = rat
-		}
-		if warning != nil {
-			warn, err := xccdf.GetWarningFromXMLString(warning.XML())
-			if err != nil {
-				log.Error(err, "couldn't parse a rule's warning")
-				warn = ""
-			}
-			p.Warning = warn
-		}
+		p.Title, p.TitleTranslations = ParseLocalizedTexts(ruleObj.GetChildren("title"), GetLocalizedNodeText)
+		p.Description, p.DescriptionTranslations = ParseLocalizedTexts(ruleObj.GetChildren("description"), getLocalizedDescription)
+		p.Rationale, p.RationaleTranslations = ParseLocalizedTexts(ruleObj.GetChildren("rationale"), getLocalizedRationale)
+		p.Warning, p.WarningTranslations = ParseLocalizedTexts(ruleObj.GetChildren("warning"), getLocalizedWarning)
+		p.RenderedDescription = renderMarkdown(p.Description, resolveSub)
+		p.RenderedRationale = renderMarkdown(p.Rationale, resolveSub)
+		p.RenderedWarning = renderMarkdown(p.Warning, resolveSub)
 		if severity != nil {
 			p.Severity = severity.Text
 		}
 		if len(fixes) > 0 {
 			p.AvailableFixes = fixes
 		}
+		p.Requires, p.Conflicts = parseRuleRelationships(ruleObj)
+		p.Groups = getRuleGroups(ruleObj)
+		p.Selectors = getRuleSelectors(ruleObj)
 		err = action(&p)
 		if err != nil {
 			log.Error(err, "couldn't execute action for rule")
@@ -265,6 +331,117 @@ func ParseRulesAndDo(contentDom *xmldom.Document, pcfg *ParserConfig, action fun
 	return nil
 }
 
+// ParseLocalizedTexts gathers all the language variants of a text (e.g. all
+// the "description" elements of a rule). It returns the text in the default
+// language, or the first variant if there's none in the default language, as
+// well as the translations keyed by language. Variants that can't be parsed
+// are skipped.
+func ParseLocalizedTexts(nodes []*xmldom.Node, parse func(*xmldom.Node) (string, string, error)) (string, cmpv1alpha1.LocalizedTexts) {
+	var text string
+	var translations cmpv1alpha1.LocalizedTexts
+	found, foundDefault := false, false
+	for _, node := range nodes {
+		lang, value, err := parse(node)
+		if err != nil {
+			log.Error(err, "couldn't parse localized text", "element", node.Name)
+			continue
+		}
+		if !found || (!foundDefault && lang == xccdf.DefaultLang) {
+			text = value
+			found = true
+			foundDefault = lang == xccdf.DefaultLang
+		}
+		if lang == "" {
+			continue
+		}
+		if translations == nil {
+			translations = make(cmpv1alpha1.LocalizedTexts)
+		}
+		translations[lang] = value
+	}
+	return text, translations
+}
+
+// GetLocalizedNodeText gets the language and the text of a plain text node
+// (e.g. a title)
+func GetLocalizedNodeText(node *xmldom.Node) (string, string, error) {
+	return node.GetAttributeValue("lang"), node.Text, nil
+}
+
+func getLocalizedDescription(node *xmldom.Node) (string, string, error) {
+	return xccdf.GetLocalizedDescriptionFromXMLString(node.XML())
+}
+
+func getLocalizedRationale(node *xmldom.Node) (string, string, error) {
+	return xccdf.GetLocalizedRationaleFromXMLString(node.XML())
+}
+
+func getLocalizedWarning(node *xmldom.Node) (string, string, error) {
+	return xccdf.GetLocalizedWarningFromXMLString(node.XML())
+}
+
+// getRuleGroups returns the XCCDF IDs of the groups containing the rule, from
+// the outermost to the innermost one
+func getRuleGroups(ruleObj *xmldom.Node) []string {
+	groups := []string{}
+	for parent := ruleObj.Parent; parent != nil; parent = parent.Parent {
+		if parent.Name != "Group" {
+			continue
+		}
+		if id := parent.GetAttributeValue("id"); id != "" {
+			groups = append([]string{id}, groups...)
+		}
+	}
+	if len(groups) == 0 {
+		return nil
+	}
+	return groups
+}
+
+// getRuleSelectors returns the selectors of the rule's check, fix and text
+// variants, sorted and without duplicates
+func getRuleSelectors(ruleObj *xmldom.Node) []string {
+	seen := make(map[string]bool)
+	selectors := []string{}
+	for _, child := range ruleObj.Children {
+		selector := child.GetAttributeValue("selector")
+		if selector == "" || seen[selector] {
+			continue
+		}
+		seen[selector] = true
+		selectors = append(selectors, selector)
+	}
+	if len(selectors) == 0 {
+		return nil
+	}
+	sort.Strings(selectors)
+	return selectors
+}
+
+// parseRuleRelationships gets the "requires" and "conflicts" references of a
+// rule. Each "requires" entry is kept as the raw (space-separated) idref list,
+// since only one of the listed items needs to be selected.
+func parseRuleRelationships(ruleObj *xmldom.Node) ([]string, []string) {
+	var requires, conflicts []string
+	for _, reqObj := range ruleObj.GetChildren("requires") {
+		idref := strings.Join(strings.Fields(reqObj.GetAttributeValue("idref")), " ")
+		if idref == "" {
+			log.Info("no idref in requires element")
+			continue
+		}
+		requires = append(requires, idref)
+	}
+	for _, conflictObj := range ruleObj.GetChildren("conflicts") {
+		idref := conflictObj.GetAttributeValue("idref")
+		if idref == "" {
+			log.Info("no idref in conflicts element")
+			continue
+		}
+		conflicts = append(conflicts, idref)
+	}
+	return requires, conflicts
+}
+
 // Reads a YAML file and returns an unstructured object from it. This object
 // can be taken into use by the dynamic client
 func readObjFromYAML(r io.Reader) (*unstructured.Unstructured, error) {
@@ -347,7 +524,7 @@ func (p *referenceParser) parseXmlNode(ruleObj *xmldom.Node) (map[string]string,
 
 func profileOperatorFormatter(annotations map[string]string, std, ctrl string) {
 	const poSep = ";"
-	key := controlAnnotationBase + std
+	key := cmpv1alpha1.ControlAnnotationBase + std
 
 	appendKeyWithSep(annotations, key, ctrl, poSep)
 }
@@ -355,8 +532,8 @@ func profileOperatorFormatter(annotations map[string]string, std, ctrl string) {
 func rhacmFormatter(annotations map[string]string, std, ctrl string) {
 	const rhacmSeperator = ","
 
-	appendKeyWithSep(annotations, rhacmStdsAnnotationKey, std, rhacmSeperator)
-	appendKeyWithSep(annotations, rhacmCtrlsAnnotationsKey, ctrl, rhacmSeperator)
+	appendKeyWithSep(annotations, cmpv1alpha1.RuleStandardsAnnotationKey, std, rhacmSeperator)
+	appendKeyWithSep(annotations, cmpv1alpha1.RuleControlsAnnotationKey, ctrl, rhacmSeperator)
 }
 
 func appendKeyWithSep(annotations map[string]string, key, item, sep string) {
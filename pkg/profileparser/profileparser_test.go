@@ -161,13 +161,235 @@ var _ = Describe("Testing parse rules", func() {
 		})
 
 		It("Has the expected control NIST annotations in profile operator format", func() {
-			nistKey := controlAnnotationBase + "NIST-800-53"
+			nistKey := cmpv1alpha1.ControlAnnotationBase + "NIST-800-53"
 			Expect(pwMinLenRule.Annotations).To(HaveKeyWithValue(nistKey, "IA-5(f);IA-5(1)(a);CM-6(a)"))
 		})
 
 		It("Has the expected control NIST annotations in RHACM format", func() {
-			Expect(pwMinLenRule.Annotations).To(HaveKeyWithValue(rhacmStdsAnnotationKey, "NIST-800-53"))
-			Expect(pwMinLenRule.Annotations).To(HaveKeyWithValue(rhacmCtrlsAnnotationsKey, "IA-5(f),IA-5(1)(a),CM-6(a)"))
+			Expect(pwMinLenRule.Annotations).To(HaveKeyWithValue(cmpv1alpha1.RuleStandardsAnnotationKey, "NIST-800-53"))
+			Expect(pwMinLenRule.Annotations).To(HaveKeyWithValue(cmpv1alpha1.RuleControlsAnnotationKey, "IA-5(f),IA-5(1)(a),CM-6(a)"))
 		})
 	})
 })
+
+var _ = Describe("Testing parse rule relationships", func() {
+	const ruleXML = `<xccdf-1.2:Benchmark xmlns:xccdf-1.2="http://checklists.nist.gov/xccdf/1.2">
+  <xccdf-1.2:Rule id="xccdf_org.ssgproject.content_rule_audit_rules_immutable" selected="false">
+    <xccdf-1.2:title>Make the auditd Configuration Immutable</xccdf-1.2:title>
+    <xccdf-1.2:requires idref="xccdf_org.ssgproject.content_rule_service_auditd_enabled"/>
+    <xccdf-1.2:requires idref="xccdf_org.ssgproject.content_rule_package_audit_installed
+      xccdf_org.ssgproject.content_rule_package_audit-libs_installed"/>
+    <xccdf-1.2:conflicts idref="xccdf_org.ssgproject.content_rule_audit_rules_mutable"/>
+  </xccdf-1.2:Rule>
+</xccdf-1.2:Benchmark>`
+	var rule *cmpv1alpha1.Rule
+
+	BeforeEach(func() {
+		dom, err := xmldom.ParseXML(ruleXML)
+		Expect(err).To(BeNil())
+
+		rule = nil
+		err = ParseRulesAndDo(dom, pcfg, func(r *cmpv1alpha1.Rule) error {
+			rule = r
+			return nil
+		})
+		Expect(err).To(BeNil())
+		Expect(rule).ToNot(BeNil())
+	})
+
+	It("Has the expected requirements", func() {
+		Expect(rule.Requires).To(Equal([]string{
+			"xccdf_org.ssgproject.content_rule_service_auditd_enabled",
+			"xccdf_org.ssgproject.content_rule_package_audit_installed xccdf_org.ssgproject.content_rule_package_audit-libs_installed",
+		}))
+	})
+
+	It("Has the expected conflicts", func() {
+		Expect(rule.Conflicts).To(ConsistOf("xccdf_org.ssgproject.content_rule_audit_rules_mutable"))
+	})
+})
+
+var _ = Describe("Testing parse localized texts", func() {
+	const ruleXML = `<xccdf-1.2:Benchmark xmlns:xccdf-1.2="http://checklists.nist.gov/xccdf/1.2">
+  <xccdf-1.2:Rule id="xccdf_org.ssgproject.content_rule_banner_etc_issue" selected="false">
+    <xccdf-1.2:title xml:lang="fr-FR">Modifier la bannière système</xccdf-1.2:title>
+    <xccdf-1.2:title xml:lang="en-US">Modify the System Login Banner</xccdf-1.2:title>
+    <xccdf-1.2:title xml:lang="de-DE">Systemanmeldebanner ändern</xccdf-1.2:title>
+    <xccdf-1.2:rationale xml:lang="de-DE">Rechtliche Hinweise.</xccdf-1.2:rationale>
+  </xccdf-1.2:Rule>
+</xccdf-1.2:Benchmark>`
+	var rule *cmpv1alpha1.Rule
+
+	BeforeEach(func() {
+		dom, err := xmldom.ParseXML(ruleXML)
+		Expect(err).To(BeNil())
+
+		rule = nil
+		err = ParseRulesAndDo(dom, pcfg, func(r *cmpv1alpha1.Rule) error {
+			rule = r
+			return nil
+		})
+		Expect(err).To(BeNil())
+		Expect(rule).ToNot(BeNil())
+	})
+
+	It("Prefers the default language", func() {
+		Expect(rule.Title).To(Equal("Modify the System Login Banner"))
+	})
+
+	It("Keeps all the translations", func() {
+		Expect(rule.TitleTranslations).To(Equal(cmpv1alpha1.LocalizedTexts{
+			"fr-FR": "Modifier la bannière système",
+			"en-US": "Modify the System Login Banner",
+			"de-DE": "Systemanmeldebanner ändern",
+		}))
+	})
+
+	It("Falls back to the first variant", func() {
+		Expect(rule.Rationale).To(Equal("Rechtliche Hinweise."))
+		Expect(rule.RationaleTranslations).To(HaveKeyWithValue("de-DE", "Rechtliche Hinweise."))
+	})
+})
+
+var _ = Describe("Testing parse variable constraints", func() {
+	const varXML = `<xccdf-1.2:Benchmark xmlns:xccdf-1.2="http://checklists.nist.gov/xccdf/1.2">
+  <xccdf-1.2:Value id="xccdf_org.ssgproject.content_value_var_accounts_tmout" type="number" operator="less than or equal" interactive="0">
+    <xccdf-1.2:title>Account Inactivity Timeout (seconds)</xccdf-1.2:title>
+    <xccdf-1.2:value>600</xccdf-1.2:value>
+    <xccdf-1.2:value selector="10_minutes">600</xccdf-1.2:value>
+    <xccdf-1.2:lower-bound selector="10_minutes">600</xccdf-1.2:lower-bound>
+    <xccdf-1.2:lower-bound>60</xccdf-1.2:lower-bound>
+    <xccdf-1.2:upper-bound>3600</xccdf-1.2:upper-bound>
+    <xccdf-1.2:match>^[0-9]+$</xccdf-1.2:match>
+  </xccdf-1.2:Value>
+</xccdf-1.2:Benchmark>`
+	var variable *cmpv1alpha1.Variable
+
+	BeforeEach(func() {
+		dom, err := xmldom.ParseXML(varXML)
+		Expect(err).To(BeNil())
+
+		variable = nil
+		err = ParseVariablesAndDo(dom, pcfg, func(v *cmpv1alpha1.Variable) error {
+			variable = v
+			return nil
+		})
+		Expect(err).To(BeNil())
+		Expect(variable).ToNot(BeNil())
+	})
+
+	It("Has the expected operator", func() {
+		Expect(variable.Operator).To(Equal(cmpv1alpha1.VarOpLessThanOrEqual))
+		Expect(variable.Interactive).To(BeFalse())
+	})
+
+	It("Has the default bounds and match", func() {
+		Expect(variable.LowerBound).To(Equal("60"))
+		Expect(variable.UpperBound).To(Equal("3600"))
+		Expect(variable.Match).To(Equal("^[0-9]+$"))
+	})
+})
+
+var _ = Describe("Testing parse variable types", func() {
+	const varXML = `<xccdf-1.2:Benchmark xmlns:xccdf-1.2="http://checklists.nist.gov/xccdf/1.2">
+  <xccdf-1.2:Value id="xccdf_org.ssgproject.content_value_var_ratio" type="decimal">
+    <xccdf-1.2:title>Ratio</xccdf-1.2:title>
+    <xccdf-1.2:value>0.5</xccdf-1.2:value>
+  </xccdf-1.2:Value>
+  <xccdf-1.2:Value id="xccdf_org.ssgproject.content_value_var_unknown" type="duration">
+    <xccdf-1.2:title>Unknown</xccdf-1.2:title>
+    <xccdf-1.2:value>5m</xccdf-1.2:value>
+  </xccdf-1.2:Value>
+  <xccdf-1.2:Value id="xccdf_org.ssgproject.content_value_var_bad_default" type="number">
+    <xccdf-1.2:title>Bad default</xccdf-1.2:title>
+    <xccdf-1.2:value>ten</xccdf-1.2:value>
+  </xccdf-1.2:Value>
+</xccdf-1.2:Benchmark>`
+	variables := map[string]*cmpv1alpha1.Variable{}
+
+	BeforeEach(func() {
+		dom, err := xmldom.ParseXML(varXML)
+		Expect(err).To(BeNil())
+
+		err = ParseVariablesAndDo(dom, pcfg, func(v *cmpv1alpha1.Variable) error {
+			variables[v.ID] = v
+			return nil
+		})
+		Expect(err).To(BeNil())
+	})
+
+	It("Maps non-standard numeric types to number with a warning", func() {
+		v := variables["xccdf_org.ssgproject.content_value_var_ratio"]
+		Expect(v.Type).To(BeEquivalentTo(cmpv1alpha1.VarTypeNumber))
+		Expect(v.Value).To(Equal("0.5"))
+		Expect(v.Warnings).To(ConsistOf("non-standard type 'decimal' is handled as a number"))
+	})
+
+	It("Reports unknown types", func() {
+		v := variables["xccdf_org.ssgproject.content_value_var_unknown"]
+		Expect(v.Type).To(BeEquivalentTo(cmpv1alpha1.VarTypeString))
+		Expect(v.Warnings).To(ConsistOf("unknown type 'duration' is handled as a string"))
+	})
+
+	It("Reports values that don't match the type", func() {
+		v := variables["xccdf_org.ssgproject.content_value_var_bad_default"]
+		Expect(v.Warnings).To(ConsistOf("value 'ten' doesn't match the type number: value ten is not a number"))
+	})
+})
+
+var _ = Describe("Testing parse rule groups", func() {
+	const ruleXML = `<xccdf-1.2:Benchmark xmlns:xccdf-1.2="http://checklists.nist.gov/xccdf/1.2">
+  <xccdf-1.2:Group id="xccdf_org.ssgproject.content_group_auditing">
+    <xccdf-1.2:title>Auditing</xccdf-1.2:title>
+    <xccdf-1.2:Group id="xccdf_org.ssgproject.content_group_audit_rules">
+      <xccdf-1.2:title>Audit rules</xccdf-1.2:title>
+      <xccdf-1.2:Rule id="xccdf_org.ssgproject.content_rule_audit_rules_immutable" selected="false">
+        <xccdf-1.2:title>Make the auditd Configuration Immutable</xccdf-1.2:title>
+      </xccdf-1.2:Rule>
+    </xccdf-1.2:Group>
+  </xccdf-1.2:Group>
+</xccdf-1.2:Benchmark>`
+
+	It("Has the groups from the outermost to the innermost one", func() {
+		dom, err := xmldom.ParseXML(ruleXML)
+		Expect(err).To(BeNil())
+
+		var rule *cmpv1alpha1.Rule
+		err = ParseRulesAndDo(dom, pcfg, func(r *cmpv1alpha1.Rule) error {
+			rule = r
+			return nil
+		})
+		Expect(err).To(BeNil())
+		Expect(rule).ToNot(BeNil())
+		Expect(rule.Groups).To(Equal([]string{
+			"xccdf_org.ssgproject.content_group_auditing",
+			"xccdf_org.ssgproject.content_group_audit_rules",
+		}))
+	})
+})
+
+var _ = Describe("Testing parse rule selectors", func() {
+	const ruleXML = `<xccdf-1.2:Benchmark xmlns:xccdf-1.2="http://checklists.nist.gov/xccdf/1.2">
+  <xccdf-1.2:Rule id="xccdf_org.ssgproject.content_rule_sshd_set_idle_timeout" selected="false">
+    <xccdf-1.2:title>Set SSH Idle Timeout Interval</xccdf-1.2:title>
+    <xccdf-1.2:check system="http://oval.mitre.org/XMLSchema/oval-definitions-5" selector="strict"/>
+    <xccdf-1.2:check system="http://oval.mitre.org/XMLSchema/oval-definitions-5" selector="lenient"/>
+    <xccdf-1.2:check system="http://oval.mitre.org/XMLSchema/oval-definitions-5"/>
+    <xccdf-1.2:fix system="urn:xccdf:fix:script:sh" selector="strict"/>
+  </xccdf-1.2:Rule>
+</xccdf-1.2:Benchmark>`
+
+	It("Has the selectors of its variants", func() {
+		dom, err := xmldom.ParseXML(ruleXML)
+		Expect(err).To(BeNil())
+
+		var rule *cmpv1alpha1.Rule
+		err = ParseRulesAndDo(dom, pcfg, func(r *cmpv1alpha1.Rule) error {
+			rule = r
+			return nil
+		})
+		Expect(err).To(BeNil())
+		Expect(rule).ToNot(BeNil())
+		Expect(rule.Selectors).To(Equal([]string{"lenient", "strict"}))
+	})
+})
@@ -0,0 +1,20 @@
+package utils
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ToUnstructured converts an API object to an unstructured object. It's used
+// for the types that aren't registered in the operator's scheme, and for the
+// fields the vendored API types don't know about.
+func ToUnstructured(obj interface{}) (*unstructured.Unstructured, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	// The API server doesn't keep the empty timestamp, which would make the
+	// object look modified on every reconcile
+	unstructured.RemoveNestedField(content, "metadata", "creationTimestamp")
+	return &unstructured.Unstructured{Object: content}, nil
+}
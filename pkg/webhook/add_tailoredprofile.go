@@ -0,0 +1,10 @@
+package webhook
+
+import (
+	"github.com/JAORMX/compliance-profile-operator/pkg/webhook/tailoredprofile"
+)
+
+func init() {
+	// AddToManagerFuncs is a list of functions to create webhooks and add them to a manager.
+	AddToManagerFuncs = append(AddToManagerFuncs, tailoredprofile.Add)
+}
@@ -0,0 +1,19 @@
+package tailoredprofile
+
+import (
+	"testing"
+
+	"github.com/JAORMX/compliance-profile-operator/pkg/apis"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestTailoredProfile(t *testing.T) {
+	RegisterFailHandler(Fail)
+	if err := apis.AddToScheme(scheme.Scheme); err != nil {
+		t.Fatal(err)
+	}
+	RunSpecs(t, "tailoredprofile webhook Suite")
+}
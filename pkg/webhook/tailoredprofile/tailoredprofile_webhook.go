@@ -0,0 +1,464 @@
+package tailoredprofile
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	compliancev1alpha1 "github.com/JAORMX/compliance-profile-operator/pkg/apis/compliance/v1alpha1"
+	"github.com/JAORMX/compliance-profile-operator/pkg/controller/common"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+var log = logf.Log.WithName("webhook_tailoredprofile")
+
+const (
+	// ValidatePath is the path the validating webhook is served at
+	ValidatePath = "/validate-compliance-openshift-io-v1alpha1-tailoredprofile"
+	// DefaultPath is the path the defaulting webhook is served at
+	DefaultPath = "/mutate-compliance-openshift-io-v1alpha1-tailoredprofile"
+)
+
+// Add registers the TailoredProfile webhooks in the Manager's webhook server
+func Add(mgr manager.Manager) error {
+	server := mgr.GetWebhookServer()
+	server.Register(ValidatePath, &webhook.Admission{Handler: &tailoredProfileValidator{}})
+	server.Register(DefaultPath, &webhook.Admission{Handler: &tailoredProfileDefaulter{}})
+	return nil
+}
+
+// tailoredProfileValidator rejects TailoredProfiles that the controller
+// wouldn't be able to render, so mistakes surface when applying the object
+// instead of in its status.
+type tailoredProfileValidator struct {
+	client  client.Client
+	decoder *admission.Decoder
+}
+
+// InjectClient injects the client into the validator
+func (v *tailoredProfileValidator) InjectClient(c client.Client) error {
+	v.client = c
+	return nil
+}
+
+// InjectDecoder injects the decoder into the validator
+func (v *tailoredProfileValidator) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+	return nil
+}
+
+// Handle implements admission.Handler
+func (v *tailoredProfileValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	tp := &compliancev1alpha1.TailoredProfile{}
+	if err := v.decoder.Decode(req, tp); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	if req.Operation == admissionv1beta1.Update {
+		oldTP := &compliancev1alpha1.TailoredProfile{}
+		if err := v.decoder.DecodeRaw(req.OldObject, oldTP); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		// Only changes to the spec are validated. Otherwise objects that
+		// became invalid later on (e.g. a rule got removed) couldn't even
+		// be updated by the controller.
+		if equality.Semantic.DeepEqual(oldTP.Spec, tp.Spec) {
+			return admission.Allowed("")
+		}
+	}
+
+	errs, err := v.validate(ctx, tp, req.Namespace)
+	if err != nil {
+		log.Error(err, "Couldn't validate TailoredProfile", "TailoredProfile.Namespace", req.Namespace, "TailoredProfile.Name", tp.Name)
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if len(errs) > 0 {
+		return admission.Denied(errs.ToAggregate().Error())
+	}
+	return admission.Allowed("")
+}
+
+func (v *tailoredProfileValidator) validate(ctx context.Context, tp *compliancev1alpha1.TailoredProfile, namespace string) (field.ErrorList, error) {
+	errs := field.ErrorList{}
+	specPath := field.NewPath("spec")
+
+	if tp.Spec.Extends == "" && tp.Spec.ProfileBundle == "" {
+		errs = append(errs, field.Required(specPath.Child("extends"), "the profile to extend needs to be set, unless profileBundle is"))
+	}
+	if tp.Spec.Extends != "" && tp.Spec.ProfileBundle != "" {
+		errs = append(errs, field.Forbidden(specPath.Child("profileBundle"), "profileBundle and extends are mutually exclusive"))
+	}
+
+	switch tp.Spec.ExtendsKind {
+	case "", compliancev1alpha1.ExtendsProfile:
+	case compliancev1alpha1.ExtendsTailoredProfile:
+		if tp.Spec.Extends == tp.Name && (tp.Spec.ContentNamespace == "" || tp.Spec.ContentNamespace == namespace) {
+			errs = append(errs, field.Invalid(specPath.Child("extends"), tp.Spec.Extends, "a TailoredProfile can't extend itself"))
+		}
+	default:
+		errs = append(errs, field.NotSupported(specPath.Child("extendsKind"), tp.Spec.ExtendsKind, []string{
+			string(compliancev1alpha1.ExtendsProfile),
+			string(compliancev1alpha1.ExtendsTailoredProfile),
+		}))
+	}
+
+	switch tp.Spec.OutputType {
+	case "", compliancev1alpha1.ConfigMapOutput, compliancev1alpha1.PolicyOutput, compliancev1alpha1.SecretOutput,
+		compliancev1alpha1.ComplianceSuiteOutput:
+	default:
+		errs = append(errs, field.NotSupported(specPath.Child("outputType"), tp.Spec.OutputType, []string{
+			string(compliancev1alpha1.ConfigMapOutput),
+			string(compliancev1alpha1.PolicyOutput),
+			string(compliancev1alpha1.SecretOutput),
+			string(compliancev1alpha1.ComplianceSuiteOutput),
+		}))
+	}
+
+	// ComplianceSuites and Policies only take tailorings from ConfigMaps
+	if tp.Spec.OutputType == compliancev1alpha1.SecretOutput {
+		if tp.Spec.PolicyOptions != nil {
+			errs = append(errs, field.Forbidden(specPath.Child("policyOptions"), "can't be used with the Secret output type, as Policies only take tailorings from ConfigMaps"))
+		}
+		if tp.Spec.ScanSettings != nil {
+			errs = append(errs, field.Forbidden(specPath.Child("scanSettings"), "can't be used with the Secret output type, as ComplianceSuites only take tailorings from ConfigMaps"))
+		}
+	}
+	if tp.Spec.PolicyOptions != nil {
+		if err := tp.Spec.PolicyOptions.Validate(); err != nil {
+			errs = append(errs, field.Invalid(specPath.Child("policyOptions"), tp.Spec.PolicyOptions, err.Error()))
+		}
+	}
+	if tp.Spec.ScanSettings != nil {
+		if err := tp.Spec.ScanSettings.Validate(); err != nil {
+			errs = append(errs, field.Invalid(specPath.Child("scanSettings"), tp.Spec.ScanSettings, err.Error()))
+		}
+	}
+
+	if tp.Spec.RevisionHistoryLimit != nil && *tp.Spec.RevisionHistoryLimit < 1 {
+		errs = append(errs, field.Invalid(specPath.Child("revisionHistoryLimit"), *tp.Spec.RevisionHistoryLimit, "at least one revision needs to be kept"))
+	}
+	if tp.Spec.PinnedRevision < 0 {
+		errs = append(errs, field.Invalid(specPath.Child("pinnedRevision"), tp.Spec.PinnedRevision, "must be a revision number, or 0 to follow the latest revision"))
+	}
+
+	// The rules and variables are looked up in the namespace of the content,
+	// as long as the TailoredProfile is allowed to reference it. Otherwise
+	// the webhook would tell what another namespace holds.
+	contentNamespace := namespace
+	if tp.Spec.ContentNamespace != "" && tp.Spec.ContentNamespace != namespace {
+		contentNamespace = tp.Spec.ContentNamespace
+		kind, name := tp.GetReference()
+		granted, err := common.IsReferenceGranted(ctx, v.client, namespace, kind, contentNamespace, name)
+		if err != nil {
+			return nil, err
+		}
+		if !granted {
+			return append(errs, field.Forbidden(specPath.Child("contentNamespace"), fmt.Sprintf(
+				"no ReferenceGrant in namespace %s allows referencing %s %s", contentNamespace, kind, name))), nil
+		}
+	}
+
+	pb, err := v.getProfileBundle(ctx, tp, contentNamespace)
+	if err != nil {
+		return nil, err
+	}
+
+	ruleErrs, err := v.validateRuleSelections(ctx, tp, contentNamespace, pb, specPath)
+	if err != nil {
+		return nil, err
+	}
+	errs = append(errs, ruleErrs...)
+
+	varErrs, err := v.validateSetValues(ctx, tp, contentNamespace, pb, specPath.Child("setValues"))
+	if err != nil {
+		return nil, err
+	}
+	errs = append(errs, varErrs...)
+
+	refineErrs, err := v.validateRefinements(ctx, tp, contentNamespace, pb, specPath)
+	if err != nil {
+		return nil, err
+	}
+	return append(errs, refineErrs...), nil
+}
+
+// getProfileBundle gets the ProfileBundle that references by XCCDF ID or short
+// name are resolved against. It's nil if it can't be told without resolving
+// the whole chain of TailoredProfiles, or if it doesn't exist.
+func (v *tailoredProfileValidator) getProfileBundle(ctx context.Context, tp *compliancev1alpha1.TailoredProfile, namespace string) (*compliancev1alpha1.ProfileBundle, error) {
+	pbName := tp.Spec.ProfileBundle
+	if tp.Spec.Extends != "" {
+		if tp.Spec.ExtendsKind == compliancev1alpha1.ExtendsTailoredProfile {
+			return nil, nil
+		}
+		p := &compliancev1alpha1.Profile{}
+		err := v.client.Get(ctx, types.NamespacedName{Name: tp.Spec.Extends, Namespace: namespace}, p)
+		if errors.IsNotFound(err) {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+		owner := metav1.GetControllerOf(p)
+		if owner == nil || owner.Kind != "ProfileBundle" {
+			return nil, nil
+		}
+		pbName = owner.Name
+	}
+
+	pb := &compliancev1alpha1.ProfileBundle{}
+	err := v.client.Get(ctx, types.NamespacedName{Name: pbName, Namespace: namespace}, pb)
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	return pb, err
+}
+
+// resolveReference resolves a reference to a rule or variable with the given
+// resolver. An empty name is returned if the reference can't be resolved, in
+// which case the returned field error, if any, tells why.
+func resolveReference(ctx context.Context, resolve func(context.Context, client.Client, string, string, *compliancev1alpha1.ProfileBundle) (string, error),
+	c client.Client, namespace, ref string, pb *compliancev1alpha1.ProfileBundle, namePath *field.Path) (string, *field.Error, error) {
+	name, err := resolve(ctx, c, namespace, ref, pb)
+	if errors.IsNotFound(err) {
+		return "", field.NotFound(namePath, ref), nil
+	} else if common.IsAmbiguousReference(err) {
+		if pb == nil {
+			// The controller resolves it once the ProfileBundle is known
+			return "", nil, nil
+		}
+		return "", field.Invalid(namePath, ref, err.Error()), nil
+	} else if err != nil {
+		return "", nil, err
+	}
+	return name, nil, nil
+}
+
+func (v *tailoredProfileValidator) validateRefinements(ctx context.Context, tp *compliancev1alpha1.TailoredProfile, namespace string, pb *compliancev1alpha1.ProfileBundle, specPath *field.Path) (field.ErrorList, error) {
+	errs := field.ErrorList{}
+	seen := make(map[string]bool)
+	for i, refinement := range tp.Spec.RefineRules {
+		refinementPath := specPath.Child("refineRules").Index(i)
+		validErr := refinement.Validate()
+		if validErr != nil {
+			errs = append(errs, field.Invalid(refinementPath, refinement.Name, validErr.Error()))
+		}
+
+		name, fieldErr, err := resolveReference(ctx, common.ResolveRuleName, v.client, namespace, refinement.Name, pb, refinementPath.Child("name"))
+		if err != nil {
+			return nil, err
+		} else if fieldErr != nil {
+			errs = append(errs, fieldErr)
+		}
+		if name == "" {
+			continue
+		}
+		if seen[name] {
+			errs = append(errs, field.Duplicate(refinementPath.Child("name"), refinement.Name))
+			continue
+		}
+		seen[name] = true
+		if validErr != nil || refinement.Selector == "" {
+			continue
+		}
+
+		// The selector needs to pick one of the rule's variants
+		rule := &compliancev1alpha1.Rule{}
+		err = v.client.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, rule)
+		if err != nil {
+			return nil, err
+		}
+		if err := refinement.ValidateFor(rule); err != nil {
+			errs = append(errs, field.Invalid(refinementPath.Child("selector"), refinement.Selector, err.Error()))
+		}
+	}
+
+	seen = make(map[string]bool)
+	for i, refinement := range tp.Spec.RefineValues {
+		refinementPath := specPath.Child("refineValues").Index(i)
+		name, fieldErr, err := resolveReference(ctx, common.ResolveVariableName, v.client, namespace, refinement.Name, pb, refinementPath.Child("name"))
+		if err != nil {
+			return nil, err
+		} else if fieldErr != nil {
+			errs = append(errs, fieldErr)
+		}
+		if name == "" {
+			continue
+		}
+		if seen[name] {
+			errs = append(errs, field.Duplicate(refinementPath.Child("name"), refinement.Name))
+			continue
+		}
+		seen[name] = true
+
+		variable := &compliancev1alpha1.Variable{}
+		err = v.client.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, variable)
+		if err != nil {
+			return nil, err
+		}
+		if err := refinement.ValidateFor(variable); err != nil {
+			errs = append(errs, field.Invalid(refinementPath, refinement.Name, err.Error()))
+		}
+	}
+	return errs, nil
+}
+
+func (v *tailoredProfileValidator) validateRuleSelections(ctx context.Context, tp *compliancev1alpha1.TailoredProfile, namespace string, pb *compliancev1alpha1.ProfileBundle, specPath *field.Path) (field.ErrorList, error) {
+	errs := field.ErrorList{}
+	seen := make(map[string]bool)
+	selections := map[string][]compliancev1alpha1.RuleReferenceSpec{
+		"enableRules":  tp.Spec.EnableRules,
+		"disableRules": tp.Spec.DisableRules,
+	}
+	// Check enableRules first, so duplicates are reported in disableRules
+	for _, list := range []string{"enableRules", "disableRules"} {
+		for i, selection := range selections[list] {
+			selectionPath := specPath.Child(list).Index(i)
+			if selection.Selector != nil {
+				errs = append(errs, validateRuleSelector(selection, selectionPath)...)
+				continue
+			}
+			namePath := selectionPath.Child("name")
+			if selection.Name == "" {
+				errs = append(errs, field.Required(namePath, "either a name or a selector needs to be set"))
+				continue
+			}
+			name, fieldErr, err := resolveReference(ctx, common.ResolveRuleName, v.client, namespace, selection.Name, pb, namePath)
+			if err != nil {
+				return nil, err
+			} else if fieldErr != nil {
+				errs = append(errs, fieldErr)
+			}
+			if name == "" {
+				continue
+			}
+			if seen[name] {
+				errs = append(errs, field.Duplicate(namePath, selection.Name))
+				continue
+			}
+			seen[name] = true
+		}
+	}
+	return errs, nil
+}
+
+func validateRuleSelector(selection compliancev1alpha1.RuleReferenceSpec, selectionPath *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+	selectorPath := selectionPath.Child("selector")
+	if selection.Name != "" {
+		errs = append(errs, field.Forbidden(selectionPath.Child("name"), "name and selector are mutually exclusive"))
+	}
+	if selection.Selector.IsEmpty() {
+		errs = append(errs, field.Required(selectorPath, "the selector needs at least one criteria"))
+	}
+	if selection.Selector.LabelSelector != nil {
+		if _, err := metav1.LabelSelectorAsSelector(selection.Selector.LabelSelector); err != nil {
+			errs = append(errs, field.Invalid(selectorPath.Child("labelSelector"), selection.Selector.LabelSelector, err.Error()))
+		}
+	}
+	if control := selection.Selector.Control; control != nil {
+		if control.Standard == "" {
+			errs = append(errs, field.Required(selectorPath.Child("control", "standard"), ""))
+		}
+		if control.Control == "" {
+			errs = append(errs, field.Required(selectorPath.Child("control", "control"), ""))
+		}
+	}
+	return errs
+}
+
+func (v *tailoredProfileValidator) validateSetValues(ctx context.Context, tp *compliancev1alpha1.TailoredProfile, namespace string, pb *compliancev1alpha1.ProfileBundle, setValuesPath *field.Path) (field.ErrorList, error) {
+	errs := field.ErrorList{}
+	seen := make(map[string]bool)
+	for i, setValue := range tp.Spec.SetValues {
+		valuePath := setValuesPath.Index(i)
+		name, fieldErr, err := resolveReference(ctx, common.ResolveVariableName, v.client, namespace, setValue.Name, pb, valuePath.Child("name"))
+		if err != nil {
+			return nil, err
+		} else if fieldErr != nil {
+			errs = append(errs, fieldErr)
+		}
+		if name == "" {
+			continue
+		}
+		if seen[name] {
+			errs = append(errs, field.Duplicate(valuePath.Child("name"), setValue.Name))
+			continue
+		}
+		seen[name] = true
+
+		variable := &compliancev1alpha1.Variable{}
+		err = v.client.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, variable)
+		if err != nil {
+			return nil, err
+		}
+
+		// SetValue validates the value against the variable's type and
+		// constraints
+		if err := variable.SetValue(setValue.Value); err != nil {
+			errs = append(errs, field.Invalid(valuePath.Child("value"), setValue.Value, err.Error()))
+		}
+	}
+	return errs, nil
+}
+
+// tailoredProfileDefaulter sets the defaults of a TailoredProfile's spec and
+// records who last changed it
+type tailoredProfileDefaulter struct {
+	decoder *admission.Decoder
+}
+
+// InjectDecoder injects the decoder into the defaulter
+func (d *tailoredProfileDefaulter) InjectDecoder(decoder *admission.Decoder) error {
+	d.decoder = decoder
+	return nil
+}
+
+// Handle implements admission.Handler
+func (d *tailoredProfileDefaulter) Handle(ctx context.Context, req admission.Request) admission.Response {
+	tp := &compliancev1alpha1.TailoredProfile{}
+	if err := d.decoder.Decode(req, tp); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	setDefaults(tp)
+
+	// Record who changed the spec, so the revisions of the tailoring can
+	// tell who's behind them
+	specChanged := true
+	if req.Operation == admissionv1beta1.Update {
+		oldTP := &compliancev1alpha1.TailoredProfile{}
+		if err := d.decoder.DecodeRaw(req.OldObject, oldTP); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		specChanged = !equality.Semantic.DeepEqual(oldTP.Spec, tp.Spec)
+	}
+	if specChanged && req.UserInfo.Username != "" {
+		if tp.Annotations == nil {
+			tp.Annotations = map[string]string{}
+		}
+		tp.Annotations[compliancev1alpha1.LastModifiedByAnnotation] = req.UserInfo.Username
+	}
+
+	marshaled, err := json.Marshal(tp)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, marshaled)
+}
+
+func setDefaults(tp *compliancev1alpha1.TailoredProfile) {
+	if tp.Spec.OutputType == "" {
+		tp.Spec.OutputType = compliancev1alpha1.ConfigMapOutput
+	}
+}
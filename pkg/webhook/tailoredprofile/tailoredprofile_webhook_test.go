@@ -0,0 +1,188 @@
+package tailoredprofile
+
+import (
+	"context"
+
+	compliancev1alpha1 "github.com/JAORMX/compliance-profile-operator/pkg/apis/compliance/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Testing the TailoredProfile validation", func() {
+	var validator *tailoredProfileValidator
+	var tp *compliancev1alpha1.TailoredProfile
+
+	validate := func() field.ErrorList {
+		errs, err := validator.validate(context.TODO(), tp, "test")
+		Expect(err).ToNot(HaveOccurred())
+		return errs
+	}
+
+	// fieldErrors sums up the errors as "<type> <field>"
+	fieldErrors := func(errs field.ErrorList) []string {
+		summary := []string{}
+		for _, err := range errs {
+			summary = append(summary, string(err.Type)+" "+err.Field)
+		}
+		return summary
+	}
+
+	BeforeEach(func() {
+		validator = &tailoredProfileValidator{client: fake.NewFakeClientWithScheme(scheme.Scheme)}
+		tp = &compliancev1alpha1.TailoredProfile{
+			ObjectMeta: metav1.ObjectMeta{Name: "tailored", Namespace: "test"},
+			Spec: compliancev1alpha1.TailoredProfileSpec{
+				Extends: "ocp4-moderate",
+			},
+		}
+	})
+
+	It("accepts a valid TailoredProfile", func() {
+		Expect(validate()).To(BeEmpty())
+	})
+
+	Context("the extended object", func() {
+		It("needs to be set", func() {
+			tp.Spec.Extends = ""
+			Expect(fieldErrors(validate())).To(Equal([]string{"FieldValueRequired spec.extends"}))
+		})
+
+		It("can be a ProfileBundle instead", func() {
+			tp.Spec.Extends = ""
+			tp.Spec.ProfileBundle = "ocp4"
+			Expect(validate()).To(BeEmpty())
+		})
+
+		It("can't be set along with a ProfileBundle", func() {
+			tp.Spec.ProfileBundle = "ocp4"
+			Expect(fieldErrors(validate())).To(Equal([]string{"FieldValueForbidden spec.profileBundle"}))
+		})
+
+		It("needs to be of a known kind", func() {
+			tp.Spec.ExtendsKind = "ProfileBundle"
+			Expect(fieldErrors(validate())).To(Equal([]string{"FieldValueNotSupported spec.extendsKind"}))
+		})
+
+		It("can't be the TailoredProfile itself", func() {
+			tp.Spec.ExtendsKind = compliancev1alpha1.ExtendsTailoredProfile
+			tp.Spec.Extends = tp.Name
+			Expect(fieldErrors(validate())).To(Equal([]string{"FieldValueInvalid spec.extends"}))
+		})
+
+		It("can be a TailoredProfile of the same name in another namespace", func() {
+			validator.client = fake.NewFakeClientWithScheme(scheme.Scheme, &compliancev1alpha1.ReferenceGrant{
+				ObjectMeta: metav1.ObjectMeta{Name: "grant", Namespace: "content"},
+				Spec: compliancev1alpha1.ReferenceGrantSpec{
+					From: []compliancev1alpha1.ReferenceGrantFrom{{Namespace: "test"}},
+					To:   []compliancev1alpha1.ReferenceGrantTo{{Kind: compliancev1alpha1.ReferenceKindTailoredProfile}},
+				},
+			})
+			tp.Spec.ExtendsKind = compliancev1alpha1.ExtendsTailoredProfile
+			tp.Spec.Extends = tp.Name
+			tp.Spec.ContentNamespace = "content"
+			Expect(validate()).To(BeEmpty())
+		})
+
+		It("can't be in another namespace without a ReferenceGrant", func() {
+			tp.Spec.ContentNamespace = "content"
+			Expect(fieldErrors(validate())).To(Equal([]string{"FieldValueForbidden spec.contentNamespace"}))
+		})
+	})
+
+	Context("rule selectors", func() {
+		selectRules := func(selector *compliancev1alpha1.RuleSelector, name string) {
+			tp.Spec.EnableRules = []compliancev1alpha1.RuleReferenceSpec{{Name: name, Selector: selector, Rationale: "test"}}
+		}
+
+		It("accept valid selectors", func() {
+			selectRules(&compliancev1alpha1.RuleSelector{
+				LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"area": "network"}},
+				Severity:      "high",
+				Control:       &compliancev1alpha1.RuleControlSelector{Standard: "NIST-800-53", Control: "AC-2"},
+			}, "")
+			Expect(validate()).To(BeEmpty())
+		})
+
+		It("require a name or a selector", func() {
+			selectRules(nil, "")
+			Expect(fieldErrors(validate())).To(Equal([]string{"FieldValueRequired spec.enableRules[0].name"}))
+		})
+
+		It("can't be set along with a name", func() {
+			selectRules(&compliancev1alpha1.RuleSelector{Severity: "high"}, "ocp4-audit")
+			Expect(fieldErrors(validate())).To(Equal([]string{"FieldValueForbidden spec.enableRules[0].name"}))
+		})
+
+		It("need at least one criteria", func() {
+			selectRules(&compliancev1alpha1.RuleSelector{}, "")
+			Expect(fieldErrors(validate())).To(Equal([]string{"FieldValueRequired spec.enableRules[0].selector"}))
+		})
+
+		It("reject invalid label selectors", func() {
+			selectRules(&compliancev1alpha1.RuleSelector{
+				LabelSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "area", Operator: "Like", Values: []string{"network"}},
+				}},
+			}, "")
+			Expect(fieldErrors(validate())).To(Equal([]string{"FieldValueInvalid spec.enableRules[0].selector.labelSelector"}))
+		})
+
+		It("require both the standard and the control", func() {
+			selectRules(&compliancev1alpha1.RuleSelector{Control: &compliancev1alpha1.RuleControlSelector{}}, "")
+			Expect(fieldErrors(validate())).To(Equal([]string{
+				"FieldValueRequired spec.enableRules[0].selector.control.standard",
+				"FieldValueRequired spec.enableRules[0].selector.control.control",
+			}))
+		})
+	})
+
+	Context("rule refinements", func() {
+		BeforeEach(func() {
+			validator.client = fake.NewFakeClientWithScheme(scheme.Scheme, &compliancev1alpha1.Rule{
+				ObjectMeta: metav1.ObjectMeta{Name: "ocp4-sshd-set-idle-timeout", Namespace: "test"},
+				ID:         "xccdf_org.ssgproject.content_rule_sshd_set_idle_timeout",
+				Selectors:  []string{"lenient", "strict"},
+			})
+		})
+
+		refine := func(selector string) {
+			tp.Spec.RefineRules = []compliancev1alpha1.RuleRefinementSpec{
+				{Name: "ocp4-sshd-set-idle-timeout", Selector: selector, Rationale: "test"},
+			}
+		}
+
+		It("accept the selectors of the rule", func() {
+			refine("strict")
+			Expect(validate()).To(BeEmpty())
+		})
+
+		It("reject unknown selectors", func() {
+			refine("stirct")
+			Expect(fieldErrors(validate())).To(Equal([]string{"FieldValueInvalid spec.refineRules[0].selector"}))
+		})
+	})
+
+	Context("the Secret output", func() {
+		BeforeEach(func() {
+			tp.Spec.OutputType = compliancev1alpha1.SecretOutput
+		})
+
+		It("is accepted without options", func() {
+			Expect(validate()).To(BeEmpty())
+		})
+
+		It("can't be used with policyOptions or scanSettings", func() {
+			tp.Spec.PolicyOptions = &compliancev1alpha1.PolicyOptions{}
+			tp.Spec.ScanSettings = &compliancev1alpha1.ScanSettings{}
+			Expect(fieldErrors(validate())).To(Equal([]string{
+				"FieldValueForbidden spec.policyOptions",
+				"FieldValueForbidden spec.scanSettings",
+			}))
+		})
+	})
+})
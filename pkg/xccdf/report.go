@@ -0,0 +1,126 @@
+package xccdf
+
+import (
+	"fmt"
+	"strings"
+
+	cmpv1alpha1 "github.com/JAORMX/compliance-profile-operator/pkg/apis/compliance/v1alpha1"
+)
+
+const noRationale string = "No rationale given"
+
+// TailoredProfileToJustificationReport gets a readable report, in Markdown, of
+// why the rules and values of the tailoring were selected, set and refined.
+// It takes the same arguments as TailoredProfileToXML and lists the same
+// elements in the same order, so it can be handed to auditors along with the
+// tailoring.
+func TailoredProfileToJustificationReport(tp *cmpv1alpha1.TailoredProfile, p *cmpv1alpha1.Profile, pb *cmpv1alpha1.ProfileBundle, rules map[string]*cmpv1alpha1.Rule, variables []*cmpv1alpha1.Variable, refinedVariables map[string]*cmpv1alpha1.Variable) string {
+	tailoring := newTailoringElement(tp, p, pb, rules, variables, refinedVariables)
+
+	titles := make(map[string]string)
+	for _, rule := range rules {
+		titles[rule.ID] = rule.Title
+	}
+	for _, variable := range variables {
+		titles[variable.ID] = variable.Title
+	}
+	for _, variable := range refinedVariables {
+		titles[variable.ID] = variable.Title
+	}
+
+	report := &strings.Builder{}
+	fmt.Fprintf(report, "# Justification of the tailored profile %s\n\n", tp.Name)
+	fmt.Fprintf(report, "Tailoring %s, version %s of %s.\n", tailoring.ID, tailoring.Version.Value, tailoring.Version.Time)
+	if p != nil {
+		fmt.Fprintf(report, "Extends the profile %s.\n", p.ID)
+	} else {
+		fmt.Fprintf(report, "Built from scratch out of the ProfileBundle %s.\n", pb.Name)
+	}
+
+	enabled := []string{}
+	disabled := []string{}
+	for _, selection := range tailoring.Profile.Selections {
+		item := getReportItem(selection.IDRef, titles, "", getRemarksText(selection.Remarks))
+		if selection.Selected {
+			enabled = append(enabled, item)
+		} else {
+			disabled = append(disabled, item)
+		}
+	}
+	writeReportSection(report, "Enabled rules", enabled)
+	writeReportSection(report, "Disabled rules", disabled)
+
+	valueRationales := make(map[string]string)
+	if tailoring.Profile.Metadata != nil {
+		for _, rationale := range tailoring.Profile.Metadata.ValueRationales {
+			valueRationales[rationale.IDRef] = rationale.Value
+		}
+	}
+	values := []string{}
+	for _, value := range tailoring.Profile.Values {
+		details := fmt.Sprintf("set to %q", value.Value)
+		values = append(values, getReportItem(value.IDRef, titles, details, valueRationales[value.IDRef]))
+	}
+	writeReportSection(report, "Set values", values)
+
+	ruleRefinements := []string{}
+	for _, refinement := range tailoring.Profile.RefineRules {
+		details := getRefinementDetails("severity", refinement.Severity, "weight", refinement.Weight,
+			"role", refinement.Role, "selector", refinement.Selector)
+		ruleRefinements = append(ruleRefinements, getReportItem(refinement.IDRef, titles, details, getRemarksText(refinement.Remarks)))
+	}
+	writeReportSection(report, "Refined rules", ruleRefinements)
+
+	valueRefinements := []string{}
+	for _, refinement := range tailoring.Profile.RefineValues {
+		details := getRefinementDetails("operator", refinement.Operator, "selector", refinement.Selector)
+		valueRefinements = append(valueRefinements, getReportItem(refinement.IDRef, titles, details, getRemarksText(refinement.Remarks)))
+	}
+	writeReportSection(report, "Refined values", valueRefinements)
+
+	return report.String()
+}
+
+func getRemarksText(remarks []RemarkElement) string {
+	texts := []string{}
+	for _, remark := range remarks {
+		texts = append(texts, remark.Value)
+	}
+	return strings.Join(texts, " ")
+}
+
+// getRefinementDetails formats the given pairs of refined properties and
+// values, skipping the properties that aren't refined
+func getRefinementDetails(pairs ...string) string {
+	details := []string{}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if pairs[i+1] != "" {
+			details = append(details, fmt.Sprintf("%s %s", pairs[i], pairs[i+1]))
+		}
+	}
+	return strings.Join(details, ", ")
+}
+
+func getReportItem(id string, titles map[string]string, details, rationale string) string {
+	item := "`" + id + "`"
+	if titles[id] != "" {
+		item += " " + titles[id]
+	}
+	if details != "" {
+		item += " (" + details + ")"
+	}
+	if rationale == "" {
+		rationale = noRationale
+	}
+	return item + ": " + rationale
+}
+
+func writeReportSection(report *strings.Builder, title string, items []string) {
+	if len(items) == 0 {
+		return
+	}
+	fmt.Fprintf(report, "\n## %s\n\n", title)
+	for _, item := range items {
+		fmt.Fprintf(report, "* %s\n", item)
+	}
+}
@@ -1,39 +1,341 @@
 package xccdf
 
-import "encoding/xml"
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// DefaultLang is the language assumed for texts that don't specify one
+const DefaultLang string = "en-US"
+
+type localizedText struct {
+	XMLName xml.Name
+	Lang    string `xml:"lang,attr,omitempty"`
+	Value   string `xml:",innerxml"`
+}
+
+func getLocalizedTextFromXMLString(raw, elementName string) (string, string, error) {
+	obj := &localizedText{}
+	if err := xml.Unmarshal([]byte(raw), obj); err != nil {
+		return "", "", err
+	}
+	if obj.XMLName.Local != elementName {
+		return "", "", fmt.Errorf("expected element type <%s> but have <%s>", elementName, obj.XMLName.Local)
+	}
+	return obj.Lang, obj.Value, nil
+}
 
 // GetDescriptionFromXMLString gets a description from the given XML string
 func GetDescriptionFromXMLString(raw string) (string, error) {
-	type Description struct {
-		XMLName xml.Name `xml:"description"`
-		Lang    string   `xml:"lang,attr,omitempty"`
-		Value   string   `xml:",innerxml"`
-	}
-	obj := &Description{}
-	err := xml.Unmarshal([]byte(raw), obj)
-	return obj.Value, err
+	_, desc, err := GetLocalizedDescriptionFromXMLString(raw)
+	return desc, err
+}
+
+// GetLocalizedDescriptionFromXMLString gets the language and the description
+// from the given XML string
+func GetLocalizedDescriptionFromXMLString(raw string) (string, string, error) {
+	return getLocalizedTextFromXMLString(raw, "description")
 }
 
 // GetRationaleFromXMLString gets the rationale from the given XML string
 func GetRationaleFromXMLString(raw string) (string, error) {
-	type Rationale struct {
-		XMLName xml.Name `xml:"rationale"`
-		Lang    string   `xml:"lang,attr,omitempty"`
-		Value   string   `xml:",innerxml"`
-	}
-	obj := &Rationale{}
-	err := xml.Unmarshal([]byte(raw), obj)
-	return obj.Value, err
+	_, rat, err := GetLocalizedRationaleFromXMLString(raw)
+	return rat, err
+}
+
+// GetLocalizedRationaleFromXMLString gets the language and the rationale from
+// the given XML string
+func GetLocalizedRationaleFromXMLString(raw string) (string, string, error) {
+	return getLocalizedTextFromXMLString(raw, "rationale")
 }
 
 // GetWarningFromXMLString gets a warning from the given XML string
 func GetWarningFromXMLString(raw string) (string, error) {
-	type Warning struct {
-		XMLName xml.Name `xml:"warning"`
-		Lang    string   `xml:"lang,attr,omitempty"`
-		Value   string   `xml:",innerxml"`
-	}
-	obj := &Warning{}
-	err := xml.Unmarshal([]byte(raw), obj)
-	return obj.Value, err
+	_, warn, err := GetLocalizedWarningFromXMLString(raw)
+	return warn, err
+}
+
+// GetLocalizedWarningFromXMLString gets the language and the warning from the
+// given XML string
+func GetLocalizedWarningFromXMLString(raw string) (string, string, error) {
+	return getLocalizedTextFromXMLString(raw, "warning")
+}
+
+// SubResolverFn gets the text that an XCCDF <sub idref="..."/> reference
+// should be rendered as
+type SubResolverFn func(idref string) string
+
+// XHTMLToMarkdown renders an XCCDF text, which may contain XHTML markup
+// (e.g. a rule's description), as Markdown. <sub> references are rendered
+// through the given resolver; if it's nil, the idref itself is used.
+func XHTMLToMarkdown(raw string, resolveSub SubResolverFn) (string, error) {
+	return renderXHTML(raw, true, resolveSub)
+}
+
+// XHTMLToPlainText renders an XCCDF text, which may contain XHTML markup
+// (e.g. a rule's description), as plain text. <sub> references are rendered
+// through the given resolver; if it's nil, the idref itself is used.
+func XHTMLToPlainText(raw string, resolveSub SubResolverFn) (string, error) {
+	return renderXHTML(raw, false, resolveSub)
+}
+
+type xhtmlList struct {
+	ordered bool
+	items   int
+}
+
+type xhtmlRenderer struct {
+	markdown   bool
+	resolveSub SubResolverFn
+	out        []byte
+	preDepth   int
+	lists      []xhtmlList
+	links      []string
+	// whether markup was just opened, so leading spaces are dropped
+	justOpened bool
+}
+
+var (
+	trailingSpacesRegexp = regexp.MustCompile(`(?m)[ \t]+$`)
+	blankLinesRegexp     = regexp.MustCompile(`\n{3,}`)
+)
+
+func renderXHTML(raw string, markdown bool, resolveSub SubResolverFn) (string, error) {
+	// The text is an XML fragment that might use namespace prefixes
+	// without declaring them, so we parse it leniently.
+	decoder := xml.NewDecoder(strings.NewReader("<root>" + raw + "</root>"))
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+
+	r := &xhtmlRenderer{markdown: markdown, resolveSub: resolveSub}
+	for {
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			r.start(t)
+		case xml.EndElement:
+			r.end(t)
+		case xml.CharData:
+			r.text(string(t))
+		}
+	}
+
+	out := trailingSpacesRegexp.ReplaceAll(r.out, nil)
+	out = blankLinesRegexp.ReplaceAll(out, []byte("\n\n"))
+	return strings.TrimSpace(string(out)), nil
+}
+
+func (r *xhtmlRenderer) start(el xml.StartElement) {
+	switch el.Name.Local {
+	case "br":
+		r.newline()
+	case "p", "div":
+		r.paragraph()
+	case "pre":
+		r.paragraph()
+		if r.markdown {
+			r.write("```\n")
+		}
+		r.preDepth++
+	case "code", "tt", "kbd", "samp", "var":
+		if r.preDepth == 0 {
+			r.open("`")
+		}
+	case "b", "strong":
+		r.open("**")
+	case "i", "em":
+		r.open("*")
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		r.paragraph()
+		level := int(el.Name.Local[1] - '0')
+		r.open(strings.Repeat("#", level) + " ")
+	case "ul", "ol":
+		if len(r.lists) == 0 {
+			r.paragraph()
+		} else {
+			r.newline()
+		}
+		r.lists = append(r.lists, xhtmlList{ordered: el.Name.Local == "ol"})
+	case "li":
+		r.newline()
+		if len(r.lists) == 0 {
+			r.write("- ")
+			break
+		}
+		list := &r.lists[len(r.lists)-1]
+		list.items++
+		r.write(strings.Repeat("  ", len(r.lists)-1))
+		if list.ordered {
+			r.write(fmt.Sprintf("%d. ", list.items))
+		} else {
+			r.write("- ")
+		}
+		r.justOpened = true
+	case "a":
+		r.links = append(r.links, getAttr(el, "href"))
+		r.open("[")
+	case "sub":
+		idref := getAttr(el, "idref")
+		text := idref
+		if r.resolveSub != nil {
+			text = r.resolveSub(idref)
+		}
+		r.write(text)
+		r.justOpened = false
+	}
+}
+
+func (r *xhtmlRenderer) end(el xml.EndElement) {
+	switch el.Name.Local {
+	case "p", "div":
+		r.paragraph()
+	case "pre":
+		r.preDepth--
+		if r.markdown {
+			r.newline()
+			r.write("```")
+		}
+		r.paragraph()
+	case "code", "tt", "kbd", "samp", "var":
+		if r.preDepth == 0 {
+			r.close("`")
+		}
+	case "b", "strong":
+		r.close("**")
+	case "i", "em":
+		r.close("*")
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		r.paragraph()
+	case "ul", "ol":
+		if len(r.lists) > 0 {
+			r.lists = r.lists[:len(r.lists)-1]
+		}
+		if len(r.lists) == 0 {
+			r.paragraph()
+		} else {
+			r.newline()
+		}
+	case "a":
+		href := ""
+		if len(r.links) > 0 {
+			href = r.links[len(r.links)-1]
+			r.links = r.links[:len(r.links)-1]
+		}
+		suffix := ""
+		if r.markdown {
+			suffix = "](" + href + ")"
+		} else if href != "" {
+			suffix = " (" + href + ")"
+		}
+		r.closeWith(suffix)
+	}
+}
+
+func (r *xhtmlRenderer) text(data string) {
+	if r.preDepth > 0 {
+		r.write(data)
+		return
+	}
+
+	collapsed := strings.Join(strings.Fields(data), " ")
+	if collapsed == "" {
+		if data != "" {
+			r.space()
+		}
+		return
+	}
+	if startsWithSpace(data) {
+		r.space()
+	}
+	r.write(collapsed)
+	r.justOpened = false
+	if endsWithSpace(data) {
+		r.space()
+	}
+}
+
+// open writes markup that is opening an inline element
+func (r *xhtmlRenderer) open(markup string) {
+	if r.markdown {
+		r.write(markup)
+	}
+	r.justOpened = true
+}
+
+// close writes markup that is closing an inline element
+func (r *xhtmlRenderer) close(markup string) {
+	if !r.markdown {
+		markup = ""
+	}
+	r.closeWith(markup)
+}
+
+// closeWith writes the given text at the end of an inline element. Spaces
+// are kept outside of the element.
+func (r *xhtmlRenderer) closeWith(text string) {
+	trimmed := strings.TrimRight(string(r.out), " ")
+	hadSpace := len(trimmed) != len(r.out)
+	r.out = []byte(trimmed)
+	r.write(text)
+	if hadSpace {
+		r.write(" ")
+	}
+	r.justOpened = false
+}
+
+func (r *xhtmlRenderer) space() {
+	if r.justOpened || len(r.out) == 0 {
+		return
+	}
+	switch r.out[len(r.out)-1] {
+	case ' ', '\n':
+		return
+	}
+	r.write(" ")
+}
+
+func (r *xhtmlRenderer) newline() {
+	r.out = []byte(strings.TrimRight(string(r.out), " "))
+	if len(r.out) > 0 && r.out[len(r.out)-1] != '\n' {
+		r.write("\n")
+	}
+}
+
+func (r *xhtmlRenderer) paragraph() {
+	r.newline()
+	if len(r.out) > 0 && !strings.HasSuffix(string(r.out), "\n\n") {
+		r.write("\n")
+	}
+}
+
+func (r *xhtmlRenderer) write(s string) {
+	r.out = append(r.out, s...)
+}
+
+func startsWithSpace(s string) bool {
+	return strings.TrimLeftFunc(s, unicode.IsSpace) != s
+}
+
+func endsWithSpace(s string) bool {
+	return strings.TrimRightFunc(s, unicode.IsSpace) != s
+}
+
+func getAttr(el xml.StartElement, name string) string {
+	for _, attr := range el.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
 }
@@ -20,6 +20,14 @@ in <html:code>/etc/audit/auditd.conf</html:code>.`
 			Expect(str).To(Equal(expected))
 		})
 
+		It("gets the language of the description", func() {
+			desc := `<xccdf-1.2:description xml:lang="de-DE">Setzen Sie <html:code>freq</html:code> auf <html:code>50</html:code>.</xccdf-1.2:description>`
+			lang, str, err := GetLocalizedDescriptionFromXMLString(desc)
+			Expect(err).To(BeNil())
+			Expect(lang).To(Equal("de-DE"))
+			Expect(str).To(Equal(`Setzen Sie <html:code>freq</html:code> auf <html:code>50</html:code>.`))
+		})
+
 		It("fails if wrong object given", func() {
 			desc := `<xccdf-1.2:warning xml:lang="en-US" category="management">When the <html:code>PodSecurityPolicy</html:code> admission plugin is in use, there
 needs to be at least one <html:code>PodSecurityPolicy</html:code> in place for ANY pods to
@@ -62,4 +70,49 @@ be admitted.`
 			Expect(str).To(Equal(expected))
 		})
 	})
+
+	Context("Rendering XHTML", func() {
+		const raw = `Set the banner to <sub idref="xccdf_org.ssgproject.content_value_login_banner_text" use="legacy"/>
+by editing <html:code>/etc/issue</html:code>:<html:br/>
+<html:pre>$ sudo vi /etc/issue</html:pre>
+This is <html:b>required</html:b> for:
+<html:ul><html:li>console logins</html:li><html:li>SSH logins</html:li></html:ul>
+See <html:a href="https://example.com/banner">the docs</html:a>.`
+		resolveSub := func(idref string) string {
+			return "Login Banner Verbiage"
+		}
+
+		It("renders Markdown", func() {
+			expected := "Set the banner to Login Banner Verbiage by editing `/etc/issue`:\n\n" +
+				"```\n$ sudo vi /etc/issue\n```\n\n" +
+				"This is **required** for:\n\n" +
+				"- console logins\n- SSH logins\n\n" +
+				"See [the docs](https://example.com/banner)."
+			str, err := XHTMLToMarkdown(raw, resolveSub)
+			Expect(err).To(BeNil())
+			Expect(str).To(Equal(expected))
+		})
+
+		It("renders plain text", func() {
+			expected := "Set the banner to xccdf_org.ssgproject.content_value_login_banner_text by editing /etc/issue:\n\n" +
+				"$ sudo vi /etc/issue\n\n" +
+				"This is required for:\n\n" +
+				"- console logins\n- SSH logins\n\n" +
+				"See the docs (https://example.com/banner)."
+			str, err := XHTMLToPlainText(raw, nil)
+			Expect(err).To(BeNil())
+			Expect(str).To(Equal(expected))
+		})
+
+		It("collapses the line wrapping of the source", func() {
+			desc := `To configure Audit daemon to issue an explicit flush to disk command
+after writing 50 records, set <html:code>freq</html:code> to <html:code>50</html:code>
+in <html:code>/etc/audit/auditd.conf</html:code>.`
+			expected := "To configure Audit daemon to issue an explicit flush to disk command " +
+				"after writing 50 records, set `freq` to `50` in `/etc/audit/auditd.conf`."
+			str, err := XHTMLToMarkdown(desc, nil)
+			Expect(err).To(BeNil())
+			Expect(str).To(Equal(expected))
+		})
+	})
 })
@@ -1,9 +1,14 @@
 package xccdf
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/xml"
 	"fmt"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -20,8 +25,15 @@ const (
 	// specification, this assiciates the content with the author
 	XCCDFNamespace string = "compliance.openshift.io"
 	XCCDFURI       string = "http://checklists.nist.gov/xccdf/1.2"
+	// MetadataURI is the namespace of the profile metadata written by this
+	// project
+	MetadataURI string = "http://compliance.openshift.io/tailoring"
 )
 
+// versionRegexp matches the version of a tailoring, along with the time it
+// was generated at
+var versionRegexp = regexp.MustCompile(`(<xccdf-1.2:version)[^>]*>[^<]*(</xccdf-1.2:version>)`)
+
 type TailoringElement struct {
 	XMLName         xml.Name `xml:"xccdf-1.2:Tailoring"`
 	XMLNamespaceURI string   `xml:"xmlns:xccdf-1.2,attr"`
@@ -48,24 +60,50 @@ type VersionElement struct {
 }
 
 type ProfileElement struct {
-	XMLName     xml.Name                   `xml:"xccdf-1.2:Profile"`
-	ID          string                     `xml:"id,attr"`
-	Extends     string                     `xml:"extends,attr"`
-	Title       *TitleOrDescriptionElement `xml:"xccdf-1.2:title,omitempty"`
-	Description *TitleOrDescriptionElement `xml:"xccdf-1.2:description,omitempty"`
-	Selections  []SelectElement
-	Values      []SetValueElement
+	XMLName      xml.Name                    `xml:"xccdf-1.2:Profile"`
+	ID           string                      `xml:"id,attr"`
+	Extends      string                      `xml:"extends,attr,omitempty"`
+	Titles       []TitleOrDescriptionElement `xml:"xccdf-1.2:title,omitempty"`
+	Descriptions []TitleOrDescriptionElement `xml:"xccdf-1.2:description,omitempty"`
+	Selections   []SelectElement
+	Values       []SetValueElement
+	RefineValues []RefineValueElement
+	RefineRules  []RefineRuleElement
+	Metadata     *MetadataElement
 }
 
 type TitleOrDescriptionElement struct {
+	Lang     string `xml:"xml:lang,attr,omitempty"`
 	Override bool   `xml:"override,attr"`
 	Value    string `xml:",chardata"`
 }
 
 type SelectElement struct {
-	XMLName  xml.Name `xml:"xccdf-1.2:select"`
-	IDRef    string   `xml:"idref,attr"`
-	Selected bool     `xml:"selected,attr"`
+	XMLName  xml.Name        `xml:"xccdf-1.2:select"`
+	IDRef    string          `xml:"idref,attr"`
+	Selected bool            `xml:"selected,attr"`
+	Remarks  []RemarkElement `xml:",omitempty"`
+}
+
+// RemarkElement holds the rationale of a selection or refinement
+type RemarkElement struct {
+	XMLName xml.Name `xml:"xccdf-1.2:remark"`
+	Value   string   `xml:",chardata"`
+}
+
+// MetadataElement holds the rationales that XCCDF has no remark for, i.e.
+// the ones of the set values
+type MetadataElement struct {
+	XMLName         xml.Name                `xml:"xccdf-1.2:metadata"`
+	XMLNamespaceURI string                  `xml:"xmlns:cpo,attr"`
+	ValueRationales []ValueRationaleElement `xml:",omitempty"`
+}
+
+// ValueRationaleElement holds the rationale of setting a value
+type ValueRationaleElement struct {
+	XMLName xml.Name `xml:"cpo:value-rationale"`
+	IDRef   string   `xml:"idref,attr"`
+	Value   string   `xml:",chardata"`
 }
 
 type SetValueElement struct {
@@ -74,6 +112,24 @@ type SetValueElement struct {
 	Value   string   `xml:",chardata"`
 }
 
+type RefineValueElement struct {
+	XMLName  xml.Name        `xml:"xccdf-1.2:refine-value"`
+	IDRef    string          `xml:"idref,attr"`
+	Selector string          `xml:"selector,attr,omitempty"`
+	Operator string          `xml:"operator,attr,omitempty"`
+	Remarks  []RemarkElement `xml:",omitempty"`
+}
+
+type RefineRuleElement struct {
+	XMLName  xml.Name        `xml:"xccdf-1.2:refine-rule"`
+	IDRef    string          `xml:"idref,attr"`
+	Weight   string          `xml:"weight,attr,omitempty"`
+	Selector string          `xml:"selector,attr,omitempty"`
+	Severity string          `xml:"severity,attr,omitempty"`
+	Role     string          `xml:"role,attr,omitempty"`
+	Remarks  []RemarkElement `xml:",omitempty"`
+}
+
 // GetXCCDFProfileID gets a profile xccdf ID from the TailoredProfile object
 func GetXCCDFProfileID(tp *cmpv1alpha1.TailoredProfile) string {
 	return fmt.Sprintf("xccdf_%s_profile_%s", XCCDFNamespace, tp.Name)
@@ -96,28 +152,64 @@ func GetVariableNameFromID(id string) string {
 	return strings.ToLower(strings.ReplaceAll(trimedName, "_", "-"))
 }
 
+// GetShortNameFromID gets the short name of an xccdf ID, i.e. the ID without
+// the "xccdf_<namespace>_<type>_" prefix. The namespace is taken from the ID
+// itself, so this works for any content. Dashes are replaced by underscores,
+// so that "chronyd-client-only" and "chronyd_client_only" are the same short
+// name. IDs that aren't xccdf IDs have no short name.
+func GetShortNameFromID(id string) string {
+	if !strings.HasPrefix(id, "xccdf_") {
+		return ""
+	}
+	// The namespace is a reverse DNS name and the type is a single word, so
+	// neither contains underscores
+	parts := strings.SplitN(strings.TrimPrefix(id, "xccdf_"), "_", 3)
+	if len(parts) < 3 {
+		return ""
+	}
+	return NormalizeShortName(parts[2])
+}
+
+// NormalizeShortName normalizes the short name of a rule or variable, as
+// given by a user, to the way GetShortNameFromID returns it
+func NormalizeShortName(name string) string {
+	return strings.ReplaceAll(name, "-", "_")
+}
+
 func getTailoringID(tp *cmpv1alpha1.TailoredProfile) string {
 	return fmt.Sprintf("xccdf_%s_tailoring_%s", XCCDFNamespace, tp.Name)
 }
 
-func getSelectElementFromCRRule(rule *cmpv1alpha1.Rule, enable bool) SelectElement {
+func getSelectElementFromCRRule(rule *cmpv1alpha1.Rule, enable bool, rationale string) SelectElement {
 	return SelectElement{
 		IDRef:    rule.ID,
 		Selected: enable,
+		Remarks:  getRemarks(rationale),
 	}
 }
 
+// getRemarks gets the remarks holding the given rationale, if any
+func getRemarks(rationale string) []RemarkElement {
+	if rationale == "" {
+		return nil
+	}
+	return []RemarkElement{{Value: rationale}}
+}
+
 func getSelections(tp *cmpv1alpha1.TailoredProfile, rules map[string]*cmpv1alpha1.Rule) []SelectElement {
 	selections := []SelectElement{}
 	for _, selection := range tp.Spec.EnableRules {
 		rule := rules[selection.Name]
-		selections = append(selections, getSelectElementFromCRRule(rule, true))
+		selections = append(selections, getSelectElementFromCRRule(rule, true, selection.Rationale))
 	}
 
 	for _, selection := range tp.Spec.DisableRules {
 		rule := rules[selection.Name]
-		selections = append(selections, getSelectElementFromCRRule(rule, false))
+		selections = append(selections, getSelectElementFromCRRule(rule, false, selection.Rationale))
 	}
+	sort.SliceStable(selections, func(i, j int) bool {
+		return selections[i].IDRef < selections[j].IDRef
+	})
 	return selections
 }
 
@@ -130,20 +222,152 @@ func getValuesFromVariables(variables []*cmpv1alpha1.Variable) []SetValueElement
 			Value: varObj.Value,
 		})
 	}
+	sort.SliceStable(values, func(i, j int) bool {
+		return values[i].IDRef < values[j].IDRef
+	})
 
 	return values
 }
 
-// TailoredProfileToXML gets an XML string from a TailoredProfile and the corresponding Profile
-func TailoredProfileToXML(tp *cmpv1alpha1.TailoredProfile, p *cmpv1alpha1.Profile, pb *cmpv1alpha1.ProfileBundle, rules map[string]*cmpv1alpha1.Rule, variables []*cmpv1alpha1.Variable) (string, error) {
+// getValueRationales gets the metadata holding the rationales of the set
+// values, sorted by ID. It's nil if no value has a rationale.
+func getValueRationales(tp *cmpv1alpha1.TailoredProfile, variables []*cmpv1alpha1.Variable) *MetadataElement {
+	rationales := make(map[string]string)
+	for _, setValue := range tp.Spec.SetValues {
+		rationales[setValue.Name] = setValue.Rationale
+	}
+
+	elements := []ValueRationaleElement{}
+	for _, varObj := range variables {
+		if rationales[varObj.Name] == "" {
+			continue
+		}
+		elements = append(elements, ValueRationaleElement{
+			IDRef: varObj.ID,
+			Value: rationales[varObj.Name],
+		})
+	}
+	if len(elements) == 0 {
+		return nil
+	}
+	sort.SliceStable(elements, func(i, j int) bool {
+		return elements[i].IDRef < elements[j].IDRef
+	})
+	return &MetadataElement{
+		XMLNamespaceURI: MetadataURI,
+		ValueRationales: elements,
+	}
+}
+
+func getRuleRefinements(tp *cmpv1alpha1.TailoredProfile, rules map[string]*cmpv1alpha1.Rule) []RefineRuleElement {
+	refinements := []RefineRuleElement{}
+	for _, refinement := range tp.Spec.RefineRules {
+		rule := rules[refinement.Name]
+		refinements = append(refinements, RefineRuleElement{
+			IDRef:    rule.ID,
+			Weight:   refinement.Weight,
+			Selector: refinement.Selector,
+			Severity: refinement.Severity,
+			Role:     string(refinement.Role),
+			Remarks:  getRemarks(refinement.Rationale),
+		})
+	}
+	sort.SliceStable(refinements, func(i, j int) bool {
+		return refinements[i].IDRef < refinements[j].IDRef
+	})
+	return refinements
+}
+
+func getValueRefinements(tp *cmpv1alpha1.TailoredProfile, refinedVariables map[string]*cmpv1alpha1.Variable) []RefineValueElement {
+	refinements := []RefineValueElement{}
+	for _, refinement := range tp.Spec.RefineValues {
+		variable := refinedVariables[refinement.Name]
+		refinements = append(refinements, RefineValueElement{
+			IDRef:    variable.ID,
+			Selector: refinement.Selector,
+			Operator: string(refinement.Operator),
+			Remarks:  getRemarks(refinement.Rationale),
+		})
+	}
+	sort.SliceStable(refinements, func(i, j int) bool {
+		return refinements[i].IDRef < refinements[j].IDRef
+	})
+	return refinements
+}
+
+// getLocalizedOverrides returns the elements overriding a title or description:
+// the text itself in the default language followed by its translations,
+// sorted by language.
+func getLocalizedOverrides(text string, translations cmpv1alpha1.LocalizedTexts) []TitleOrDescriptionElement {
+	elements := []TitleOrDescriptionElement{}
+	if text != "" {
+		elements = append(elements, TitleOrDescriptionElement{
+			Lang:     DefaultLang,
+			Override: true,
+			Value:    text,
+		})
+	}
+
+	langs := make([]string, 0, len(translations))
+	for lang := range translations {
+		if lang == DefaultLang && text != "" {
+			// The text itself takes precedence
+			continue
+		}
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	for _, lang := range langs {
+		elements = append(elements, TitleOrDescriptionElement{
+			Lang:     lang,
+			Override: true,
+			Value:    translations[lang],
+		})
+	}
+	return elements
+}
+
+// getVersion gets the version element of the tailoring from the version
+// recorded in the TailoredProfile's status. A TailoredProfile that wasn't
+// versioned yet gets the first version, generated now.
+func getVersion(tp *cmpv1alpha1.TailoredProfile) VersionElement {
+	version := tp.Status.Version
+	if version == 0 {
+		version = 1
+	}
+	versionTime := time.Now()
+	if tp.Status.VersionTime != nil {
+		versionTime = tp.Status.VersionTime.Time
+	}
+	return VersionElement{
+		Time:  versionTime.UTC().Format(time.RFC3339),
+		Value: strconv.FormatInt(version, 10),
+	}
+}
+
+// TailoredProfileToXML gets an XML string from a TailoredProfile and the corresponding Profile.
+// The Profile is nil for TailoredProfiles built from scratch, which don't
+// extend any profile. The rules map needs to contain both the selected and
+// the refined rules, while refinedVariables contains the refined variables;
+// both are keyed by name. The rationales are written as remarks, except for
+// the ones of the set values, which go to the profile's metadata. The version
+// of the tailoring is taken from the TailoredProfile's status. Selections,
+// values and refinements are sorted by ID, so the same input always renders
+// the same XML.
+func TailoredProfileToXML(tp *cmpv1alpha1.TailoredProfile, p *cmpv1alpha1.Profile, pb *cmpv1alpha1.ProfileBundle, rules map[string]*cmpv1alpha1.Rule, variables []*cmpv1alpha1.Variable, refinedVariables map[string]*cmpv1alpha1.Variable) (string, error) {
+	tailoring := newTailoringElement(tp, p, pb, rules, variables, refinedVariables)
+	output, err := xml.MarshalIndent(tailoring, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return XMLHeader + "\n" + string(output), nil
+}
+
+func newTailoringElement(tp *cmpv1alpha1.TailoredProfile, p *cmpv1alpha1.Profile, pb *cmpv1alpha1.ProfileBundle, rules map[string]*cmpv1alpha1.Rule, variables []*cmpv1alpha1.Variable, refinedVariables map[string]*cmpv1alpha1.Variable) TailoringElement {
 	tailoring := TailoringElement{
 		XMLNamespaceURI: XCCDFURI,
 		ID:              getTailoringID(tp),
-		Version: VersionElement{
-			Time: time.Now().Format(time.RFC3339),
-			// TODO(jaosorior): Establish a TailoredProfile versioning mechanism
-			Value: "1",
-		},
+		Version:         getVersion(tp),
 		Benchmark: BenchmarkElement{
 			// NOTE(jaosorior): Both this operator and the compliance-operator
 			// assume the content will be mounted on a "content/" directory
@@ -151,27 +375,32 @@ func TailoredProfileToXML(tp *cmpv1alpha1.TailoredProfile, p *cmpv1alpha1.Profil
 		},
 		Profile: ProfileElement{
 			ID:         GetXCCDFProfileID(tp),
-			Extends:    p.ID,
 			Selections: getSelections(tp, rules),
 			Values:     getValuesFromVariables(variables),
 		},
 	}
-	if tp.Spec.Title != "" {
-		tailoring.Profile.Title = &TitleOrDescriptionElement{
-			Override: true,
-			Value:    tp.Spec.Title,
-		}
+	tailoring.Profile.RefineValues = getValueRefinements(tp, refinedVariables)
+	tailoring.Profile.RefineRules = getRuleRefinements(tp, rules)
+	tailoring.Profile.Metadata = getValueRationales(tp, variables)
+	if p != nil {
+		tailoring.Profile.Extends = p.ID
 	}
-	if tp.Spec.Description != "" {
-		tailoring.Profile.Description = &TitleOrDescriptionElement{
-			Override: true,
-			Value:    tp.Spec.Description,
-		}
+	title := tp.Spec.Title
+	if p == nil && title == "" && len(tp.Spec.TitleTranslations) == 0 {
+		// A profile that doesn't extend another one has no title to
+		// inherit, while XCCDF requires one
+		title = tp.Name
 	}
+	tailoring.Profile.Titles = getLocalizedOverrides(title, tp.Spec.TitleTranslations)
+	tailoring.Profile.Descriptions = getLocalizedOverrides(tp.Spec.Description, tp.Spec.DescriptionTranslations)
+	return tailoring
+}
 
-	output, err := xml.MarshalIndent(tailoring, "", "  ")
-	if err != nil {
-		return "", err
-	}
-	return XMLHeader + "\n" + string(output), nil
+// GetTailoringContentHash gets a hash of the given tailoring XML. Neither the
+// version of the tailoring nor the time it was generated at are taken into
+// account, so the hash only changes when the actual content does.
+func GetTailoringContentHash(tailoringXML string) string {
+	normalized := versionRegexp.ReplaceAllString(tailoringXML, "${1}>${2}")
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
 }
@@ -0,0 +1,346 @@
+package xccdf
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	cmpv1alpha1 "github.com/JAORMX/compliance-profile-operator/pkg/apis/compliance/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// The documents below mirror the elements of a tailoring for reading it. They
+// only match element names, so tailorings are read whatever the prefix of
+// the XCCDF namespace, if any, is.
+
+type tailoringDocument struct {
+	XMLName   xml.Name          `xml:"Tailoring"`
+	ID        string            `xml:"id,attr"`
+	Benchmark benchmarkDocument `xml:"benchmark"`
+	Version   versionDocument   `xml:"version"`
+	Profiles  []profileDocument `xml:"Profile"`
+}
+
+type benchmarkDocument struct {
+	Href string `xml:"href,attr"`
+}
+
+type versionDocument struct {
+	Time  string `xml:"time,attr"`
+	Value string `xml:",chardata"`
+}
+
+type profileDocument struct {
+	ID           string                `xml:"id,attr"`
+	Extends      string                `xml:"extends,attr"`
+	Titles       []textDocument        `xml:"title"`
+	Descriptions []textDocument        `xml:"description"`
+	Selections   []selectDocument      `xml:"select"`
+	Values       []setValueDocument    `xml:"set-value"`
+	RefineValues []refineValueDocument `xml:"refine-value"`
+	RefineRules  []refineRuleDocument  `xml:"refine-rule"`
+	// Only the metadata written by this project is read
+	ValueRationales []setValueDocument `xml:"metadata>value-rationale"`
+}
+
+type textDocument struct {
+	Lang     string `xml:"lang,attr"`
+	Override bool   `xml:"override,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type selectDocument struct {
+	IDRef    string   `xml:"idref,attr"`
+	Selected bool     `xml:"selected,attr"`
+	Remarks  []string `xml:"remark"`
+}
+
+type setValueDocument struct {
+	IDRef string `xml:"idref,attr"`
+	Value string `xml:",chardata"`
+}
+
+type refineValueDocument struct {
+	IDRef    string   `xml:"idref,attr"`
+	Selector string   `xml:"selector,attr"`
+	Operator string   `xml:"operator,attr"`
+	Remarks  []string `xml:"remark"`
+}
+
+type refineRuleDocument struct {
+	IDRef    string   `xml:"idref,attr"`
+	Weight   string   `xml:"weight,attr"`
+	Selector string   `xml:"selector,attr"`
+	Severity string   `xml:"severity,attr"`
+	Role     string   `xml:"role,attr"`
+	Remarks  []string `xml:"remark"`
+}
+
+// ParseTailoring reads a tailoring file, as written by this operator, SCAP
+// Workbench or autotailor. This is the inverse of rendering a
+// TailoringElement. As a tailoring may hold several profiles, a
+// TailoringElement is returned for each of them.
+func ParseTailoring(r io.Reader) ([]TailoringElement, error) {
+	doc := tailoringDocument{}
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("couldn't parse the tailoring: %s", err)
+	}
+	if len(doc.Profiles) == 0 {
+		return nil, fmt.Errorf("the tailoring %s holds no profile", doc.ID)
+	}
+
+	tailorings := []TailoringElement{}
+	for _, profile := range doc.Profiles {
+		if profile.ID == "" {
+			return nil, fmt.Errorf("a profile of the tailoring %s has no id", doc.ID)
+		}
+		tailorings = append(tailorings, TailoringElement{
+			XMLNamespaceURI: XCCDFURI,
+			ID:              doc.ID,
+			Benchmark:       BenchmarkElement{Href: doc.Benchmark.Href},
+			Version: VersionElement{
+				Time:  doc.Version.Time,
+				Value: strings.TrimSpace(doc.Version.Value),
+			},
+			Profile: profile.toElement(),
+		})
+	}
+	return tailorings, nil
+}
+
+func (p *profileDocument) toElement() ProfileElement {
+	profile := ProfileElement{
+		ID:           p.ID,
+		Extends:      p.Extends,
+		Titles:       []TitleOrDescriptionElement{},
+		Descriptions: []TitleOrDescriptionElement{},
+		Selections:   []SelectElement{},
+		Values:       []SetValueElement{},
+		RefineValues: []RefineValueElement{},
+		RefineRules:  []RefineRuleElement{},
+	}
+	for _, title := range p.Titles {
+		profile.Titles = append(profile.Titles, title.toElement())
+	}
+	for _, description := range p.Descriptions {
+		profile.Descriptions = append(profile.Descriptions, description.toElement())
+	}
+	for _, selection := range p.Selections {
+		profile.Selections = append(profile.Selections, SelectElement{
+			IDRef:    selection.IDRef,
+			Selected: selection.Selected,
+			Remarks:  toRemarkElements(selection.Remarks),
+		})
+	}
+	for _, value := range p.Values {
+		profile.Values = append(profile.Values, SetValueElement{
+			IDRef: value.IDRef,
+			Value: value.Value,
+		})
+	}
+	for _, refinement := range p.RefineValues {
+		profile.RefineValues = append(profile.RefineValues, RefineValueElement{
+			IDRef:    refinement.IDRef,
+			Selector: refinement.Selector,
+			Operator: refinement.Operator,
+			Remarks:  toRemarkElements(refinement.Remarks),
+		})
+	}
+	for _, refinement := range p.RefineRules {
+		profile.RefineRules = append(profile.RefineRules, RefineRuleElement{
+			IDRef:    refinement.IDRef,
+			Weight:   refinement.Weight,
+			Selector: refinement.Selector,
+			Severity: refinement.Severity,
+			Role:     refinement.Role,
+			Remarks:  toRemarkElements(refinement.Remarks),
+		})
+	}
+	if len(p.ValueRationales) > 0 {
+		profile.Metadata = &MetadataElement{XMLNamespaceURI: MetadataURI}
+		for _, rationale := range p.ValueRationales {
+			profile.Metadata.ValueRationales = append(profile.Metadata.ValueRationales, ValueRationaleElement{
+				IDRef: rationale.IDRef,
+				Value: strings.TrimSpace(rationale.Value),
+			})
+		}
+	}
+	return profile
+}
+
+func toRemarkElements(remarks []string) []RemarkElement {
+	var elements []RemarkElement
+	for _, remark := range remarks {
+		elements = append(elements, RemarkElement{Value: strings.TrimSpace(remark)})
+	}
+	return elements
+}
+
+func (t *textDocument) toElement() TitleOrDescriptionElement {
+	return TitleOrDescriptionElement{
+		Lang:     t.Lang,
+		Override: t.Override,
+		Value:    strings.TrimSpace(t.Value),
+	}
+}
+
+// TailoringObjectNames maps the XCCDF IDs of a ProfileBundle's Profiles, Rules
+// and Variables to the names of their objects
+type TailoringObjectNames struct {
+	// The name of the ProfileBundle, which tailored profiles that don't
+	// extend a profile are built from
+	ProfileBundle string
+	Profiles      map[string]string
+	Rules         map[string]string
+	Variables     map[string]string
+}
+
+// GetTailoredProfileNameFromID gets the name of a TailoredProfile from the
+// XCCDF ID of a tailored profile
+func GetTailoredProfileNameFromID(id string) string {
+	name := id
+	if i := strings.Index(id, "_profile_"); i >= 0 {
+		name = id[i+len("_profile_"):]
+	}
+	return strings.ToLower(strings.ReplaceAll(name, "_", "-"))
+}
+
+// TailoringToTailoredProfile turns a parsed tailoring into a TailoredProfile in
+// the given namespace. The XCCDF IDs are mapped back to the names of the
+// Profile, Rule and Variable objects; the IDs that can't be mapped are left
+// out of the TailoredProfile and returned. The remarks become the rationales,
+// which default to mentioning the tailoring they were imported from.
+func TailoringToTailoredProfile(t *TailoringElement, names *TailoringObjectNames, namespace string) (*cmpv1alpha1.TailoredProfile, []string) {
+	unresolved := []string{}
+	defaultRationale := fmt.Sprintf("Imported from the tailoring %s", t.ID)
+	rationale := func(remarks []RemarkElement) string {
+		if len(remarks) == 0 || remarks[0].Value == "" {
+			return defaultRationale
+		}
+		return remarks[0].Value
+	}
+
+	tp := &cmpv1alpha1.TailoredProfile{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "TailoredProfile",
+			APIVersion: cmpv1alpha1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GetTailoredProfileNameFromID(t.Profile.ID),
+			Namespace: namespace,
+		},
+	}
+
+	if t.Profile.Extends == "" {
+		tp.Spec.ProfileBundle = names.ProfileBundle
+	} else if name, ok := names.Profiles[t.Profile.Extends]; ok {
+		tp.Spec.Extends = name
+	} else {
+		unresolved = append(unresolved, t.Profile.Extends)
+	}
+
+	tp.Spec.Title, tp.Spec.TitleTranslations = getTextAndTranslations(t.Profile.Titles)
+	tp.Spec.Description, tp.Spec.DescriptionTranslations = getTextAndTranslations(t.Profile.Descriptions)
+
+	// Later selections of a rule override the earlier ones
+	selected := map[string]bool{}
+	selectionRationales := map[string]string{}
+	ruleNames := []string{}
+	for _, selection := range t.Profile.Selections {
+		name, ok := names.Rules[selection.IDRef]
+		if !ok {
+			unresolved = append(unresolved, selection.IDRef)
+			continue
+		}
+		if _, ok := selected[name]; !ok {
+			ruleNames = append(ruleNames, name)
+		}
+		selected[name] = selection.Selected
+		selectionRationales[name] = rationale(selection.Remarks)
+	}
+	for _, name := range ruleNames {
+		ref := cmpv1alpha1.RuleReferenceSpec{Name: name, Rationale: selectionRationales[name]}
+		if selected[name] {
+			tp.Spec.EnableRules = append(tp.Spec.EnableRules, ref)
+		} else {
+			tp.Spec.DisableRules = append(tp.Spec.DisableRules, ref)
+		}
+	}
+
+	valueRationales := map[string]string{}
+	if t.Profile.Metadata != nil {
+		for _, valueRationale := range t.Profile.Metadata.ValueRationales {
+			valueRationales[valueRationale.IDRef] = valueRationale.Value
+		}
+	}
+	for _, value := range t.Profile.Values {
+		name, ok := names.Variables[value.IDRef]
+		if !ok {
+			unresolved = append(unresolved, value.IDRef)
+			continue
+		}
+		valueRationale := valueRationales[value.IDRef]
+		if valueRationale == "" {
+			valueRationale = defaultRationale
+		}
+		tp.Spec.SetValues = append(tp.Spec.SetValues, cmpv1alpha1.VariableValueSpec{
+			Name:      name,
+			Rationale: valueRationale,
+			Value:     value.Value,
+		})
+	}
+
+	for _, refinement := range t.Profile.RefineRules {
+		name, ok := names.Rules[refinement.IDRef]
+		if !ok {
+			unresolved = append(unresolved, refinement.IDRef)
+			continue
+		}
+		tp.Spec.RefineRules = append(tp.Spec.RefineRules, cmpv1alpha1.RuleRefinementSpec{
+			Name:      name,
+			Rationale: rationale(refinement.Remarks),
+			Severity:  refinement.Severity,
+			Weight:    refinement.Weight,
+			Role:      cmpv1alpha1.RuleRole(refinement.Role),
+			Selector:  refinement.Selector,
+		})
+	}
+
+	for _, refinement := range t.Profile.RefineValues {
+		name, ok := names.Variables[refinement.IDRef]
+		if !ok {
+			unresolved = append(unresolved, refinement.IDRef)
+			continue
+		}
+		tp.Spec.RefineValues = append(tp.Spec.RefineValues, cmpv1alpha1.ValueRefinementSpec{
+			Name:      name,
+			Rationale: rationale(refinement.Remarks),
+			Operator:  cmpv1alpha1.VariableOperator(refinement.Operator),
+			Selector:  refinement.Selector,
+		})
+	}
+
+	return tp, unresolved
+}
+
+// getTextAndTranslations splits the given titles or descriptions into the text
+// in the default language and its translations. Texts without a language are
+// assumed to be in the default language.
+func getTextAndTranslations(elements []TitleOrDescriptionElement) (string, cmpv1alpha1.LocalizedTexts) {
+	var text string
+	var translations cmpv1alpha1.LocalizedTexts
+	for _, element := range elements {
+		if element.Lang == "" || element.Lang == DefaultLang {
+			if text == "" {
+				text = element.Value
+			}
+			continue
+		}
+		if translations == nil {
+			translations = cmpv1alpha1.LocalizedTexts{}
+		}
+		translations[element.Lang] = element.Value
+	}
+	return text, translations
+}
@@ -0,0 +1,167 @@
+package xccdf
+
+import (
+	"strings"
+
+	cmpv1alpha1 "github.com/JAORMX/compliance-profile-operator/pkg/apis/compliance/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const workbenchTailoring = `<?xml version="1.0" encoding="UTF-8"?>
+<Tailoring xmlns="http://checklists.nist.gov/xccdf/1.2" id="xccdf_scap-workbench_tailoring_default">
+  <benchmark href="/usr/share/xml/scap/ssg/content/ssg-ocp4-ds.xml"/>
+  <version time="2020-05-04T10:30:00">1</version>
+  <Profile id="xccdf_org.ssgproject.content_profile_moderate_customized" extends="xccdf_org.ssgproject.content_profile_moderate">
+    <title xmlns:xhtml="http://www.w3.org/1999/xhtml" xml:lang="en-US" override="true">Moderate [CUSTOMIZED]</title>
+    <title xml:lang="fr-FR" override="true">Modéré</title>
+    <select idref="xccdf_org.ssgproject.content_rule_foo" selected="true"/>
+    <select idref="xccdf_org.ssgproject.content_rule_bar" selected="true">
+      <remark>Required by our auditors</remark>
+    </select>
+    <select idref="xccdf_org.ssgproject.content_rule_foo" selected="false"/>
+    <select idref="xccdf_org.ssgproject.content_rule_missing" selected="true"/>
+    <set-value idref="xccdf_org.ssgproject.content_value_timeout">600</set-value>
+    <set-value idref="xccdf_org.ssgproject.content_value_missing">1</set-value>
+  </Profile>
+</Tailoring>`
+
+var _ = Describe("Testing importing tailorings", func() {
+	var names *TailoringObjectNames
+
+	BeforeEach(func() {
+		names = &TailoringObjectNames{
+			ProfileBundle: "ocp4",
+			Profiles: map[string]string{
+				"xccdf_org.ssgproject.content_profile_moderate": "ocp4-moderate",
+			},
+			Rules: map[string]string{
+				"xccdf_org.ssgproject.content_rule_foo": "ocp4-foo",
+				"xccdf_org.ssgproject.content_rule_bar": "ocp4-bar",
+			},
+			Variables: map[string]string{
+				"xccdf_org.ssgproject.content_value_timeout": "ocp4-timeout",
+			},
+		}
+	})
+
+	Context("a tailoring written by SCAP Workbench", func() {
+		var (
+			tp         *cmpv1alpha1.TailoredProfile
+			unresolved []string
+		)
+
+		BeforeEach(func() {
+			tailorings, err := ParseTailoring(strings.NewReader(workbenchTailoring))
+			Expect(err).To(BeNil())
+			Expect(tailorings).To(HaveLen(1))
+			Expect(tailorings[0].Version.Value).To(Equal("1"))
+			tp, unresolved = TailoringToTailoredProfile(&tailorings[0], names, "openshift-compliance")
+		})
+
+		It("names the tailored profile after the profile", func() {
+			Expect(tp.Name).To(Equal("moderate-customized"))
+			Expect(tp.Namespace).To(Equal("openshift-compliance"))
+		})
+
+		It("maps the extended profile back to its object", func() {
+			Expect(tp.Spec.Extends).To(Equal("ocp4-moderate"))
+			Expect(tp.Spec.ProfileBundle).To(BeEmpty())
+		})
+
+		It("imports the title with its translations", func() {
+			Expect(tp.Spec.Title).To(Equal("Moderate [CUSTOMIZED]"))
+			Expect(tp.Spec.TitleTranslations).To(Equal(cmpv1alpha1.LocalizedTexts{"fr-FR": "Modéré"}))
+		})
+
+		It("imports the remarks as rationales", func() {
+			Expect(tp.Spec.EnableRules[0].Rationale).To(Equal("Required by our auditors"))
+			Expect(tp.Spec.DisableRules[0].Rationale).To(Equal("Imported from the tailoring xccdf_scap-workbench_tailoring_default"))
+		})
+
+		It("keeps the last selection of a rule", func() {
+			Expect(tp.Spec.EnableRules).To(HaveLen(1))
+			Expect(tp.Spec.EnableRules[0].Name).To(Equal("ocp4-bar"))
+			Expect(tp.Spec.DisableRules).To(HaveLen(1))
+			Expect(tp.Spec.DisableRules[0].Name).To(Equal("ocp4-foo"))
+		})
+
+		It("maps the values back to their variables", func() {
+			Expect(tp.Spec.SetValues).To(HaveLen(1))
+			Expect(tp.Spec.SetValues[0].Name).To(Equal("ocp4-timeout"))
+			Expect(tp.Spec.SetValues[0].Value).To(Equal("600"))
+		})
+
+		It("reports the unresolved IDs", func() {
+			Expect(unresolved).To(ConsistOf(
+				"xccdf_org.ssgproject.content_rule_missing",
+				"xccdf_org.ssgproject.content_value_missing"))
+		})
+	})
+
+	Context("a tailoring rendered by the operator", func() {
+		It("is imported back into the same spec", func() {
+			tp := &cmpv1alpha1.TailoredProfile{
+				ObjectMeta: v1.ObjectMeta{Name: "my-profile"},
+				Spec: cmpv1alpha1.TailoredProfileSpec{
+					Extends:     "ocp4-moderate",
+					Title:       "My profile",
+					Description: "My description",
+					EnableRules: []cmpv1alpha1.RuleReferenceSpec{{Name: "ocp4-bar", Rationale: "Enabled"}},
+					DisableRules: []cmpv1alpha1.RuleReferenceSpec{
+						{Name: "ocp4-foo"},
+					},
+					RefineRules: []cmpv1alpha1.RuleRefinementSpec{
+						{Name: "ocp4-foo", Severity: "high", Role: cmpv1alpha1.RuleRoleUnscored},
+					},
+					RefineValues: []cmpv1alpha1.ValueRefinementSpec{
+						{Name: "ocp4-timeout", Operator: cmpv1alpha1.VarOpGreaterThan},
+					},
+					SetValues: []cmpv1alpha1.VariableValueSpec{
+						{Name: "ocp4-timeout", Value: "600", Rationale: "Longer sessions"},
+					},
+				},
+			}
+			p := &cmpv1alpha1.Profile{ID: "xccdf_org.ssgproject.content_profile_moderate"}
+			pb := &cmpv1alpha1.ProfileBundle{Spec: cmpv1alpha1.ProfileBundleSpec{ContentFile: "ssg-ocp4-ds.xml"}}
+			rules := map[string]*cmpv1alpha1.Rule{
+				"ocp4-foo": {ID: "xccdf_org.ssgproject.content_rule_foo"},
+				"ocp4-bar": {ID: "xccdf_org.ssgproject.content_rule_bar"},
+			}
+			variables := []*cmpv1alpha1.Variable{
+				{ObjectMeta: v1.ObjectMeta{Name: "ocp4-timeout"}, ID: "xccdf_org.ssgproject.content_value_timeout", Value: "600"},
+			}
+			refinedVariables := map[string]*cmpv1alpha1.Variable{"ocp4-timeout": variables[0]}
+
+			tailoring, err := TailoredProfileToXML(tp, p, pb, rules, variables, refinedVariables)
+			Expect(err).To(BeNil())
+			tailorings, err := ParseTailoring(strings.NewReader(tailoring))
+			Expect(err).To(BeNil())
+			Expect(tailorings).To(HaveLen(1))
+
+			imported, unresolved := TailoringToTailoredProfile(&tailorings[0], names, "")
+			Expect(unresolved).To(BeEmpty())
+			Expect(imported.Name).To(Equal("my-profile"))
+			Expect(imported.Spec.Extends).To(Equal("ocp4-moderate"))
+			Expect(imported.Spec.Title).To(Equal("My profile"))
+			Expect(imported.Spec.Description).To(Equal("My description"))
+			Expect(imported.Spec.EnableRules[0].Name).To(Equal("ocp4-bar"))
+			Expect(imported.Spec.EnableRules[0].Rationale).To(Equal("Enabled"))
+			Expect(imported.Spec.DisableRules[0].Name).To(Equal("ocp4-foo"))
+			Expect(imported.Spec.SetValues[0].Value).To(Equal("600"))
+			Expect(imported.Spec.SetValues[0].Rationale).To(Equal("Longer sessions"))
+			Expect(imported.Spec.RefineRules[0].Severity).To(Equal("high"))
+			Expect(imported.Spec.RefineRules[0].Role).To(Equal(cmpv1alpha1.RuleRoleUnscored))
+			Expect(imported.Spec.RefineValues[0].Operator).To(Equal(cmpv1alpha1.VarOpGreaterThan))
+		})
+	})
+
+	Context("a file that isn't a tailoring", func() {
+		It("is rejected", func() {
+			_, err := ParseTailoring(strings.NewReader(`<Benchmark id="foo"></Benchmark>`))
+			Expect(err).ToNot(BeNil())
+		})
+	})
+})
@@ -1,6 +1,9 @@
 package xccdf
 
 import (
+	"strings"
+	"time"
+
 	cmpv1alpha1 "github.com/JAORMX/compliance-profile-operator/pkg/apis/compliance/v1alpha1"
 	"github.com/subchen/go-xmldom"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -32,6 +35,28 @@ func findVariablesInTailoring(tailoring string) ([]tailoredValue, error) {
 	return tailoredVars, nil
 }
 
+type tailoredText struct {
+	Lang  string
+	Value string
+}
+
+func findTitlesInTailoring(tailoring string) ([]tailoredText, error) {
+	tailoringDom, err := xmldom.ParseXML(tailoring)
+	if err != nil {
+		return nil, err
+	}
+
+	titles := []tailoredText{}
+	for _, titleNode := range tailoringDom.Root.Query("//title") {
+		titles = append(titles, tailoredText{
+			Lang:  titleNode.GetAttributeValue("lang"),
+			Value: titleNode.Text,
+		})
+	}
+
+	return titles, nil
+}
+
 var _ = Describe("Testing parse variables", func() {
 	var (
 		tp        *cmpv1alpha1.TailoredProfile
@@ -84,7 +109,7 @@ var _ = Describe("Testing parse variables", func() {
 		})
 
 		JustBeforeEach(func() {
-			tailoring, err = TailoredProfileToXML(tp, p, pb, nil, variables)
+			tailoring, err = TailoredProfileToXML(tp, p, pb, nil, variables, nil)
 			Expect(err).To(BeNil())
 		})
 
@@ -97,5 +122,222 @@ var _ = Describe("Testing parse variables", func() {
 				tailoredValue{ID: "bar_id", Value: "3"},
 				tailoredValue{ID: "baz_id", Value: "true"}))
 		})
+
+		It("sorts the variables by ID", func() {
+			tailoredVars, err := findVariablesInTailoring(tailoring)
+			Expect(err).To(BeNil())
+			Expect(tailoredVars).To(Equal([]tailoredValue{
+				{ID: "bar_id", Value: "3"},
+				{ID: "baz_id", Value: "true"},
+				{ID: "foo_id", Value: "fooval"},
+			}))
+		})
+	})
+
+	Context("tailoring titles", func() {
+		BeforeEach(func() {
+			tp.Spec.Title = "My profile"
+			tp.Spec.TitleTranslations = cmpv1alpha1.LocalizedTexts{
+				"fr-FR": "Mon profil",
+				"de-DE": "Mein Profil",
+			}
+		})
+
+		JustBeforeEach(func() {
+			tailoring, err = TailoredProfileToXML(tp, p, pb, nil, nil, nil)
+			Expect(err).To(BeNil())
+		})
+
+		It("inherits the title of the extended profile if it isn't set", func() {
+			tp.Spec.Title = ""
+			tp.Spec.TitleTranslations = nil
+			tailoring, err = TailoredProfileToXML(tp, p, pb, nil, nil, nil)
+			Expect(err).To(BeNil())
+			titles, err := findTitlesInTailoring(tailoring)
+			Expect(err).To(BeNil())
+			Expect(titles).To(BeEmpty())
+		})
+
+		It("renders the title with all its translations", func() {
+			titles, err := findTitlesInTailoring(tailoring)
+			Expect(err).To(BeNil())
+			Expect(titles).To(Equal([]tailoredText{
+				{Lang: "en-US", Value: "My profile"},
+				{Lang: "de-DE", Value: "Mein Profil"},
+				{Lang: "fr-FR", Value: "Mon profil"},
+			}))
+		})
+
+		It("marks the language with xml:lang", func() {
+			Expect(tailoring).To(ContainSubstring(`xml:lang="de-DE"`))
+		})
+	})
+
+	Context("tailoring content hash", func() {
+		JustBeforeEach(func() {
+			tailoring, err = TailoredProfileToXML(tp, p, pb, nil, nil, nil)
+			Expect(err).To(BeNil())
+		})
+
+		It("ignores the generation time", func() {
+			later := strings.Replace(tailoring, `time="`, `time="1`, 1)
+			Expect(later).ToNot(Equal(tailoring))
+			Expect(GetTailoringContentHash(later)).To(Equal(GetTailoringContentHash(tailoring)))
+		})
+
+		It("ignores the version", func() {
+			tp.Status.Version = 2
+			newer, err := TailoredProfileToXML(tp, p, pb, nil, nil, nil)
+			Expect(err).To(BeNil())
+			Expect(newer).ToNot(Equal(tailoring))
+			Expect(GetTailoringContentHash(newer)).To(Equal(GetTailoringContentHash(tailoring)))
+		})
+
+		It("changes with the content", func() {
+			tp.Spec.Title = "Another title"
+			changed, err := TailoredProfileToXML(tp, p, pb, nil, nil, nil)
+			Expect(err).To(BeNil())
+			Expect(GetTailoringContentHash(changed)).ToNot(Equal(GetTailoringContentHash(tailoring)))
+		})
+	})
+
+	Context("tailoring version", func() {
+		It("starts at the first version", func() {
+			tailoring, err = TailoredProfileToXML(tp, p, pb, nil, nil, nil)
+			Expect(err).To(BeNil())
+			Expect(tailoring).To(MatchRegexp(`<xccdf-1.2:version time="[^"]+">1</xccdf-1.2:version>`))
+		})
+
+		It("takes the version and its time from the status", func() {
+			versionTime := v1.NewTime(time.Date(2020, time.May, 4, 10, 30, 0, 0, time.UTC))
+			tp.Status.Version = 3
+			tp.Status.VersionTime = &versionTime
+			tailoring, err = TailoredProfileToXML(tp, p, pb, nil, nil, nil)
+			Expect(err).To(BeNil())
+			Expect(tailoring).To(ContainSubstring(`<xccdf-1.2:version time="2020-05-04T10:30:00Z">3</xccdf-1.2:version>`))
+
+			again, err := TailoredProfileToXML(tp, p, pb, nil, nil, nil)
+			Expect(err).To(BeNil())
+			Expect(again).To(Equal(tailoring))
+		})
+	})
+
+	Context("getting short names from IDs", func() {
+		It("strips the prefix of the ID", func() {
+			Expect(GetShortNameFromID("xccdf_org.ssgproject.content_rule_chronyd_client_only")).To(Equal("chronyd_client_only"))
+			Expect(GetShortNameFromID("xccdf_org.ssgproject.content_value_var_sshd_set_keepalive")).To(Equal("var_sshd_set_keepalive"))
+		})
+
+		It("takes the namespace from the ID", func() {
+			Expect(GetShortNameFromID("xccdf_com.example.www_rule_no_telnet")).To(Equal("no_telnet"))
+		})
+
+		It("normalizes dashes", func() {
+			Expect(GetShortNameFromID("xccdf_com.example_rule_no-telnet")).To(Equal("no_telnet"))
+			Expect(NormalizeShortName("chronyd-client-only")).To(Equal("chronyd_client_only"))
+		})
+
+		It("gives no short name for other IDs", func() {
+			Expect(GetShortNameFromID("chronyd_client_only")).To(BeEmpty())
+			Expect(GetShortNameFromID("xccdf_org.ssgproject.content_rule")).To(BeEmpty())
+		})
+	})
+
+	Context("tailoring from scratch", func() {
+		JustBeforeEach(func() {
+			tailoring, err = TailoredProfileToXML(tp, nil, pb, nil, nil, nil)
+			Expect(err).To(BeNil())
+		})
+
+		It("doesn't extend any profile", func() {
+			Expect(tailoring).ToNot(ContainSubstring("extends="))
+		})
+
+		It("defaults the title to the name of the TailoredProfile", func() {
+			titles, err := findTitlesInTailoring(tailoring)
+			Expect(err).To(BeNil())
+			Expect(titles).To(Equal([]tailoredText{{Lang: "en-US", Value: "tailoredProfileName"}}))
+		})
+
+		It("keeps the title that is set", func() {
+			tp.Spec.Title = "My profile"
+			tailoring, err = TailoredProfileToXML(tp, nil, pb, nil, nil, nil)
+			Expect(err).To(BeNil())
+			titles, err := findTitlesInTailoring(tailoring)
+			Expect(err).To(BeNil())
+			Expect(titles).To(Equal([]tailoredText{{Lang: "en-US", Value: "My profile"}}))
+		})
+	})
+
+	Context("tailoring rationales", func() {
+		var report string
+
+		BeforeEach(func() {
+			tp.Spec.DisableRules = []cmpv1alpha1.RuleReferenceSpec{
+				{Name: "rule-foo", Rationale: "Not applicable to our clusters"},
+			}
+			tp.Spec.SetValues = []cmpv1alpha1.VariableValueSpec{
+				{Name: "var-foo", Value: "600", Rationale: "Our sessions last longer"},
+			}
+			tp.Spec.RefineRules = []cmpv1alpha1.RuleRefinementSpec{
+				{Name: "rule-foo", Severity: "low", Rationale: "Mitigated elsewhere"},
+			}
+		})
+
+		JustBeforeEach(func() {
+			rules := map[string]*cmpv1alpha1.Rule{"rule-foo": {ID: "rule_foo_id", Title: "Foo rule"}}
+			variables := []*cmpv1alpha1.Variable{
+				{ObjectMeta: v1.ObjectMeta{Name: "var-foo"}, ID: "var_foo_id", Value: "600"},
+			}
+			tailoring, err = TailoredProfileToXML(tp, p, pb, rules, variables, nil)
+			Expect(err).To(BeNil())
+			report = TailoredProfileToJustificationReport(tp, p, pb, rules, variables, nil)
+		})
+
+		It("renders the selection rationale as a remark", func() {
+			Expect(tailoring).To(MatchRegexp(`<xccdf-1.2:select idref="rule_foo_id" selected="false">\s*<xccdf-1.2:remark>Not applicable to our clusters</xccdf-1.2:remark>`))
+		})
+
+		It("renders the refinement rationale as a remark", func() {
+			Expect(tailoring).To(MatchRegexp(`<xccdf-1.2:refine-rule idref="rule_foo_id" severity="low">\s*<xccdf-1.2:remark>Mitigated elsewhere</xccdf-1.2:remark>`))
+		})
+
+		It("renders the value rationale in the metadata", func() {
+			Expect(tailoring).To(ContainSubstring(`<xccdf-1.2:metadata xmlns:cpo="http://compliance.openshift.io/tailoring">`))
+			Expect(tailoring).To(ContainSubstring(`<cpo:value-rationale idref="var_foo_id">Our sessions last longer</cpo:value-rationale>`))
+		})
+
+		It("writes the rationales to the justification report", func() {
+			Expect(report).To(ContainSubstring("## Disabled rules\n\n* `rule_foo_id` Foo rule: Not applicable to our clusters\n"))
+			Expect(report).To(ContainSubstring("## Set values\n\n* `var_foo_id` (set to \"600\"): Our sessions last longer\n"))
+			Expect(report).To(ContainSubstring("## Refined rules\n\n* `rule_foo_id` Foo rule (severity low): Mitigated elsewhere\n"))
+			Expect(report).ToNot(ContainSubstring("## Enabled rules"))
+		})
+	})
+
+	Context("tailoring refinements", func() {
+		BeforeEach(func() {
+			tp.Spec.RefineRules = []cmpv1alpha1.RuleRefinementSpec{
+				{Name: "rule-foo", Severity: "high", Role: cmpv1alpha1.RuleRoleUnscored},
+			}
+			tp.Spec.RefineValues = []cmpv1alpha1.ValueRefinementSpec{
+				{Name: "var-foo", Selector: "10_minutes"},
+			}
+		})
+
+		JustBeforeEach(func() {
+			rules := map[string]*cmpv1alpha1.Rule{"rule-foo": {ID: "rule_foo_id"}}
+			refinedVariables := map[string]*cmpv1alpha1.Variable{"var-foo": {ID: "var_foo_id"}}
+			tailoring, err = TailoredProfileToXML(tp, p, pb, rules, nil, refinedVariables)
+			Expect(err).To(BeNil())
+		})
+
+		It("renders the rule refinements", func() {
+			Expect(tailoring).To(ContainSubstring(`<xccdf-1.2:refine-rule idref="rule_foo_id" severity="high" role="unscored"></xccdf-1.2:refine-rule>`))
+		})
+
+		It("renders the value refinements", func() {
+			Expect(tailoring).To(ContainSubstring(`<xccdf-1.2:refine-value idref="var_foo_id" selector="10_minutes"></xccdf-1.2:refine-value>`))
+		})
 	})
 })